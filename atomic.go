@@ -0,0 +1,78 @@
+package migrator
+
+import (
+	"database/sql"
+	"fmt"
+)
+
+// migrateAtomic runs pending migrations and stores them in migration table within one transaction.
+// Nothing is migrated if any of the migrations fails.
+// Batch runs within a single transaction of the pool, so session variables of migrations can't be applied.
+func (m Migrator) migrateAtomic(db *sql.DB, pending []Migration, schemas []Schema, batch uint64) (migrated []string, err error) {
+	for i, item := range pending {
+		if len(item.Session) > 0 {
+			return migrated, fmt.Errorf(`Migration "%s" has session variables and can't be run atomically`, item.Name)
+		}
+
+		for _, c := range schemas[i].pool {
+			if m.dialect().commitsImplicitly(c) {
+				return migrated, fmt.Errorf(`Migration "%s" contains commands with implicit commit and can't be run atomically`, item.Name)
+			}
+		}
+	}
+
+	if len(pending) == 0 {
+		return migrated, nil
+	}
+
+	err = m.Retry.transaction(func() error {
+		migrated, err = m.runAtomic(db, pending, schemas, batch)
+		return err
+	}, m.Retry.retryable)
+
+	return migrated, err
+}
+
+func (m Migrator) runAtomic(db *sql.DB, pending []Migration, schemas []Schema, batch uint64) (migrated []string, err error) {
+	tx, err := db.Begin()
+	if err != nil {
+		return nil, err
+	}
+
+	for i, item := range pending {
+		commands, err := m.commands(schemas[i])
+		if err != nil {
+			tx.Rollback()
+			return nil, err
+		}
+
+		if err := run(tx, commands...); err != nil {
+			tx.Rollback()
+			return nil, err
+		}
+
+		if err := m.insertEntry(tx, item.Name, batch); err != nil {
+			tx.Rollback()
+			return nil, err
+		}
+
+		migrated = append(migrated, item.Name)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, commitError{err}
+	}
+
+	return migrated, nil
+}
+
+// commitsImplicitly reports whether command is known to cause an implicit commit in MySQL.
+// https://dev.mysql.com/doc/refman/8.0/en/implicit-commit.html
+func commitsImplicitly(c command) bool {
+	switch c.(type) {
+	case createTableCommand, dropTableCommand, renameTableCommand, alterTableCommand:
+		return true
+	}
+
+	return false
+}
@@ -0,0 +1,178 @@
+package migrator
+
+import (
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMigrateAtomic(t *testing.T) {
+	dataMigration := func(name string, sql string) Migration {
+		return Migration{Name: name, Up: func() Schema {
+			var s Schema
+			s.CustomCommand(testDummyCommand(sql))
+			return s
+		}}
+	}
+	rows := func() *sqlmock.Rows {
+		return sqlmock.NewRows([]string{"id", "name", "batch", "applied_at"}).AddRow(1, "done", 1, time.Now())
+	}
+
+	t.Run("it refuses migrations with implicit commit", func(t *testing.T) {
+		m := Migrator{Atomic: true, Pool: []Migration{
+			dataMigration("first", "UPDATE posts SET active = 1"),
+			{Name: "second", Up: func() Schema {
+				var s Schema
+				s.DropTableIfExists("posts")
+				return s
+			}},
+		}}
+		db, mock, resetDB := testDBConnection(t)
+		defer resetDB()
+
+		mock.ExpectQuery("SELECT").WillReturnRows(sqlmock.NewRows(nil))
+		mock.ExpectQuery("SELECT id, name, batch, applied_at FROM `migrations`").WillReturnRows(rows())
+
+		migrated, err := m.Migrate(db)
+
+		assert.Len(t, migrated, 0)
+		assert.Error(t, err)
+		assert.Equal(t, `Migration "second" contains commands with implicit commit and can't be run atomically`, err.Error())
+	})
+
+	t.Run("it refuses migrations with session variables", func(t *testing.T) {
+		withSession := dataMigration("first", "UPDATE posts SET active = 1")
+		withSession.Session = map[string]string{"foreign_key_checks": "0"}
+		m := Migrator{Atomic: true, Pool: []Migration{withSession}}
+		db, mock, resetDB := testDBConnection(t)
+		defer resetDB()
+
+		mock.ExpectQuery("SELECT").WillReturnRows(sqlmock.NewRows(nil))
+		mock.ExpectQuery("SELECT id, name, batch, applied_at FROM `migrations`").WillReturnRows(rows())
+
+		migrated, err := m.Migrate(db)
+
+		assert.Len(t, migrated, 0)
+		assert.EqualError(t, err, `Migration "first" has session variables and can't be run atomically`)
+	})
+
+	t.Run("it fails executing empty list of migrations", func(t *testing.T) {
+		m := Migrator{Atomic: true, Pool: []Migration{{Name: "test", Up: func() Schema { return Schema{} }}}}
+		db, mock, resetDB := testDBConnection(t)
+		defer resetDB()
+
+		mock.ExpectQuery("SELECT").WillReturnRows(sqlmock.NewRows(nil))
+		mock.ExpectQuery("SELECT id, name, batch, applied_at FROM `migrations`").WillReturnRows(rows())
+
+		migrated, err := m.Migrate(db)
+
+		assert.Len(t, migrated, 0)
+		assert.Equal(t, ErrNoSQLCommandsToRun, err)
+	})
+
+	t.Run("it does nothing when everything is migrated", func(t *testing.T) {
+		m := Migrator{Atomic: true, Pool: []Migration{dataMigration("done", "UPDATE posts SET active = 1")}}
+		db, mock, resetDB := testDBConnection(t)
+		defer resetDB()
+
+		mock.ExpectQuery("SELECT").WillReturnRows(sqlmock.NewRows(nil))
+		mock.ExpectQuery("SELECT id, name, batch, applied_at FROM `migrations`").WillReturnRows(rows())
+
+		migrated, err := m.Migrate(db)
+
+		assert.Len(t, migrated, 0)
+		assert.Nil(t, err)
+	})
+
+	t.Run("it rolls back everything when one migration fails", func(t *testing.T) {
+		m := Migrator{Atomic: true, Pool: []Migration{
+			dataMigration("first", "UPDATE posts SET active = 1"),
+			dataMigration("second", "UPDATE comments SET active = 1"),
+		}}
+		db, mock, resetDB := testDBConnection(t)
+		defer resetDB()
+
+		mock.ExpectQuery("SELECT").WillReturnRows(sqlmock.NewRows(nil))
+		mock.ExpectQuery("SELECT id, name, batch, applied_at FROM `migrations`").WillReturnRows(rows())
+		mock.ExpectBegin()
+		mock.ExpectExec("UPDATE posts").WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(`INSERT .* VALUES \("first", 2\)`).WillReturnResult(sqlmock.NewResult(2, 1))
+		mock.ExpectExec("UPDATE comments").WillReturnError(errTestDBExecFailed)
+		mock.ExpectRollback()
+
+		migrated, err := m.Migrate(db)
+
+		assert.Len(t, migrated, 0)
+		assert.Equal(t, errTestDBExecFailed, err)
+	})
+
+	t.Run("it rolls back everything when migration can't be stored", func(t *testing.T) {
+		m := Migrator{Atomic: true, Pool: []Migration{dataMigration("first", "UPDATE posts SET active = 1")}}
+		db, mock, resetDB := testDBConnection(t)
+		defer resetDB()
+
+		mock.ExpectQuery("SELECT").WillReturnRows(sqlmock.NewRows(nil))
+		mock.ExpectQuery("SELECT id, name, batch, applied_at FROM `migrations`").WillReturnRows(rows())
+		mock.ExpectBegin()
+		mock.ExpectExec("UPDATE posts").WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec("INSERT").WillReturnError(errTestDBExecFailed)
+		mock.ExpectRollback()
+
+		migrated, err := m.Migrate(db)
+
+		assert.Len(t, migrated, 0)
+		assert.Equal(t, errTestDBExecFailed, err)
+	})
+
+	t.Run("it fails when transaction can't be committed", func(t *testing.T) {
+		m := Migrator{Atomic: true, Pool: []Migration{dataMigration("first", "UPDATE posts SET active = 1")}}
+		db, mock, resetDB := testDBConnection(t)
+		defer resetDB()
+
+		mock.ExpectQuery("SELECT").WillReturnRows(sqlmock.NewRows(nil))
+		mock.ExpectQuery("SELECT id, name, batch, applied_at FROM `migrations`").WillReturnRows(rows())
+		mock.ExpectBegin()
+		mock.ExpectExec("UPDATE posts").WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec("INSERT").WillReturnResult(sqlmock.NewResult(2, 1))
+		mock.ExpectCommit().WillReturnError(errTestDBTransactionFailed)
+
+		migrated, err := m.Migrate(db)
+
+		assert.Len(t, migrated, 0)
+		assert.Equal(t, errTestDBTransactionFailed, err)
+	})
+
+	t.Run("it migrates all pending migrations in one transaction", func(t *testing.T) {
+		m := Migrator{Atomic: true, Pool: []Migration{
+			dataMigration("done", "UPDATE users SET active = 1"),
+			dataMigration("first", "UPDATE posts SET active = 1"),
+			dataMigration("second", "UPDATE comments SET active = 1"),
+		}}
+		db, mock, resetDB := testDBConnection(t)
+		defer resetDB()
+
+		mock.ExpectQuery("SELECT").WillReturnRows(sqlmock.NewRows(nil))
+		mock.ExpectQuery("SELECT id, name, batch, applied_at FROM `migrations`").WillReturnRows(rows())
+		mock.ExpectBegin()
+		mock.ExpectExec("UPDATE posts").WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(`INSERT .* VALUES \("first", 2\)`).WillReturnResult(sqlmock.NewResult(2, 1))
+		mock.ExpectExec("UPDATE comments").WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(`INSERT .* VALUES \("second", 2\)`).WillReturnResult(sqlmock.NewResult(3, 1))
+		mock.ExpectCommit()
+
+		migrated, err := m.Migrate(db)
+
+		assert.Nil(t, err)
+		assert.Equal(t, []string{"first", "second"}, migrated)
+	})
+}
+
+func TestCommitsImplicitly(t *testing.T) {
+	assert.True(t, commitsImplicitly(createTableCommand{}))
+	assert.True(t, commitsImplicitly(dropTableCommand{}))
+	assert.True(t, commitsImplicitly(renameTableCommand{}))
+	assert.True(t, commitsImplicitly(alterTableCommand{}))
+	assert.False(t, commitsImplicitly(testDummyCommand("UPDATE posts SET active = 1")))
+}
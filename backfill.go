@@ -0,0 +1,174 @@
+package migrator
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// BackfillCommand is a command to update existing rows of the table in chunks.
+// It is executed as repeated `UPDATE ... WHERE key BETWEEN ? AND ?` statements,
+// so the table isn't locked for the whole update. Bounds of every chunk are read from the table,
+// so sparse keys don't produce empty chunks.
+//
+// - Table		table to be updated
+// - Set		assignment list of UPDATE statement, e.g. `rating = 0`
+// - Where		optional condition to filter rows
+// - Key		numeric primary key column, default: id
+// - ChunkSize	amount of rows to be updated at once, default: 1000
+// - Sleep		pause between chunks
+// - Progress	optional hook called after every chunk with the last updated key and the biggest key
+type BackfillCommand struct {
+	Table     string
+	Set       string
+	Where     string
+	Key       string
+	ChunkSize uint64
+	Sleep     time.Duration
+	Progress  func(table string, last uint64, max uint64)
+
+	dialect Dialect
+}
+
+func (c BackfillCommand) quote(name string) string {
+	if c.dialect == nil {
+		return MySQL.quote(name)
+	}
+
+	return c.dialect.quote(name)
+}
+
+func (c BackfillCommand) key() string {
+	if c.Key == "" {
+		return "id"
+	}
+
+	return c.Key
+}
+
+func (c BackfillCommand) chunkSize() uint64 {
+	if c.ChunkSize == 0 {
+		return 1000
+	}
+
+	return c.ChunkSize
+}
+
+// toSQL renders the whole update as a single statement, while it is executed in chunks
+func (c BackfillCommand) toSQL() string {
+	if c.Table == "" || c.Set == "" {
+		return ""
+	}
+
+	sql := fmt.Sprintf("UPDATE %s SET %s", c.quote(c.Table), c.Set)
+	if c.Where != "" {
+		sql += " WHERE " + c.Where
+	}
+
+	return sql
+}
+
+func (c BackfillCommand) chunkSQL() string {
+	sql := fmt.Sprintf("UPDATE %s SET %s WHERE %s BETWEEN ? AND ?", c.quote(c.Table), c.Set, c.quote(c.key()))
+	if c.Where != "" {
+		sql += " AND (" + c.Where + ")"
+	}
+
+	if c.dialect == nil {
+		return sql
+	}
+
+	return c.dialect.rebind(sql)
+}
+
+func (c BackfillCommand) chunkEndSQL() string {
+	sql := chunkEndSQL(c.quote(c.Table), c.quote(c.key()), c.chunkSize())
+	if c.dialect == nil {
+		return sql
+	}
+
+	return c.dialect.rebind(sql)
+}
+
+func (c BackfillCommand) exec(db executableSQL) error {
+	if c.toSQL() == "" {
+		return ErrNoSQLCommandsToRun
+	}
+
+	var min, max *uint64
+	sql := fmt.Sprintf("SELECT MIN(%s), MAX(%s) FROM %s", c.quote(c.key()), c.quote(c.key()), c.quote(c.Table))
+	if err := db.QueryRow(sql).Scan(&min, &max); err != nil {
+		return err
+	}
+
+	if min == nil || max == nil {
+		return nil
+	}
+
+	for from := *min; ; {
+		to, err := chunkEnd(db, c.chunkEndSQL(), from, *max, c.chunkSize())
+		if err != nil {
+			return err
+		}
+
+		if err := c.update(db, from, to); err != nil {
+			return err
+		}
+
+		if c.Progress != nil {
+			c.Progress(c.Table, to, *max)
+		}
+
+		if to >= *max {
+			return nil
+		}
+
+		if c.Sleep > 0 {
+			sleep(c.Sleep)
+		}
+
+		from = to + 1
+	}
+}
+
+// chunkEndSQL selects the key of the last row of the chunk, that starts with the given key
+func chunkEndSQL(table string, key string, size uint64) string {
+	return fmt.Sprintf("SELECT %s FROM %s WHERE %s >= ? ORDER BY %s LIMIT 1 OFFSET %d", key, table, key, key, size-1)
+}
+
+// chunkEnd returns the key of the last row of the chunk, that starts with the given key.
+// The biggest key is returned for the last chunk, so keys are never incremented past it.
+func chunkEnd(db executableSQL, query string, from uint64, max uint64, size uint64) (uint64, error) {
+	if max-from < size {
+		return max, nil
+	}
+
+	var to uint64
+	err := db.QueryRow(query, from).Scan(&to)
+	if err == sql.ErrNoRows || (err == nil && to > max) {
+		return max, nil
+	}
+
+	return to, err
+}
+
+// update runs a chunk within its own transaction, if it isn't running in transaction already
+func (c BackfillCommand) update(db executableSQL, from uint64, to uint64) error {
+	pool, ok := db.(transactableSQL)
+	if !ok {
+		_, err := db.Exec(c.chunkSQL(), from, to)
+		return err
+	}
+
+	tx, err := pool.Begin()
+	if err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec(c.chunkSQL(), from, to); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
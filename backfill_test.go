@@ -0,0 +1,163 @@
+package migrator
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"math"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBackfillCommand(t *testing.T) {
+	t.Run("it returns an empty command if table is missing", func(t *testing.T) {
+		c := BackfillCommand{Set: "rating = 0"}
+
+		assert.Equal(t, "", c.toSQL())
+	})
+
+	t.Run("it returns an empty command if assignment is missing", func(t *testing.T) {
+		c := BackfillCommand{Table: "comments"}
+
+		assert.Equal(t, "", c.toSQL())
+	})
+
+	t.Run("it renders the whole update", func(t *testing.T) {
+		c := BackfillCommand{Table: "comments", Set: "rating = 0", Where: "rating IS NULL"}
+
+		assert.Equal(t, "UPDATE `comments` SET rating = 0 WHERE rating IS NULL", c.toSQL())
+	})
+
+	t.Run("it renders the update of a chunk", func(t *testing.T) {
+		c := BackfillCommand{Table: "comments", Set: "rating = 0", Where: "rating IS NULL", Key: "uid"}
+
+		assert.Equal(t, "UPDATE `comments` SET rating = 0 WHERE `uid` BETWEEN ? AND ? AND (rating IS NULL)", c.chunkSQL())
+	})
+}
+
+// testUint64Converter passes keys bigger than math.MaxInt64 as they are, like MySQL driver does
+type testUint64Converter struct{}
+
+func (testUint64Converter) ConvertValue(v interface{}) (driver.Value, error) {
+	if u, ok := v.(uint64); ok {
+		return u, nil
+	}
+
+	return driver.DefaultParameterConverter.ConvertValue(v)
+}
+
+func TestBackfillCommandExec(t *testing.T) {
+	chunkSQL := "UPDATE `comments` SET rating = 0 WHERE `id` BETWEEN \\? AND \\? AND \\(rating IS NULL\\)"
+
+	t.Run("it fails on invalid command", func(t *testing.T) {
+		db, _, resetDB := testDBConnection(t)
+		defer resetDB()
+
+		assert.Equal(t, ErrNoSQLCommandsToRun, BackfillCommand{}.exec(db))
+	})
+
+	t.Run("it does nothing on empty table", func(t *testing.T) {
+		db, mock, resetDB := testDBConnection(t)
+		defer resetDB()
+
+		mock.ExpectQuery("SELECT MIN\\(`id`\\), MAX\\(`id`\\) FROM `comments`").
+			WillReturnRows(sqlmock.NewRows([]string{"min", "max"}).AddRow(nil, nil))
+
+		assert.Nil(t, BackfillCommand{Table: "comments", Set: "rating = 0"}.exec(db))
+	})
+
+	t.Run("it updates every chunk within its own transaction and reports progress", func(t *testing.T) {
+		defer func(s func(time.Duration)) { sleep = s }(sleep)
+		var pauses int
+		sleep = func(time.Duration) { pauses++ }
+
+		db, mock, resetDB := testDBConnection(t)
+		defer resetDB()
+
+		mock.ExpectQuery("SELECT MIN\\(`id`\\), MAX\\(`id`\\) FROM `comments`").
+			WillReturnRows(sqlmock.NewRows([]string{"min", "max"}).AddRow(5, 120))
+		mock.ExpectQuery("SELECT `id` FROM `comments` WHERE `id` >= \\? ORDER BY `id` LIMIT 1 OFFSET 4").
+			WithArgs(5).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(9))
+		mock.ExpectBegin()
+		mock.ExpectExec(chunkSQL).WithArgs(5, 9).WillReturnResult(sqlmock.NewResult(0, 5))
+		mock.ExpectCommit()
+		mock.ExpectQuery("SELECT `id` FROM `comments` WHERE `id` >= \\? ORDER BY `id` LIMIT 1 OFFSET 4").
+			WithArgs(10).WillReturnError(sql.ErrNoRows)
+		mock.ExpectBegin()
+		mock.ExpectExec(chunkSQL).WithArgs(10, 120).WillReturnResult(sqlmock.NewResult(0, 3))
+		mock.ExpectCommit()
+
+		var progress [][]uint64
+		c := BackfillCommand{
+			Table:     "comments",
+			Set:       "rating = 0",
+			Where:     "rating IS NULL",
+			ChunkSize: 5,
+			Sleep:     time.Second,
+			Progress: func(table string, last uint64, max uint64) {
+				assert.Equal(t, "comments", table)
+				progress = append(progress, []uint64{last, max})
+			},
+		}
+
+		assert.Nil(t, c.exec(db))
+		assert.Equal(t, [][]uint64{{9, 120}, {120, 120}}, progress)
+		assert.Equal(t, 1, pauses)
+	})
+
+	t.Run("it stops at the biggest key without overflow", func(t *testing.T) {
+		db, mock, err := sqlmock.New(sqlmock.ValueConverterOption(testUint64Converter{}))
+		assert.Nil(t, err)
+		defer db.Close()
+
+		max := uint64(math.MaxUint64)
+		key := func(v uint64) []byte { return []byte(strconv.FormatUint(v, 10)) }
+
+		mock.ExpectQuery("SELECT MIN").WillReturnRows(sqlmock.NewRows([]string{"min", "max"}).AddRow(key(max-2), key(max)))
+		mock.ExpectQuery("SELECT `id` FROM `comments`").WithArgs(max - 2).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(key(max - 1)))
+		mock.ExpectBegin()
+		mock.ExpectExec(chunkSQL).WithArgs(max-2, max-1).WillReturnResult(sqlmock.NewResult(0, 2))
+		mock.ExpectCommit()
+		mock.ExpectBegin()
+		mock.ExpectExec(chunkSQL).WithArgs(max, max).WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		c := BackfillCommand{Table: "comments", Set: "rating = 0", Where: "rating IS NULL", ChunkSize: 2}
+
+		assert.Nil(t, c.exec(db))
+		assert.Nil(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("it rolls back failed chunk", func(t *testing.T) {
+		db, mock, resetDB := testDBConnection(t)
+		defer resetDB()
+
+		mock.ExpectQuery("SELECT MIN").WillReturnRows(sqlmock.NewRows([]string{"min", "max"}).AddRow(1, 1))
+		mock.ExpectBegin()
+		mock.ExpectExec(chunkSQL).WithArgs(1, 1).WillReturnError(errTestDBExecFailed)
+		mock.ExpectRollback()
+
+		c := BackfillCommand{Table: "comments", Set: "rating = 0", Where: "rating IS NULL"}
+
+		assert.Equal(t, errTestDBExecFailed, c.exec(db))
+	})
+
+	t.Run("it updates chunks within running transaction", func(t *testing.T) {
+		db, mock, resetDB := testDBConnection(t)
+		defer resetDB()
+
+		mock.ExpectBegin()
+		mock.ExpectQuery("SELECT MIN").WillReturnRows(sqlmock.NewRows([]string{"min", "max"}).AddRow(1, 1))
+		mock.ExpectExec(chunkSQL).WithArgs(1, 1).WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		tx, _ := db.Begin()
+		c := BackfillCommand{Table: "comments", Set: "rating = 0", Where: "rating IS NULL"}
+
+		assert.Nil(t, c.exec(tx))
+		assert.Nil(t, tx.Commit())
+	})
+}
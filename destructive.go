@@ -0,0 +1,89 @@
+package migrator
+
+import (
+	"fmt"
+)
+
+// DestructiveCommand describes a BC incompatible command queued by migration.
+//
+// - Migration	name of the migration
+// - Command	SQL of the command
+// - Allowed	reports whether migration allows destructive commands explicitly
+type DestructiveCommand struct {
+	Migration string
+	Command   string
+	Allowed   bool
+}
+
+// Destructive lists BC incompatible commands of every migration from the pool, including applied ones,
+// as only Up() schemas are inspected, e.g. to review dropped columns and tables before deployment.
+func (m Migrator) Destructive() (commands []DestructiveCommand) {
+	for _, item := range m.Pool {
+		if item.Up == nil {
+			continue
+		}
+
+		for _, c := range item.Up().pool {
+			if isDestructive(c) {
+				commands = append(commands, DestructiveCommand{
+					Migration: item.Name,
+					Command:   c.toSQL(),
+					Allowed:   item.AllowDestructive,
+				})
+			}
+		}
+	}
+
+	return commands
+}
+
+// CheckDestructive fails, if any migration from the pool contains BC incompatible commands
+// without allowing them explicitly.
+func (m Migrator) CheckDestructive() error {
+	for _, c := range m.Destructive() {
+		if !c.Allowed {
+			return destructiveError(c.Migration, c.Command)
+		}
+	}
+
+	return nil
+}
+
+// checkDestructive refuses pending migrations with BC incompatible commands, unless they are allowed
+func (m Migrator) checkDestructive(pending []Migration, schemas []Schema) error {
+	for i, item := range pending {
+		if item.AllowDestructive {
+			continue
+		}
+
+		for _, c := range schemas[i].pool {
+			if isDestructive(c) {
+				return destructiveError(item.Name, c.toSQL())
+			}
+		}
+	}
+
+	return nil
+}
+
+func destructiveError(migration string, sql string) error {
+	return fmt.Errorf(`Migration "%s" contains destructive command "%s"`, migration, sql)
+}
+
+// isDestructive reports whether command is BC incompatible, i.e. it removes or renames existing data
+func isDestructive(c command) bool {
+	switch v := c.(type) {
+	case dropTableCommand, renameTableCommand:
+		return true
+	case alterTableCommand:
+		for _, tc := range v.pool {
+			if isDestructive(tc) {
+				return true
+			}
+		}
+	case DropColumnCommand, ChangeColumnCommand, ModifyColumnCommand, RenameColumnCommand:
+		return true
+	}
+
+	return false
+}
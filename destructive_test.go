@@ -0,0 +1,105 @@
+package migrator
+
+import (
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsDestructive(t *testing.T) {
+	tests := []struct {
+		name        string
+		command     command
+		destructive bool
+	}{
+		{"create table", createTableCommand{Table{Name: "t"}}, false},
+		{"drop table", dropTableCommand{table: "t"}, true},
+		{"rename table", renameTableCommand{old: "a", new: "b"}, true},
+		{"add column", alterTableCommand{name: "t", pool: TableCommands{AddColumnCommand{Name: "a", Column: Integer{}}}}, false},
+		{"drop column", alterTableCommand{name: "t", pool: TableCommands{AddIndexCommand{Name: "idx", Columns: []string{"a"}}, DropColumnCommand("a")}}, true},
+		{"rename column", alterTableCommand{name: "t", pool: TableCommands{RenameColumnCommand{Old: "a", New: "b"}}}, true},
+		{"modify column", alterTableCommand{name: "t", pool: TableCommands{ModifyColumnCommand{Name: "a", Column: Integer{}}}}, true},
+		{"change column", alterTableCommand{name: "t", pool: TableCommands{ChangeColumnCommand{From: "a", To: "b", Column: Integer{}}}}, true},
+		{"backfill", BackfillCommand{Table: "t", Set: "a = 1"}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.destructive, isDestructive(tt.command))
+		})
+	}
+}
+
+func TestDestructive(t *testing.T) {
+	m := Migrator{Pool: []Migration{
+		{Name: "create", Up: func() Schema {
+			var s Schema
+			s.CreateTable(Table{Name: "posts", columns: []column{{"id", Integer{}}}})
+			return s
+		}},
+		{Name: "drop", AllowDestructive: true, Up: func() Schema {
+			var s Schema
+			s.DropTableIfExists("comments")
+			return s
+		}},
+		{Name: "rename", Up: func() Schema {
+			var s Schema
+			s.RenameTable("posts", "articles")
+			return s
+		}},
+	}}
+
+	t.Run("it lists destructive commands of the pool", func(t *testing.T) {
+		assert.Equal(t, []DestructiveCommand{
+			{Migration: "drop", Command: "DROP TABLE IF EXISTS `comments`", Allowed: true},
+			{Migration: "rename", Command: "RENAME TABLE `posts` TO `articles`"},
+		}, m.Destructive())
+	})
+
+	t.Run("it fails on destructive command, that is not allowed", func(t *testing.T) {
+		assert.EqualError(t, m.CheckDestructive(), "Migration \"rename\" contains destructive command \"RENAME TABLE `posts` TO `articles`\"")
+	})
+
+	t.Run("it passes when destructive commands are allowed", func(t *testing.T) {
+		assert.Nil(t, Migrator{Pool: m.Pool[:2]}.CheckDestructive())
+	})
+}
+
+func TestMigrateBlockDestructive(t *testing.T) {
+	up := func() Schema {
+		var s Schema
+		s.AlterTable("posts", TableCommands{DropColumnCommand("title")})
+		return s
+	}
+
+	t.Run("it refuses destructive migration", func(t *testing.T) {
+		m := Migrator{Pool: []Migration{{Name: "test", Up: up}}, BlockDestructive: true}
+		db, mock, resetDB := testDBConnection(t)
+		defer resetDB()
+
+		mock.ExpectQuery("SELECT").WillReturnRows(sqlmock.NewRows(nil))
+		mock.ExpectQuery("SELECT id, name, batch, applied_at FROM `migrations`").WillReturnRows(sqlmock.NewRows([]string{"id"}))
+
+		migrated, err := m.Migrate(db)
+
+		assert.Len(t, migrated, 0)
+		assert.EqualError(t, err, "Migration \"test\" contains destructive command \"ALTER TABLE `posts` DROP COLUMN `title`\"")
+	})
+
+	t.Run("it runs migration allowing destructive commands", func(t *testing.T) {
+		m := Migrator{Pool: []Migration{{Name: "test", Up: up, AllowDestructive: true}}, BlockDestructive: true}
+		db, mock, resetDB := testDBConnection(t)
+		defer resetDB()
+
+		mock.ExpectQuery("SELECT").WillReturnRows(sqlmock.NewRows(nil))
+		mock.ExpectQuery("SELECT id, name, batch, applied_at FROM `migrations`").WillReturnRows(sqlmock.NewRows([]string{"id"}))
+		mock.ExpectExec("ALTER TABLE `posts` DROP COLUMN `title`").WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec("INSERT INTO").WillReturnResult(sqlmock.NewResult(1, 1))
+
+		migrated, err := m.Migrate(db)
+
+		assert.Nil(t, err)
+		assert.Equal(t, []string{"test"}, migrated)
+	})
+}
@@ -0,0 +1,334 @@
+package migrator
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Dialect renders schema commands and queries of migration table for a particular database.
+//
+// Available dialects:
+//
+// - MySQL		default
+// - PostgreSQL
+// - SQLite
+type Dialect interface {
+	// render returns SQL statements of the command
+	render(c command) ([]string, error)
+	// quote quotes an identifier
+	quote(name string) string
+	// literal quotes a string value
+	literal(value string) string
+	// rebind replaces `?` placeholders of the query with the ones supported by the database
+	rebind(query string) string
+	// commitsImplicitly reports whether command causes an implicit commit of running transaction
+	commitsImplicitly(c command) bool
+	createMigrationTable(table string) string
+	createProgressTable(table string) string
+	// sessionVariable returns a query to read current value of the session variable
+	sessionVariable(name string) string
+	// setSessionVariable returns a statement to set value of the session variable
+	setSessionVariable(name string, value string) string
+}
+
+// procedureDialect is implemented by dialects, that have to read the database to run some commands.
+// procedure returns nil, if command is rendered into statements.
+type procedureDialect interface {
+	procedure(c command) (procedure, error)
+}
+
+var (
+	// MySQL dialect renders commands as they are
+	MySQL Dialect = mysqlDialect{}
+	// PostgreSQL dialect renders commands with double-quoted identifiers, identity columns,
+	// separate CREATE INDEX and COMMENT ON statements
+	PostgreSQL Dialect = postgresDialect{}
+	// SQLite dialect renders commands for in-process databases, e.g. in unit tests,
+	// changes unsupported by ALTER TABLE are applied by rebuilding the table
+	SQLite Dialect = sqliteDialect{}
+)
+
+func (m Migrator) dialect() Dialect {
+	if m.Dialect == nil {
+		return MySQL
+	}
+
+	return m.Dialect
+}
+
+func isMySQL(d Dialect) bool {
+	_, ok := d.(mysqlDialect)
+
+	return ok
+}
+
+// commands renders commands of the schema with migrator dialect, every command is rendered 1:1,
+// so progress of the migration can be tracked by the amount of executed commands.
+func (m Migrator) commands(s Schema) ([]command, error) {
+	d := m.dialect()
+	if isMySQL(d) {
+		return m.adapt(s)
+	}
+
+	var commands []command
+	for _, c := range s.pool {
+		if b, ok := c.(BackfillCommand); ok {
+			b.dialect = d
+			commands = append(commands, b)
+			continue
+		}
+
+		if pd, ok := d.(procedureDialect); ok {
+			p, err := pd.procedure(c)
+			if err != nil {
+				return nil, err
+			}
+
+			if p != nil {
+				commands = append(commands, p)
+				continue
+			}
+		}
+
+		statements, err := d.render(c)
+		if err != nil {
+			return nil, err
+		}
+
+		commands = append(commands, rendered{c, statements})
+	}
+
+	return commands, nil
+}
+
+// adapt replaces commands, that aren't supported by detected MySQL server, with equivalent ones
+func (m Migrator) adapt(s Schema) ([]command, error) {
+	if m.server == nil {
+		return s.pool, nil
+	}
+
+	var commands []command
+	for _, c := range s.pool {
+		adapted, err := m.server.adapt(c)
+		if err != nil {
+			return nil, err
+		}
+
+		commands = append(commands, adapted)
+	}
+
+	return commands, nil
+}
+
+// rendered is a command rendered by dialect into one or more SQL statements
+type rendered struct {
+	command    command
+	statements []string
+}
+
+func (r rendered) toSQL() string {
+	return strings.Join(r.statements, "; ")
+}
+
+func (r rendered) exec(db executableSQL) error {
+	if len(r.statements) == 0 {
+		return ErrNoSQLCommandsToRun
+	}
+
+	for _, sql := range r.statements {
+		if sql == "" {
+			return ErrNoSQLCommandsToRun
+		}
+
+		if _, err := db.Exec(sql); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+type mysqlDialect struct{}
+
+func (d mysqlDialect) render(c command) ([]string, error) {
+	return []string{c.toSQL()}, nil
+}
+
+func (d mysqlDialect) quote(name string) string {
+	return "`" + name + "`"
+}
+
+func (d mysqlDialect) literal(value string) string {
+	return "\"" + value + "\""
+}
+
+func (d mysqlDialect) rebind(query string) string {
+	return query
+}
+
+func (d mysqlDialect) commitsImplicitly(c command) bool {
+	return commitsImplicitly(c)
+}
+
+func (d mysqlDialect) createMigrationTable(table string) string {
+	return fmt.Sprintf(
+		"CREATE TABLE %s (%s) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci",
+		table,
+		strings.Join([]string{
+			"id int(10) unsigned NOT NULL AUTO_INCREMENT PRIMARY KEY",
+			"name varchar(255) COLLATE utf8mb4_unicode_ci NOT NULL",
+			"batch int(11) NOT NULL",
+			"applied_at timestamp(6) NULL DEFAULT CURRENT_TIMESTAMP(6)",
+		}, ", "),
+	)
+}
+
+func (d mysqlDialect) createProgressTable(table string) string {
+	return fmt.Sprintf(
+		"CREATE TABLE IF NOT EXISTS %s (%s) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci",
+		table,
+		strings.Join([]string{
+			"name varchar(255) COLLATE utf8mb4_unicode_ci NOT NULL PRIMARY KEY",
+			"step int(11) NOT NULL",
+		}, ", "),
+	)
+}
+
+func (d mysqlDialect) sessionVariable(name string) string {
+	return "SELECT @@SESSION." + name
+}
+
+func (d mysqlDialect) setSessionVariable(name string, value string) string {
+	return fmt.Sprintf("SET SESSION %s = %s", name, value)
+}
+
+// standardDialect renders parts of SQL, that are shared by dialects following SQL standard:
+// double-quoted identifiers, single-quoted strings, separate CREATE INDEX statements.
+type standardDialect struct{}
+
+func (d standardDialect) quote(name string) string {
+	return `"` + name + `"`
+}
+
+func (d standardDialect) literal(value string) string {
+	return "'" + strings.Replace(value, "'", "''", -1) + "'"
+}
+
+func (d standardDialect) quoteList(names []string) string {
+	var quoted []string
+	for _, name := range names {
+		quoted = append(quoted, d.quote(name))
+	}
+
+	return strings.Join(quoted, ", ")
+}
+
+func (d standardDialect) createIndex(table string, name string, columns []string, unique bool) string {
+	sql := "CREATE"
+	if unique {
+		sql += " UNIQUE"
+	}
+	sql += " INDEX"
+
+	if name != "" {
+		sql += " " + d.quote(name)
+	}
+
+	return sql + fmt.Sprintf(" ON %s (%s)", d.quote(table), d.quoteList(columns))
+}
+
+func (d standardDialect) foreign(f Foreign) string {
+	if f.Key == "" || f.Column == "" || f.On == "" || f.Reference == "" {
+		return ""
+	}
+
+	sql := fmt.Sprintf(
+		"CONSTRAINT %s FOREIGN KEY (%s) REFERENCES %s (%s)",
+		d.quote(f.Key),
+		d.quote(f.Column),
+		d.quote(f.On),
+		d.quote(f.Reference),
+	)
+	if referenceOptions.has(strings.ToUpper(f.OnDelete)) {
+		sql += " ON DELETE " + strings.ToUpper(f.OnDelete)
+	}
+	if referenceOptions.has(strings.ToUpper(f.OnUpdate)) {
+		sql += " ON UPDATE " + strings.ToUpper(f.OnUpdate)
+	}
+
+	return sql
+}
+
+// stringDefault quotes default value of string column the same way as buildDefaultForString does for MySQL
+func (d standardDialect) stringDefault(v string) string {
+	if v == "" {
+		return ""
+	}
+
+	if v[:1] == "(" && v[len(v)-1:] == ")" {
+		return v
+	}
+
+	if v == "<empty>" || v == "<nil>" {
+		v = ""
+	}
+
+	return d.literal(v)
+}
+
+// columnDefinition is a column definition split into parts, so it can be used to add and to alter the column.
+// Identity replaces default value of autoincrement column
+type columnDefinition struct {
+	typ      string
+	nullable bool
+	identity string
+	def      string
+	check    string
+	comment  string
+	raw      string
+}
+
+func (c columnDefinition) definition() string {
+	if c.raw != "" {
+		return c.raw
+	}
+
+	sql := c.typ
+
+	if c.nullable {
+		sql += " NULL"
+	} else {
+		sql += " NOT NULL"
+	}
+
+	if c.identity != "" {
+		sql += " " + c.identity
+	} else if c.def != "" {
+		sql += " DEFAULT " + c.def
+	}
+
+	if c.check != "" {
+		sql += " " + c.check
+	}
+
+	return sql
+}
+
+// rebindNumbered replaces `?` placeholders with numbered ones: `$1`, `$2`, ...
+func rebindNumbered(query string) string {
+	var sb strings.Builder
+	n := 0
+
+	for _, r := range query {
+		if r == '?' {
+			n++
+			sb.WriteString("$" + strconv.Itoa(n))
+			continue
+		}
+
+		sb.WriteRune(r)
+	}
+
+	return sb.String()
+}
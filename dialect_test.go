@@ -0,0 +1,216 @@
+package migrator
+
+import (
+	"errors"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMigratorDialect(t *testing.T) {
+	assert.Equal(t, MySQL, Migrator{}.dialect())
+	assert.Equal(t, PostgreSQL, Migrator{Dialect: PostgreSQL}.dialect())
+}
+
+func TestMigratorCommands(t *testing.T) {
+	var s Schema
+	s.DropTableIfExists("posts")
+	s.CustomCommand(BackfillCommand{Table: "comments", Set: "rating = 0"})
+
+	t.Run("it keeps commands for MySQL", func(t *testing.T) {
+		commands, err := Migrator{}.commands(s)
+
+		assert.Nil(t, err)
+		assert.Equal(t, s.pool, commands)
+	})
+
+	t.Run("it renders commands with the dialect", func(t *testing.T) {
+		commands, err := Migrator{Dialect: PostgreSQL}.commands(s)
+
+		assert.Nil(t, err)
+		assert.Equal(t, []command{
+			rendered{dropTableCommand{table: "posts", soft: true}, []string{`DROP TABLE IF EXISTS "posts"`}},
+			BackfillCommand{Table: "comments", Set: "rating = 0", dialect: PostgreSQL},
+		}, commands)
+		assert.Equal(t, `UPDATE "comments" SET rating = 0 WHERE "id" BETWEEN $1 AND $2`, commands[1].(BackfillCommand).chunkSQL())
+	})
+
+	t.Run("it fails on command, that can't be rendered", func(t *testing.T) {
+		var s Schema
+		s.AlterTable("posts", TableCommands{AddColumnCommand{Name: "rating", Column: Integer{}, First: true}})
+
+		_, err := Migrator{Dialect: PostgreSQL}.commands(s)
+
+		assert.EqualError(t, err, `Column position is not supported by PostgreSQL to add column "rating"`)
+	})
+}
+
+func TestRendered(t *testing.T) {
+	t.Run("it joins statements", func(t *testing.T) {
+		r := rendered{statements: []string{"CREATE TABLE a", "CREATE INDEX b"}}
+
+		assert.Equal(t, "CREATE TABLE a; CREATE INDEX b", r.toSQL())
+	})
+
+	t.Run("it fails without statements", func(t *testing.T) {
+		db, _, resetDB := testDBConnection(t)
+		defer resetDB()
+
+		assert.Equal(t, ErrNoSQLCommandsToRun, rendered{}.exec(db))
+	})
+
+	t.Run("it stops on failed statement", func(t *testing.T) {
+		db, mock, resetDB := testDBConnection(t)
+		defer resetDB()
+
+		mock.ExpectExec("CREATE TABLE a").WillReturnError(errTestDBExecFailed)
+
+		err := rendered{statements: []string{"CREATE TABLE a", "CREATE INDEX b"}}.exec(db)
+
+		assert.Equal(t, errTestDBExecFailed, err)
+		assert.Nil(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("it runs every statement", func(t *testing.T) {
+		db, mock, resetDB := testDBConnection(t)
+		defer resetDB()
+
+		mock.ExpectExec("CREATE TABLE a").WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec("CREATE INDEX b").WillReturnResult(sqlmock.NewResult(0, 0))
+
+		err := rendered{statements: []string{"CREATE TABLE a", "CREATE INDEX b"}}.exec(db)
+
+		assert.Nil(t, err)
+		assert.Nil(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestMySQLDialect(t *testing.T) {
+	statements, err := MySQL.render(dropTableCommand{table: "posts"})
+
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"DROP TABLE `posts`"}, statements)
+	assert.Equal(t, "`posts`", MySQL.quote("posts"))
+	assert.Equal(t, `"test"`, MySQL.literal("test"))
+	assert.Equal(t, "DELETE FROM a WHERE id = ?", MySQL.rebind("DELETE FROM a WHERE id = ?"))
+	assert.True(t, MySQL.commitsImplicitly(alterTableCommand{}))
+	assert.Equal(t, "SELECT @@SESSION.lock_wait_timeout", MySQL.sessionVariable("lock_wait_timeout"))
+}
+
+func TestRebindNumbered(t *testing.T) {
+	assert.Equal(t, "SELECT 1", rebindNumbered("SELECT 1"))
+	assert.Equal(t, "UPDATE a SET step = $1 WHERE name = $2", rebindNumbered("UPDATE a SET step = ? WHERE name = ?"))
+}
+
+func TestMigrateWithDialect(t *testing.T) {
+	migration := Migration{Name: "create_posts", Up: func() Schema {
+		var s Schema
+		s.CreateTable(Table{Name: "posts", Comment: "Blog posts"})
+		return s
+	}}
+
+	t.Run("it refuses online migrations", func(t *testing.T) {
+		m := Migrator{Dialect: PostgreSQL, Pool: []Migration{{Name: "online", Online: &OnlineOptions{}, Up: func() Schema {
+			var s Schema
+			s.AlterTable("posts", TableCommands{DropColumnCommand("title")})
+			return s
+		}}}}
+		db, mock, resetDB := testDBConnection(t)
+		defer resetDB()
+
+		mock.ExpectQuery("SELECT").WillReturnRows(sqlmock.NewRows(nil))
+		mock.ExpectQuery(`SELECT id, name, batch, applied_at FROM "migrations"`).WillReturnRows(sqlmock.NewRows([]string{"id"}))
+
+		_, err := m.Migrate(db)
+
+		assert.EqualError(t, err, `Migration "online" can alter tables online with MySQL dialect only`)
+	})
+
+	t.Run("it refuses to check table size", func(t *testing.T) {
+		m := Migrator{Dialect: PostgreSQL, MaxCopySize: 1, Pool: []Migration{migration}}
+		db, mock, resetDB := testDBConnection(t)
+		defer resetDB()
+
+		mock.ExpectQuery("SELECT").WillReturnRows(sqlmock.NewRows(nil))
+		mock.ExpectQuery(`SELECT id, name, batch, applied_at FROM "migrations"`).WillReturnRows(sqlmock.NewRows([]string{"id"}))
+
+		_, err := m.Migrate(db)
+
+		assert.Equal(t, errSizeNotSupported, err)
+	})
+
+	t.Run("it runs rendered statements and tracks migration", func(t *testing.T) {
+		m := Migrator{Dialect: PostgreSQL, Resumable: true, Pool: []Migration{migration}}
+		db, mock, resetDB := testDBConnection(t)
+		defer resetDB()
+
+		mock.ExpectQuery("SELECT").WillReturnError(errors.New("relation does not exist"))
+		mock.ExpectExec(regexp.QuoteMeta(`CREATE TABLE "migrations" (id serial PRIMARY KEY,`)).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectQuery(`SELECT id, name, batch, applied_at FROM "migrations"`).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "name", "batch", "applied_at"}).AddRow(1, "done", 1, time.Now()))
+		mock.ExpectExec(regexp.QuoteMeta(`CREATE TABLE IF NOT EXISTS "migrations_progress" (name varchar(255) NOT NULL PRIMARY KEY,`)).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectQuery(`SELECT name, step FROM "migrations_progress"`).WillReturnRows(sqlmock.NewRows([]string{"name", "step"}))
+		mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO "migrations_progress" (name, step) VALUES ($1, 0)`)).
+			WithArgs("create_posts").
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectExec(regexp.QuoteMeta(`CREATE TABLE "posts" ("id" bigint NOT NULL GENERATED BY DEFAULT AS IDENTITY)`)).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec(regexp.QuoteMeta(`COMMENT ON TABLE "posts" IS 'Blog posts'`)).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec(regexp.QuoteMeta(`UPDATE "migrations_progress" SET step = $1 WHERE name = $2`)).
+			WithArgs(1, "create_posts").
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO "migrations" ("name", "batch") VALUES ('create_posts', 2)`)).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectExec(regexp.QuoteMeta(`DELETE FROM "migrations_progress" WHERE name = $1`)).
+			WithArgs("create_posts").
+			WillReturnResult(sqlmock.NewResult(1, 1))
+
+		migrated, err := m.Migrate(db)
+
+		assert.Nil(t, err)
+		assert.Equal(t, []string{"create_posts"}, migrated)
+		assert.Nil(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("it runs DDL atomically", func(t *testing.T) {
+		m := Migrator{Dialect: PostgreSQL, Atomic: true, Pool: []Migration{migration}}
+		db, mock, resetDB := testDBConnection(t)
+		defer resetDB()
+
+		mock.ExpectQuery("SELECT").WillReturnRows(sqlmock.NewRows(nil))
+		mock.ExpectQuery(`SELECT id, name, batch, applied_at FROM "migrations"`).WillReturnRows(sqlmock.NewRows([]string{"id"}))
+		mock.ExpectBegin()
+		mock.ExpectExec(`CREATE TABLE "posts"`).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec(`COMMENT ON TABLE "posts"`).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec(`INSERT INTO "migrations"`).WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectCommit()
+
+		migrated, err := m.Migrate(db)
+
+		assert.Nil(t, err)
+		assert.Equal(t, []string{"create_posts"}, migrated)
+		assert.Nil(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("it deletes reverted migration", func(t *testing.T) {
+		m := Migrator{Dialect: PostgreSQL, Pool: []Migration{migration}}
+		db, mock, resetDB := testDBConnection(t)
+		defer resetDB()
+
+		mock.ExpectQuery("SELECT").WillReturnRows(sqlmock.NewRows(nil))
+		mock.ExpectQuery(`SELECT id, name, batch, applied_at FROM "migrations"`).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "name", "batch", "applied_at"}).AddRow(3, "create_posts", 1, time.Now()))
+		mock.ExpectExec(regexp.QuoteMeta(`DROP TABLE IF EXISTS "posts"`)).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec(regexp.QuoteMeta(`DELETE FROM "migrations" WHERE id = $1`)).WithArgs(3).WillReturnResult(sqlmock.NewResult(1, 1))
+
+		reverted, err := m.Rollback(db)
+
+		assert.Nil(t, err)
+		assert.Equal(t, []string{"create_posts"}, reverted)
+		assert.Nil(t, mock.ExpectationsWereMet())
+	})
+}
@@ -0,0 +1,191 @@
+package migrator
+
+import "strings"
+
+// Diff compares current and desired definitions of the table
+// and returns commands to alter the current table into the desired one,
+// as well as commands to revert them, e.g. for Up() and Down() of the migration:
+//
+//		up, down := migrator.Diff(current, desired)
+//		Up: func() migrator.Schema {
+//			var s migrator.Schema
+//			s.AlterTable(desired.Name, up)
+//			return s
+//		},
+//
+// Foreign keys and indexes, that are removed or changed, are dropped first, then columns are dropped,
+// added after their preceding columns and modified, and finally indexes and foreign keys are added.
+// Columns are compared by their SQL definition, renamed column is reported as dropped and added one.
+// Engine, charset, collation and comment of the table are not compared.
+func Diff(current Table, desired Table) (up TableCommands, down TableCommands) {
+	return diffTable(current, desired), diffTable(desired, current)
+}
+
+func diffTable(current Table, desired Table) (pool TableCommands) {
+	currentForeigns := map[string]Foreign{}
+	for _, f := range current.foreigns {
+		currentForeigns[f.Key] = f
+	}
+	desiredForeigns := map[string]Foreign{}
+	for _, f := range desired.foreigns {
+		desiredForeigns[f.Key] = f
+	}
+
+	for _, f := range current.foreigns {
+		if d, ok := desiredForeigns[f.Key]; !ok || !sameForeign(f, d) {
+			pool = append(pool, DropForeignCommand(f.Key))
+		}
+	}
+
+	currentKeys := map[string]Key{}
+	for _, k := range current.indexes {
+		currentKeys[keyIdentity(k)] = k
+	}
+	desiredKeys := map[string]Key{}
+	for _, k := range desired.indexes {
+		desiredKeys[keyIdentity(k)] = k
+	}
+
+	for _, k := range current.indexes {
+		if d, ok := desiredKeys[keyIdentity(k)]; !ok || !sameKey(k, d) {
+			pool = append(pool, dropKey(k))
+		}
+	}
+
+	currentColumns := map[string]columnType{}
+	for _, c := range current.columns {
+		currentColumns[c.field] = c.definition
+	}
+	desiredColumns := map[string]columnType{}
+	for _, c := range desired.columns {
+		desiredColumns[c.field] = c.definition
+	}
+
+	for _, c := range current.columns {
+		if _, ok := desiredColumns[c.field]; !ok {
+			pool = append(pool, DropColumnCommand(c.field))
+		}
+	}
+
+	for i, c := range desired.columns {
+		definition, ok := currentColumns[c.field]
+		if !ok {
+			add := AddColumnCommand{Name: c.field, Column: c.definition}
+			if i == 0 {
+				add.First = true
+			} else {
+				add.After = desired.columns[i-1].field
+			}
+
+			pool = append(pool, add)
+			continue
+		}
+
+		if comparableRow(definition) != comparableRow(c.definition) {
+			pool = append(pool, ModifyColumnCommand{Name: c.field, Column: c.definition})
+		}
+	}
+
+	for _, k := range desired.indexes {
+		if c, ok := currentKeys[keyIdentity(k)]; !ok || !sameKey(c, k) {
+			pool = append(pool, addKey(k))
+		}
+	}
+
+	for _, f := range desired.foreigns {
+		if c, ok := currentForeigns[f.Key]; !ok || !sameForeign(c, f) {
+			pool = append(pool, AddForeignCommand{Foreign: f})
+		}
+	}
+
+	return pool
+}
+
+// comparableRow renders the column without integer display width and with lower case default value expression,
+// as they depend on the version of MySQL server, e.g. `bigint(20)` on 5.7 and `(uuid())` on 8.0
+func comparableRow(c columnType) string {
+	switch v := c.(type) {
+	case Integer:
+		v.Precision = 0
+		v.Default = lowerExpression(v.Default)
+		return v.buildRow()
+	case String:
+		v.Default = lowerExpression(v.Default)
+		return v.buildRow()
+	case Binary:
+		v.Default = lowerExpression(v.Default)
+		return v.buildRow()
+	}
+
+	return c.buildRow()
+}
+
+// lowerExpression lowers case of default value expression and removes spaces outside of string literals,
+// as MariaDB reports it without spaces between arguments
+func lowerExpression(def string) string {
+	if !isDefaultExpression(def) {
+		return def
+	}
+
+	var b strings.Builder
+	quoted := false
+	for _, r := range def {
+		if r == '\'' {
+			quoted = !quoted
+		}
+		if r == ' ' && !quoted {
+			continue
+		}
+		b.WriteRune(r)
+	}
+
+	return strings.ToLower(b.String())
+}
+
+// keyIdentity identifies the key within the table: primary key by its type, other keys by their names
+func keyIdentity(k Key) string {
+	if strings.ToUpper(k.Type) == "PRIMARY" {
+		return "PRIMARY"
+	}
+
+	if k.Name == "" {
+		return k.render()
+	}
+
+	return k.Name
+}
+
+func sameKey(a Key, b Key) bool {
+	return strings.EqualFold(a.Type, b.Type) && strings.Join(a.Columns, ",") == strings.Join(b.Columns, ",")
+}
+
+func sameForeign(a Foreign, b Foreign) bool {
+	return a.Column == b.Column &&
+		a.On == b.On &&
+		a.Reference == b.Reference &&
+		referentialAction(strings.ToUpper(a.OnUpdate)) == referentialAction(strings.ToUpper(b.OnUpdate)) &&
+		referentialAction(strings.ToUpper(a.OnDelete)) == referentialAction(strings.ToUpper(b.OnDelete))
+}
+
+func addKey(k Key) command {
+	switch strings.ToUpper(k.Type) {
+	case "PRIMARY":
+		if len(k.Columns) == 1 {
+			return AddPrimaryIndexCommand(k.Columns[0])
+		}
+
+		return AddCompositePrimaryIndexCommand{Columns: k.Columns}
+	case "UNIQUE":
+		return AddUniqueIndexCommand{Key: k.Name, Columns: k.Columns}
+	}
+
+	return AddIndexCommand{Name: k.Name, Columns: k.Columns}
+}
+
+func dropKey(k Key) command {
+	if strings.ToUpper(k.Type) == "PRIMARY" {
+		return DropPrimaryIndexCommand{}
+	}
+
+	return DropIndexCommand(k.Name)
+}
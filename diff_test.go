@@ -0,0 +1,124 @@
+package migrator
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDiff(t *testing.T) {
+	current := Table{Name: "posts"}
+	current.ID("id")
+	current.Varchar("title", 255)
+	current.Text("legacy", true)
+	current.Int("author_id", 0, true)
+	current.Index("posts_title_index", "title")
+	current.Foreign("author_id", "id", "users", "", "")
+
+	t.Run("it returns nothing for the same table", func(t *testing.T) {
+		up, down := Diff(current, current)
+
+		assert.Len(t, up, 0)
+		assert.Len(t, down, 0)
+	})
+
+	t.Run("it ignores default referential actions", func(t *testing.T) {
+		desired := current
+		desired.foreigns = foreigns{current.foreigns[0]}
+		desired.foreigns[0].OnDelete = "restrict"
+		desired.foreigns[0].OnUpdate = "NO ACTION"
+
+		up, down := Diff(current, desired)
+
+		assert.Len(t, up, 0)
+		assert.Len(t, down, 0)
+	})
+
+	t.Run("it ignores integer display width and case of default expressions", func(t *testing.T) {
+		inspected := Table{Name: "posts"}
+		inspected.Column("id", Integer{Prefix: "big", Precision: 20, Unsigned: true, Autoincrement: true})
+		inspected.Column("uuid", String{Fixed: true, Precision: 36, Default: "(uuid())"})
+		inspected.Primary("id")
+
+		desired := Table{Name: "posts"}
+		desired.ID("id")
+		desired.UUID("uuid", "(UUID())", false)
+
+		up, down := Diff(inspected, desired)
+
+		assert.Len(t, up, 0)
+		assert.Len(t, down, 0)
+	})
+
+	t.Run("it alters the table", func(t *testing.T) {
+		desired := Table{Name: "posts"}
+		desired.Column("uuid", String{Fixed: true, Precision: 36})
+		desired.ID("id")
+		desired.Varchar("title", 100)
+		desired.Column("status", Enum{Values: []string{"draft", "published"}, Default: "draft"})
+		desired.Int("author_id", 0, true)
+		desired.Unique("title")
+		desired.Foreign("author_id", "id", "users", "", "cascade")
+
+		up, down := Diff(current, desired)
+
+		assert.Equal(t, TableCommands{
+			DropForeignCommand("posts_author_id_foreign"),
+			DropIndexCommand("posts_title_index"),
+			DropColumnCommand("legacy"),
+			AddColumnCommand{Name: "uuid", Column: String{Fixed: true, Precision: 36}, First: true},
+			ModifyColumnCommand{Name: "title", Column: String{Precision: 100}},
+			AddColumnCommand{Name: "status", Column: Enum{Values: []string{"draft", "published"}, Default: "draft"}, After: "title"},
+			AddUniqueIndexCommand{Key: "posts_title_unique", Columns: []string{"title"}},
+			AddForeignCommand{Foreign: desired.foreigns[0]},
+		}, up)
+		assert.Equal(t, TableCommands{
+			DropForeignCommand("posts_author_id_foreign"),
+			DropIndexCommand("posts_title_unique"),
+			DropColumnCommand("uuid"),
+			DropColumnCommand("status"),
+			ModifyColumnCommand{Name: "title", Column: String{Precision: 255}},
+			AddColumnCommand{Name: "legacy", Column: Text{Nullable: true}, After: "title"},
+			AddIndexCommand{Name: "posts_title_index", Columns: []string{"title"}},
+			AddForeignCommand{Foreign: current.foreigns[0]},
+		}, down)
+	})
+
+	t.Run("it replaces primary key", func(t *testing.T) {
+		from := Table{Name: "tags"}
+		from.Int("post_id", 0, true)
+		from.Varchar("tag", 50)
+		from.Primary("post_id")
+
+		to := from
+		to.indexes = nil
+		to.Primary("post_id", "tag")
+
+		up, down := Diff(from, to)
+
+		assert.Equal(t, TableCommands{DropPrimaryIndexCommand{}, AddCompositePrimaryIndexCommand{Columns: []string{"post_id", "tag"}}}, up)
+		assert.Equal(t, "ALTER TABLE `tags` DROP PRIMARY KEY, ADD PRIMARY KEY (`post_id`, `tag`)", alterTableCommand{name: "tags", pool: up}.toSQL())
+		assert.Equal(t, TableCommands{DropPrimaryIndexCommand{}, AddPrimaryIndexCommand("post_id")}, down)
+
+		source, err := alterTableSource("tags", up)
+		assert.Nil(t, err)
+		assert.Contains(t, source, `migrator.AddCompositePrimaryIndexCommand{Columns: []string{"post_id", "tag"}}`)
+	})
+
+	t.Run("it creates columns of empty table", func(t *testing.T) {
+		desired := Table{Name: "posts"}
+		desired.ID("id")
+		desired.Timestamps()
+
+		up, _ := Diff(Table{Name: "posts"}, desired)
+
+		assert.Equal(
+			t,
+			"ALTER TABLE `posts` ADD COLUMN `id` bigint unsigned NOT NULL AUTO_INCREMENT FIRST, "+
+				"ADD COLUMN `created_at` timestamp(6) NOT NULL DEFAULT CURRENT_TIMESTAMP(6) AFTER id, "+
+				"ADD COLUMN `updated_at` timestamp(6) NOT NULL DEFAULT CURRENT_TIMESTAMP(6) ON UPDATE CURRENT_TIMESTAMP(6) AFTER created_at, "+
+				"ADD PRIMARY KEY (`id`)",
+			alterTableCommand{name: "posts", pool: up}.toSQL(),
+		)
+	})
+}
@@ -0,0 +1,152 @@
+package migrator
+
+import (
+	"bufio"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+)
+
+var errDumpNotSupported = errors.New("Schema can be dumped and loaded with MySQL dialect only")
+
+var autoIncrementOption = regexp.MustCompile(` AUTO_INCREMENT=\d+`)
+
+// Dump writes `CREATE TABLE` statement of every table in the current MySQL database
+// and rows of migration table, so a new database can be set up with Load instead of replaying all migrations.
+// Foreign key checks are disabled within the dump, so it can be loaded by mysql client as well.
+//
+// Example:
+//		f, _ := os.Create("schema.sql")
+//		defer f.Close()
+//		err := m.Dump(db, f)
+func (m Migrator) Dump(db *sql.DB, w io.Writer) error {
+	if !isMySQL(m.dialect()) {
+		return errDumpNotSupported
+	}
+
+	if !m.hasTable(db) {
+		return ErrTableNotExists
+	}
+
+	if err := m.fetchExecuted(db); err != nil {
+		return err
+	}
+
+	tables, err := databaseTables(db)
+	if err != nil {
+		return err
+	}
+
+	if _, err := io.WriteString(w, "SET FOREIGN_KEY_CHECKS = 0;\n\n"); err != nil {
+		return err
+	}
+
+	for _, name := range tables {
+		var table, create string
+		if err := db.QueryRow(fmt.Sprintf("SHOW CREATE TABLE `%s`", name)).Scan(&table, &create); err != nil {
+			return err
+		}
+
+		if _, err := fmt.Fprintf(w, "%s;\n\n", autoIncrementOption.ReplaceAllString(create, "")); err != nil {
+			return err
+		}
+	}
+
+	if len(m.executed) > 0 {
+		d := m.dialect()
+		rows := make([]string, len(m.executed))
+		for i, entry := range m.executed {
+			rows[i] = fmt.Sprintf(
+				"(%d, %s, %d, %s)",
+				entry.id,
+				d.literal(entry.name),
+				entry.batch,
+				d.literal(entry.appliedAt.Format("2006-01-02 15:04:05.000000")),
+			)
+		}
+
+		_, err := fmt.Fprintf(
+			w,
+			"INSERT INTO %s (%s, %s, %s, %s) VALUES\n%s;\n\n",
+			d.quote(m.table()),
+			d.quote("id"),
+			d.quote("name"),
+			d.quote("batch"),
+			d.quote("applied_at"),
+			strings.Join(rows, ",\n"),
+		)
+		if err != nil {
+			return err
+		}
+	}
+
+	_, err = io.WriteString(w, "SET FOREIGN_KEY_CHECKS = 1;\n")
+
+	return err
+}
+
+// Load runs statements of the dump, written by Dump, on a dedicated connection with disabled foreign key checks.
+// Database should not have migration table yet, so the dump is never loaded over migrated schema.
+func (m Migrator) Load(db *sql.DB, r io.Reader) error {
+	if !isMySQL(m.dialect()) {
+		return errDumpNotSupported
+	}
+
+	if m.hasTable(db) {
+		return fmt.Errorf(`Schema can't be loaded into database with migration table "%s"`, m.table())
+	}
+
+	statements, err := dumpStatements(r)
+	if err != nil {
+		return err
+	}
+
+	load := Migration{Name: "load", Session: map[string]string{"foreign_key_checks": "0"}}
+
+	return load.withSession(db, m.dialect(), func(conn transactableSQL) error {
+		for _, statement := range statements {
+			if _, err := conn.Exec(statement); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+// dumpStatements splits the dump into statements, every statement ends with semicolon at the end of line.
+// Empty lines and `--` comments between statements are skipped.
+func dumpStatements(r io.Reader) (statements []string, err error) {
+	reader := bufio.NewReader(r)
+	var statement strings.Builder
+
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil && err != io.EOF {
+			return nil, err
+		}
+
+		trimmed := strings.TrimSpace(line)
+		if statement.Len() > 0 || (trimmed != "" && !strings.HasPrefix(trimmed, "--")) {
+			statement.WriteString(line)
+		}
+
+		if strings.HasSuffix(trimmed, ";") {
+			statements = append(statements, strings.TrimSuffix(strings.TrimSpace(statement.String()), ";"))
+			statement.Reset()
+		}
+
+		if err == io.EOF {
+			break
+		}
+	}
+
+	if rest := strings.TrimSpace(statement.String()); rest != "" {
+		statements = append(statements, rest)
+	}
+
+	return statements, nil
+}
@@ -0,0 +1,158 @@
+package migrator
+
+import (
+	"bytes"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+const testDump = "SET FOREIGN_KEY_CHECKS = 0;\n\n" +
+	"CREATE TABLE `migrations` (\n" +
+	"  `id` int(10) unsigned NOT NULL AUTO_INCREMENT,\n" +
+	"  `name` varchar(255) COLLATE utf8mb4_unicode_ci NOT NULL,\n" +
+	"  PRIMARY KEY (`id`)\n" +
+	") ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;\n\n" +
+	"CREATE TABLE `posts` (\n" +
+	"  `id` bigint unsigned NOT NULL AUTO_INCREMENT,\n" +
+	"  `title` varchar(255) COLLATE utf8mb4_unicode_ci NOT NULL DEFAULT 'a;',\n" +
+	"  PRIMARY KEY (`id`)\n" +
+	") ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;\n\n" +
+	"INSERT INTO `migrations` (`id`, `name`, `batch`, `applied_at`) VALUES\n" +
+	"(1, \"create_posts\", 1, \"2026-10-18 09:30:00.123456\"),\n" +
+	"(2, \"add_title\", 2, \"2026-10-18 10:00:00.000000\");\n\n" +
+	"SET FOREIGN_KEY_CHECKS = 1;\n"
+
+func TestDump(t *testing.T) {
+	m := Migrator{Pool: []Migration{{Name: "create_posts"}}}
+
+	t.Run("it refuses other dialects", func(t *testing.T) {
+		db, _, resetDB := testDBConnection(t)
+		defer resetDB()
+
+		err := Migrator{Dialect: SQLite}.Dump(db, &bytes.Buffer{})
+
+		assert.EqualError(t, err, "Schema can be dumped and loaded with MySQL dialect only")
+	})
+
+	t.Run("it fails without migration table", func(t *testing.T) {
+		db, mock, resetDB := testDBConnection(t)
+		defer resetDB()
+
+		mock.ExpectQuery("SELECT").WillReturnError(errTestDBQueryFailed)
+
+		assert.Equal(t, ErrTableNotExists, m.Dump(db, &bytes.Buffer{}))
+	})
+
+	t.Run("it fails on query error", func(t *testing.T) {
+		db, mock, resetDB := testDBConnection(t)
+		defer resetDB()
+
+		mock.ExpectQuery("SELECT").WillReturnRows(sqlmock.NewRows(nil))
+		mock.ExpectQuery("SELECT id, name, batch, applied_at FROM `migrations`").
+			WillReturnRows(sqlmock.NewRows([]string{"id", "name", "batch", "applied_at"}))
+		mock.ExpectQuery("SELECT TABLE_NAME FROM information_schema.TABLES").
+			WillReturnRows(sqlmock.NewRows([]string{"TABLE_NAME"}).AddRow("posts"))
+		mock.ExpectQuery("SHOW CREATE TABLE `posts`").WillReturnError(errTestDBQueryFailed)
+
+		assert.Equal(t, errTestDBQueryFailed, m.Dump(db, &bytes.Buffer{}))
+	})
+
+	t.Run("it dumps tables and migration table rows", func(t *testing.T) {
+		db, mock, resetDB := testDBConnection(t)
+		defer resetDB()
+
+		mock.ExpectQuery("SELECT").WillReturnRows(sqlmock.NewRows(nil))
+		mock.ExpectQuery("SELECT id, name, batch, applied_at FROM `migrations`").WillReturnRows(
+			sqlmock.NewRows([]string{"id", "name", "batch", "applied_at"}).
+				AddRow(1, "create_posts", 1, time.Date(2026, 10, 18, 9, 30, 0, 123456000, time.UTC)).
+				AddRow(2, "add_title", 2, time.Date(2026, 10, 18, 10, 0, 0, 0, time.UTC)),
+		)
+		mock.ExpectQuery("SELECT TABLE_NAME FROM information_schema.TABLES").
+			WillReturnRows(sqlmock.NewRows([]string{"TABLE_NAME"}).AddRow("migrations").AddRow("posts"))
+		mock.ExpectQuery("SHOW CREATE TABLE `migrations`").WillReturnRows(
+			sqlmock.NewRows([]string{"Table", "Create Table"}).AddRow("migrations", "CREATE TABLE `migrations` (\n"+
+				"  `id` int(10) unsigned NOT NULL AUTO_INCREMENT,\n"+
+				"  `name` varchar(255) COLLATE utf8mb4_unicode_ci NOT NULL,\n"+
+				"  PRIMARY KEY (`id`)\n"+
+				") ENGINE=InnoDB AUTO_INCREMENT=3 DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci"),
+		)
+		mock.ExpectQuery("SHOW CREATE TABLE `posts`").WillReturnRows(
+			sqlmock.NewRows([]string{"Table", "Create Table"}).AddRow("posts", "CREATE TABLE `posts` (\n"+
+				"  `id` bigint unsigned NOT NULL AUTO_INCREMENT,\n"+
+				"  `title` varchar(255) COLLATE utf8mb4_unicode_ci NOT NULL DEFAULT 'a;',\n"+
+				"  PRIMARY KEY (`id`)\n"+
+				") ENGINE=InnoDB AUTO_INCREMENT=42 DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci"),
+		)
+
+		var b bytes.Buffer
+		err := m.Dump(db, &b)
+
+		assert.Nil(t, err)
+		assert.Nil(t, mock.ExpectationsWereMet())
+		assert.Equal(t, testDump, b.String())
+	})
+}
+
+func TestLoad(t *testing.T) {
+	m := Migrator{}
+
+	t.Run("it refuses database with migration table", func(t *testing.T) {
+		db, mock, resetDB := testDBConnection(t)
+		defer resetDB()
+
+		mock.ExpectQuery("SELECT").WillReturnRows(sqlmock.NewRows(nil))
+
+		err := m.Load(db, strings.NewReader(testDump))
+
+		assert.EqualError(t, err, `Schema can't be loaded into database with migration table "migrations"`)
+	})
+
+	t.Run("it loads the dump on a single connection", func(t *testing.T) {
+		db, mock, resetDB := testDBConnection(t)
+		defer resetDB()
+
+		mock.ExpectQuery("SELECT").WillReturnError(errTestDBQueryFailed)
+		mock.ExpectQuery(`SELECT @@SESSION.foreign_key_checks`).WillReturnRows(sqlmock.NewRows([]string{"value"}).AddRow("1"))
+		mock.ExpectExec(`SET SESSION foreign_key_checks = 0`).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec(`SET FOREIGN_KEY_CHECKS = 0`).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec("CREATE TABLE `migrations`").WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec(regexp.QuoteMeta("CREATE TABLE `posts` (\n  `id` bigint unsigned NOT NULL AUTO_INCREMENT,\n  `title` varchar(255) COLLATE utf8mb4_unicode_ci NOT NULL DEFAULT 'a;',\n")).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec(regexp.QuoteMeta("(2, \"add_title\", 2, \"2026-10-18 10:00:00.000000\")")).WillReturnResult(sqlmock.NewResult(2, 2))
+		mock.ExpectExec(`SET FOREIGN_KEY_CHECKS = 1`).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec(`SET SESSION foreign_key_checks = 1`).WillReturnResult(sqlmock.NewResult(0, 0))
+
+		err := m.Load(db, strings.NewReader(testDump))
+
+		assert.Nil(t, err)
+		assert.Nil(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("it stops on failed statement", func(t *testing.T) {
+		db, mock, resetDB := testDBConnection(t)
+		defer resetDB()
+
+		mock.ExpectQuery("SELECT").WillReturnError(errTestDBQueryFailed)
+		mock.ExpectQuery(`SELECT @@SESSION.foreign_key_checks`).WillReturnRows(sqlmock.NewRows([]string{"value"}).AddRow("1"))
+		mock.ExpectExec(`SET SESSION foreign_key_checks = 0`).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec(`SET FOREIGN_KEY_CHECKS = 0`).WillReturnError(errTestDBExecFailed)
+		mock.ExpectExec(`SET SESSION foreign_key_checks = 1`).WillReturnResult(sqlmock.NewResult(0, 0))
+
+		err := m.Load(db, strings.NewReader(testDump))
+
+		assert.Equal(t, errTestDBExecFailed, err)
+		assert.Nil(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestDumpStatements(t *testing.T) {
+	statements, err := dumpStatements(strings.NewReader("-- schema\n\nCREATE TABLE `a` (\n  `id` int\n);\n-- rows\nINSERT INTO `a` VALUES (1);\nSELECT 1"))
+
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"CREATE TABLE `a` (\n  `id` int\n)", "INSERT INTO `a` VALUES (1)", "SELECT 1"}, statements)
+}
@@ -0,0 +1,176 @@
+package migrator
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var errSizeNotSupported = errors.New("Table size can be estimated with MySQL dialect only")
+
+// Estimate describes the table affected by a pending AlterTable or DropTable command.
+//
+// - Migration	name of the pending migration
+// - Command	SQL of the command
+// - Table		affected table
+// - Rows		approximate amount of rows, as reported by information_schema.TABLES
+// - Size		data and index length of the table in bytes
+// - Copy		reports whether the table might be rebuilt with ALGORITHM=COPY
+type Estimate struct {
+	Migration string
+	Command   string
+	Table     string
+	Rows      uint64
+	Size      uint64
+	Copy      bool
+}
+
+// Estimate reports size of the tables to be altered or dropped by pending migrations without running them.
+// It might be used as a pre-flight check before migrating production database.
+func (m Migrator) Estimate(db *sql.DB) (estimates []Estimate, err error) {
+	if len(m.Pool) == 0 {
+		return estimates, ErrNoMigrationDefined
+	}
+
+	if !isMySQL(m.dialect()) {
+		return estimates, errSizeNotSupported
+	}
+
+	if err := m.checkMigrationPool(); err != nil {
+		return estimates, err
+	}
+
+	if m.hasTable(db) {
+		if err := m.fetchExecuted(db); err != nil {
+			return estimates, err
+		}
+	}
+
+	pending, schemas, err := m.pending()
+	if err != nil {
+		return estimates, err
+	}
+
+	for i, item := range pending {
+		for _, c := range schemas[i].pool {
+			table, copy, ok := affectedTable(c)
+			if !ok {
+				continue
+			}
+
+			rows, size, err := tableSize(db, table)
+			if err != nil {
+				return estimates, err
+			}
+
+			estimates = append(estimates, Estimate{
+				Migration: item.Name,
+				Command:   c.toSQL(),
+				Table:     table,
+				Rows:      rows,
+				Size:      size,
+				Copy:      copy,
+			})
+		}
+	}
+
+	return estimates, nil
+}
+
+// checkCopySize refuses pending migrations, that might copy tables bigger than MaxCopySize.
+// Online migrations and migrations allowing table copy explicitly are skipped.
+func (m Migrator) checkCopySize(db executableSQL, pending []Migration, schemas []Schema) error {
+	if !isMySQL(m.dialect()) {
+		return errSizeNotSupported
+	}
+
+	for i, item := range pending {
+		if item.AllowTableCopy || item.Online != nil {
+			continue
+		}
+
+		for _, c := range schemas[i].pool {
+			table, copy, ok := affectedTable(c)
+			if !ok || !copy {
+				continue
+			}
+
+			_, size, err := tableSize(db, table)
+			if err != nil {
+				return err
+			}
+
+			if size > m.MaxCopySize {
+				return fmt.Errorf(
+					`Migration "%s" might copy table "%s" of %d bytes, which exceeds limit of %d bytes`,
+					item.Name,
+					table,
+					size,
+					m.MaxCopySize,
+				)
+			}
+		}
+	}
+
+	return nil
+}
+
+// affectedTable returns table affected by AlterTable or DropTable command
+// and whether the table might be copied
+func affectedTable(c command) (table string, copy bool, ok bool) {
+	switch v := c.(type) {
+	case alterTableCommand:
+		return v.name, v.requiresCopy(), true
+	case dropTableCommand:
+		return v.table, false, true
+	}
+
+	return "", false, false
+}
+
+// requiresCopy reports whether table might be rebuilt with ALGORITHM=COPY,
+// it is the case when COPY is requested explicitly or server is left to choose the algorithm
+// for commands, that usually can't be executed in place.
+func (c alterTableCommand) requiresCopy() bool {
+	algorithm := strings.ToUpper(c.options.Algorithm)
+
+	switch algorithm {
+	case "COPY":
+		return true
+	case "INSTANT", "INPLACE":
+		return false
+	}
+
+	var dropsPrimary, addsPrimary bool
+	for _, tc := range c.pool {
+		switch tc.(type) {
+		case ModifyColumnCommand, ChangeColumnCommand:
+			return true
+		case DropPrimaryIndexCommand:
+			dropsPrimary = true
+		case AddPrimaryIndexCommand, AddCompositePrimaryIndexCommand:
+			addsPrimary = true
+		}
+	}
+
+	return dropsPrimary && !addsPrimary
+}
+
+// tableSize returns approximate amount of rows and size of the table, missing table is reported as empty
+func tableSize(db executableSQL, table string) (rows uint64, size uint64, err error) {
+	var r, s sql.NullInt64
+
+	err = db.QueryRow(
+		"SELECT TABLE_ROWS, DATA_LENGTH + INDEX_LENGTH FROM information_schema.TABLES WHERE TABLE_SCHEMA = DATABASE() AND TABLE_NAME = ?",
+		table,
+	).Scan(&r, &s)
+	if err == sql.ErrNoRows {
+		return 0, 0, nil
+	}
+	if err != nil {
+		return 0, 0, err
+	}
+
+	return uint64(r.Int64), uint64(s.Int64), nil
+}
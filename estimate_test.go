@@ -0,0 +1,132 @@
+package migrator
+
+import (
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+const testSizeQuery = "SELECT TABLE_ROWS, DATA_LENGTH \\+ INDEX_LENGTH FROM information_schema.TABLES"
+
+func TestAlterTableCommandRequiresCopy(t *testing.T) {
+	tests := []struct {
+		name    string
+		command alterTableCommand
+		copy    bool
+	}{
+		{"explicit copy", alterTableCommand{name: "t", pool: TableCommands{DropColumnCommand("a")}, options: AlterOptions{Algorithm: "copy"}}, true},
+		{"explicit inplace", alterTableCommand{name: "t", pool: TableCommands{ModifyColumnCommand{Name: "a", Column: Integer{}}}, options: AlterOptions{Algorithm: "INPLACE"}}, false},
+		{"modify column", alterTableCommand{name: "t", pool: TableCommands{ModifyColumnCommand{Name: "a", Column: Integer{}}}}, true},
+		{"change column", alterTableCommand{name: "t", pool: TableCommands{ChangeColumnCommand{From: "a", To: "b", Column: Integer{}}}}, true},
+		{"drop primary key", alterTableCommand{name: "t", pool: TableCommands{DropPrimaryIndexCommand{}}}, true},
+		{"replace primary key", alterTableCommand{name: "t", pool: TableCommands{DropPrimaryIndexCommand{}, AddPrimaryIndexCommand("id")}}, false},
+		{"add column", alterTableCommand{name: "t", pool: TableCommands{AddColumnCommand{Name: "a", Column: Integer{}}}}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.copy, tt.command.requiresCopy())
+		})
+	}
+}
+
+func TestEstimate(t *testing.T) {
+	up := func() Schema {
+		var s Schema
+		s.AlterTable("events", TableCommands{ModifyColumnCommand{Name: "payload", Column: Integer{}}})
+		s.DropTableIfExists("logs")
+		s.CreateTable(Table{Name: "posts", columns: []column{{"id", Integer{}}}})
+		return s
+	}
+
+	t.Run("it fails when migration pool is empty", func(t *testing.T) {
+		db, _, resetDB := testDBConnection(t)
+		defer resetDB()
+
+		_, err := Migrator{}.Estimate(db)
+
+		assert.Equal(t, ErrNoMigrationDefined, err)
+	})
+
+	t.Run("it estimates altered and dropped tables of pending migrations", func(t *testing.T) {
+		m := Migrator{Pool: []Migration{{Name: "done", Up: up}, {Name: "test", Up: up}}}
+		db, mock, resetDB := testDBConnection(t)
+		defer resetDB()
+
+		mock.ExpectQuery("SELECT").WillReturnRows(sqlmock.NewRows(nil))
+		mock.ExpectQuery("SELECT id, name, batch, applied_at FROM `migrations`").
+			WillReturnRows(sqlmock.NewRows([]string{"id", "name", "batch", "applied_at"}).AddRow(1, "done", 1, time.Now()))
+		mock.ExpectQuery(testSizeQuery).WithArgs("events").
+			WillReturnRows(sqlmock.NewRows([]string{"rows", "size"}).AddRow(1000, 65536))
+		mock.ExpectQuery(testSizeQuery).WithArgs("logs").WillReturnRows(sqlmock.NewRows([]string{"rows", "size"}))
+
+		estimates, err := m.Estimate(db)
+
+		assert.Nil(t, err)
+		assert.Equal(t, []Estimate{
+			{Migration: "test", Command: "ALTER TABLE `events` MODIFY `payload` int NOT NULL", Table: "events", Rows: 1000, Size: 65536, Copy: true},
+			{Migration: "test", Command: "DROP TABLE IF EXISTS `logs`", Table: "logs"},
+		}, estimates)
+		assert.Nil(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestMigrateMaxCopySize(t *testing.T) {
+	up := func() Schema {
+		var s Schema
+		s.AlterTable("events", TableCommands{ModifyColumnCommand{Name: "payload", Column: Integer{}}})
+		return s
+	}
+
+	t.Run("it refuses to copy big table", func(t *testing.T) {
+		m := Migrator{Pool: []Migration{{Name: "test", Up: up}}, MaxCopySize: 1024}
+		db, mock, resetDB := testDBConnection(t)
+		defer resetDB()
+
+		mock.ExpectQuery("SELECT").WillReturnRows(sqlmock.NewRows(nil))
+		mock.ExpectQuery("SELECT id, name, batch, applied_at FROM `migrations`").WillReturnRows(sqlmock.NewRows([]string{"id"}))
+		mock.ExpectQuery(testSizeQuery).WithArgs("events").
+			WillReturnRows(sqlmock.NewRows([]string{"rows", "size"}).AddRow(10, 2048))
+
+		migrated, err := m.Migrate(db)
+
+		assert.Len(t, migrated, 0)
+		assert.EqualError(t, err, `Migration "test" might copy table "events" of 2048 bytes, which exceeds limit of 1024 bytes`)
+	})
+
+	t.Run("it copies small table", func(t *testing.T) {
+		m := Migrator{Pool: []Migration{{Name: "test", Up: up}}, MaxCopySize: 1024}
+		db, mock, resetDB := testDBConnection(t)
+		defer resetDB()
+
+		mock.ExpectQuery("SELECT").WillReturnRows(sqlmock.NewRows(nil))
+		mock.ExpectQuery("SELECT id, name, batch, applied_at FROM `migrations`").WillReturnRows(sqlmock.NewRows([]string{"id"}))
+		mock.ExpectQuery(testSizeQuery).WithArgs("events").
+			WillReturnRows(sqlmock.NewRows([]string{"rows", "size"}).AddRow(10, 512))
+		mock.ExpectExec("ALTER TABLE `events` MODIFY `payload` int NOT NULL").WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec("INSERT INTO").WillReturnResult(sqlmock.NewResult(1, 1))
+
+		migrated, err := m.Migrate(db)
+
+		assert.Nil(t, err)
+		assert.Equal(t, []string{"test"}, migrated)
+	})
+
+	t.Run("it skips check when migration allows table copy", func(t *testing.T) {
+		m := Migrator{Pool: []Migration{{Name: "test", Up: up, AllowTableCopy: true}}, MaxCopySize: 1024}
+		db, mock, resetDB := testDBConnection(t)
+		defer resetDB()
+
+		mock.ExpectQuery("SELECT").WillReturnRows(sqlmock.NewRows(nil))
+		mock.ExpectQuery("SELECT id, name, batch, applied_at FROM `migrations`").WillReturnRows(sqlmock.NewRows([]string{"id"}))
+		mock.ExpectExec("ALTER TABLE").WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec("INSERT INTO").WillReturnResult(sqlmock.NewResult(1, 1))
+
+		migrated, err := m.Migrate(db)
+
+		assert.Nil(t, err)
+		assert.Equal(t, []string{"test"}, migrated)
+	})
+}
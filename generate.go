@@ -0,0 +1,365 @@
+package migrator
+
+import (
+	"bytes"
+	"database/sql"
+	"fmt"
+	"go/format"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Generator writes source of the migration, that moves current MySQL database to desired tables,
+// similar to `makemigrations` of Django.
+//
+// - Package	package of the generated file, default: migrations
+// - Dir		directory to write the file to, default: current directory
+//
+// Every desired table is inspected in the database: missing table is created,
+// existing one is altered with commands returned by Diff. Tables, that are not listed, are not compared.
+//
+// Example:
+//		g := migrator.Generator{Package: "migrations", Dir: "migrations"}
+//		path, err := g.Write(db, "20261018_0001_sync_posts", posts, comments)
+type Generator struct {
+	Package string
+	Dir     string
+}
+
+// Generate returns formatted Go source of the migration with the given name
+func (g Generator) Generate(db *sql.DB, name string, desired ...Table) ([]byte, error) {
+	up, down, err := generateSchemas(db, desired)
+	if err != nil {
+		return nil, err
+	}
+
+	return g.source(name, nil, up, down)
+}
+
+// Write generates the migration and writes it to `<Dir>/<name>.go`, existing file is never overwritten
+func (g Generator) Write(db *sql.DB, name string, desired ...Table) (path string, err error) {
+	source, err := g.Generate(db, name, desired...)
+	if err != nil {
+		return "", err
+	}
+
+	path = filepath.Join(g.Dir, name+".go")
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	if _, err := f.Write(source); err != nil {
+		return "", err
+	}
+
+	return path, f.Close()
+}
+
+// generateSchemas compares desired tables with the database, down schema reverts up one in reverse order
+func generateSchemas(db executableSQL, desired []Table) (up Schema, down Schema, err error) {
+	for _, t := range desired {
+		current, _, exists, err := inspectTable(db, t.Name)
+		if err != nil {
+			return up, down, err
+		}
+
+		if !exists {
+			up.CreateTable(t)
+			down.pool = append(TableCommands{dropTableCommand{table: t.Name, soft: true}}, down.pool...)
+			continue
+		}
+
+		u, d := Diff(current, t)
+		if len(u) == 0 {
+			continue
+		}
+
+		up.AlterTable(t.Name, u)
+		down.pool = append(TableCommands{alterTableCommand{name: t.Name, pool: d}}, down.pool...)
+	}
+
+	if len(up.pool) == 0 {
+		return up, down, ErrNoSchemaChanges
+	}
+
+	return up, down, nil
+}
+
+func (g Generator) source(name string, replaces []string, up Schema, down Schema) ([]byte, error) {
+	if name == "" {
+		return nil, ErrMissingMigrationName
+	}
+
+	pkg := g.Package
+	if pkg == "" {
+		pkg = "migrations"
+	}
+
+	upSource, err := schemaSource(up)
+	if err != nil {
+		return nil, fmt.Errorf(`Migration "%s" can't be generated: %v`, name, err)
+	}
+
+	downSource, err := schemaSource(down)
+	if err != nil {
+		return nil, fmt.Errorf(`Migration "%s" can't be generated: %v`, name, err)
+	}
+
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "// Migration is generated by migrator.Generator, review it before running.\n\n")
+	fmt.Fprintf(&b, "package %s\n\n", pkg)
+	fmt.Fprintf(&b, "import \"github.com/larapulse/migrator\"\n\n")
+	fmt.Fprintf(&b, "var %s = migrator.Migration{\n", migrationVariable(name))
+	fmt.Fprintf(&b, "Name: %s,\n", strconv.Quote(name))
+	if len(replaces) > 0 {
+		fmt.Fprintf(&b, "Replaces: []string{\n%s,\n},\n", strings.Join(quoteValues(replaces), ",\n"))
+	}
+	fmt.Fprintf(&b, "Up: func() migrator.Schema {\nvar s migrator.Schema\n\n%s\nreturn s\n},\n", upSource)
+	fmt.Fprintf(&b, "Down: func() migrator.Schema {\nvar s migrator.Schema\n\n%s\nreturn s\n},\n", downSource)
+	fmt.Fprintf(&b, "}\n")
+
+	return format.Source(b.Bytes())
+}
+
+// schemaSource renders commands of the schema with builders of the package
+func schemaSource(s Schema) (string, error) {
+	var b bytes.Buffer
+
+	for _, c := range s.pool {
+		switch v := c.(type) {
+		case createTableCommand:
+			source, err := tableSource(v.t)
+			if err != nil {
+				return "", err
+			}
+			b.WriteString(source)
+		case alterTableCommand:
+			source, err := alterTableSource(v.name, v.pool)
+			if err != nil {
+				return "", err
+			}
+			b.WriteString(source)
+		case dropTableCommand:
+			fmt.Fprintf(&b, "s.DropTableIfExists(%s)\n", strconv.Quote(v.table))
+		default:
+			return "", fmt.Errorf(`"%s" can't be generated`, c.toSQL())
+		}
+	}
+
+	return b.String(), nil
+}
+
+// tableSource builds the table with Table helpers, keys and foreign keys,
+// that can't be built with them, are added with AlterTable after the table is created
+func tableSource(t Table) (string, error) {
+	var b bytes.Buffer
+	var extra TableCommands
+	variable := identifier(t.Name, false) + "Table"
+	if !unicode.IsLetter([]rune(variable)[0]) {
+		variable = "table" + identifier(t.Name, true)
+	}
+
+	options := ""
+	for _, o := range []struct{ field, value string }{
+		{"Engine", t.Engine},
+		{"Charset", t.Charset},
+		{"Collation", t.Collation},
+		{"Comment", t.Comment},
+	} {
+		if o.value != "" {
+			options += fmt.Sprintf(", %s: %s", o.field, strconv.Quote(o.value))
+		}
+	}
+	fmt.Fprintf(&b, "%s := migrator.Table{Name: %s%s}\n", variable, strconv.Quote(t.Name), options)
+
+	for _, c := range t.columns {
+		definition, err := literal(reflect.ValueOf(c.definition))
+		if err != nil {
+			return "", fmt.Errorf(`Column "%s" of table "%s" can't be generated: %v`, c.field, t.Name, err)
+		}
+
+		fmt.Fprintf(&b, "%s.Column(%s, %s)\n", variable, strconv.Quote(c.field), definition)
+	}
+
+	foreignKeys := map[string]bool{}
+	for _, f := range t.foreigns {
+		if f.Key == BuildForeignNameOnTable(t.Name, f.Column) && hasKey(t.indexes, Key{Name: f.Key, Columns: []string{f.Column}}) {
+			foreignKeys[f.Key] = true
+		}
+	}
+
+	for _, k := range t.indexes {
+		switch {
+		case strings.ToUpper(k.Type) == "PRIMARY":
+			fmt.Fprintf(&b, "%s.Primary(%s)\n", variable, quoteList(k.Columns))
+		case strings.ToUpper(k.Type) == "UNIQUE" && k.Name == BuildUniqueKeyNameOnTable(t.Name, k.Columns...):
+			fmt.Fprintf(&b, "%s.Unique(%s)\n", variable, quoteList(k.Columns))
+		case strings.ToUpper(k.Type) == "UNIQUE":
+			extra = append(extra, AddUniqueIndexCommand{Key: k.Name, Columns: k.Columns})
+		case !foreignKeys[k.Name]:
+			fmt.Fprintf(&b, "%s.Index(%s, %s)\n", variable, strconv.Quote(k.Name), quoteList(k.Columns))
+		}
+	}
+
+	for _, f := range t.foreigns {
+		if !foreignKeys[f.Key] {
+			extra = append(extra, AddForeignCommand{Foreign: f})
+			continue
+		}
+
+		fmt.Fprintf(
+			&b,
+			"%s.Foreign(%s)\n",
+			variable,
+			quoteList([]string{f.Column, f.Reference, f.On, f.OnUpdate, f.OnDelete}),
+		)
+	}
+
+	fmt.Fprintf(&b, "s.CreateTable(%s)\n\n", variable)
+
+	if len(extra) == 0 {
+		return b.String(), nil
+	}
+
+	source, err := alterTableSource(t.Name, extra)
+	if err != nil {
+		return "", err
+	}
+
+	return b.String() + source, nil
+}
+
+func alterTableSource(table string, pool TableCommands) (string, error) {
+	var b bytes.Buffer
+
+	fmt.Fprintf(&b, "s.AlterTable(%s, migrator.TableCommands{\n", strconv.Quote(table))
+	for _, c := range pool {
+		source, err := literal(reflect.ValueOf(c))
+		if err != nil {
+			return "", fmt.Errorf(`"%s" of table "%s" can't be generated: %v`, c.toSQL(), table, err)
+		}
+
+		fmt.Fprintf(&b, "%s,\n", source)
+	}
+	b.WriteString("})\n\n")
+
+	return b.String(), nil
+}
+
+var packageTypes = reflect.TypeOf(Table{}).PkgPath()
+
+// literal renders exported value of the package as Go composite literal, zero fields are omitted
+func literal(v reflect.Value) (string, error) {
+	if v.Kind() == reflect.Interface {
+		return literal(v.Elem())
+	}
+
+	t := v.Type()
+	if t.PkgPath() != "" && (t.PkgPath() != packageTypes || !isExported(t.Name())) {
+		return "", fmt.Errorf("type %s is not exported by migrator", t)
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		if t.PkgPath() != "" {
+			return fmt.Sprintf("migrator.%s(%s)", t.Name(), strconv.Quote(v.String())), nil
+		}
+		return strconv.Quote(v.String()), nil
+	case reflect.Bool:
+		return strconv.FormatBool(v.Bool()), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(v.Uint(), 10), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(v.Int(), 10), nil
+	case reflect.Slice:
+		if t.Elem().Kind() == reflect.String && t.PkgPath() == "" {
+			values := make([]string, v.Len())
+			for i := range values {
+				values[i] = v.Index(i).String()
+			}
+			return "[]string{" + quoteList(values) + "}", nil
+		}
+	case reflect.Struct:
+		var fields []string
+		for i := 0; i < t.NumField(); i++ {
+			if v.Field(i).IsZero() {
+				continue
+			}
+
+			if t.Field(i).PkgPath != "" {
+				return "", fmt.Errorf("unexported field %s of %s", t.Field(i).Name, t)
+			}
+
+			value, err := literal(v.Field(i))
+			if err != nil {
+				return "", err
+			}
+
+			fields = append(fields, t.Field(i).Name+": "+value)
+		}
+
+		return fmt.Sprintf("migrator.%s{%s}", t.Name(), strings.Join(fields, ", ")), nil
+	}
+
+	return "", fmt.Errorf("type %s is not supported", t)
+}
+
+func quoteList(values []string) string {
+	return strings.Join(quoteValues(values), ", ")
+}
+
+func quoteValues(values []string) []string {
+	quoted := make([]string, len(values))
+	for i, v := range values {
+		quoted[i] = strconv.Quote(v)
+	}
+
+	return quoted
+}
+
+func hasKey(k keys, key Key) bool {
+	for _, item := range k {
+		if item.Name == key.Name && item.Type == key.Type && strings.Join(item.Columns, ",") == strings.Join(key.Columns, ",") {
+			return true
+		}
+	}
+
+	return false
+}
+
+// migrationVariable builds name of the variable from the migration name,
+// e.g. "20261018_0001_create_posts" becomes "migration20261018_0001CreatePosts"
+func migrationVariable(name string) string {
+	return "migration" + identifier(name, true)
+}
+
+// identifier joins alphanumeric words of the name in camel case, digits of adjacent words are separated with underscore
+func identifier(name string, upper bool) string {
+	var b strings.Builder
+
+	words := strings.FieldsFunc(name, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	for i, w := range words {
+		r := []rune(w)
+		if i > 0 || upper {
+			r[0] = unicode.ToUpper(r[0])
+		}
+		if i > 0 && unicode.IsDigit(r[0]) && strings.IndexFunc(words[i-1][len(words[i-1])-1:], unicode.IsDigit) == 0 {
+			b.WriteString("_")
+		}
+		b.WriteString(string(r))
+	}
+
+	return b.String()
+}
+
+func isExported(name string) bool {
+	return name != "" && unicode.IsUpper([]rune(name)[0])
+}
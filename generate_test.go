@@ -0,0 +1,218 @@
+package migrator
+
+import (
+	"database/sql"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+type testGeneratedColumn string
+
+func (c testGeneratedColumn) buildRow() string {
+	return string(c)
+}
+
+func TestIdentifier(t *testing.T) {
+	assert.Equal(t, "blogPosts", identifier("blog_posts", false))
+	assert.Equal(t, "BlogPosts", identifier("blog-posts", true))
+	assert.Equal(t, "migration20261018_0001SyncPosts", migrationVariable("20261018_0001_sync_posts"))
+}
+
+func TestLiteral(t *testing.T) {
+	tests := []struct {
+		name  string
+		value interface{}
+		want  string
+	}{
+		{"zero fields are omitted", Integer{Prefix: "big", Unsigned: true, Autoincrement: true}, `migrator.Integer{Prefix: "big", Unsigned: true, Autoincrement: true}`},
+		{"values", Enum{Values: []string{"on", "off"}, Default: "off"}, `migrator.Enum{Default: "off", Values: []string{"on", "off"}}`},
+		{"quotes", String{Comment: `"quoted"`}, `migrator.String{Comment: "\"quoted\""}`},
+		{"named string", DropColumnCommand("title"), `migrator.DropColumnCommand("title")`},
+		{"empty struct", DropPrimaryIndexCommand{}, `migrator.DropPrimaryIndexCommand{}`},
+		{
+			"nested column",
+			AddColumnCommand{Name: "rating", Column: Floatable{Type: "decimal", Precision: 3, Scale: 1}, After: "title"},
+			`migrator.AddColumnCommand{Name: "rating", Column: migrator.Floatable{Type: "decimal", Precision: 3, Scale: 1}, After: "title"}`,
+		},
+		{
+			"nested foreign",
+			AddForeignCommand{Foreign: Foreign{Key: "posts_author_id_foreign", Column: "author_id", Reference: "id", On: "users"}},
+			`migrator.AddForeignCommand{Foreign: migrator.Foreign{Key: "posts_author_id_foreign", Column: "author_id", Reference: "id", On: "users"}}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			source, err := literal(reflect.ValueOf(tt.value))
+
+			assert.Nil(t, err)
+			assert.Equal(t, tt.want, source)
+		})
+	}
+
+	t.Run("it refuses unexported types", func(t *testing.T) {
+		_, err := literal(reflect.ValueOf(rawColumn("point NOT NULL")))
+		assert.EqualError(t, err, "type migrator.rawColumn is not exported by migrator")
+
+		_, err = literal(reflect.ValueOf(testGeneratedColumn("json")))
+		assert.EqualError(t, err, "type migrator.testGeneratedColumn is not exported by migrator")
+	})
+}
+
+func TestGenerator(t *testing.T) {
+	users := Table{Name: "users"}
+	users.ID("id")
+	users.Varchar("name", 255)
+	users.Varchar("email", 255)
+	users.Unique("email")
+
+	posts := Table{Name: "posts"}
+	posts.ID("id")
+	posts.Column("author_id", Integer{Prefix: "big", Unsigned: true})
+	posts.Varchar("title", 255)
+	posts.Foreign("author_id", "id", "users", "", "cascade")
+	posts.Timestamps()
+	posts.Index("posts_title_idx", "title")
+	posts.indexes = append(posts.indexes, Key{Name: "posts_slug", Type: "unique", Columns: []string{"title"}})
+
+	mockDatabase := func(mock sqlmock.Sqlmock) {
+		mock.ExpectQuery(testInspectTableQuery).WithArgs("users").
+			WillReturnRows(testInspectTable(""))
+		mock.ExpectQuery(testInspectColumnsQuery).WithArgs("users").WillReturnRows(
+			testInspectColumns().
+				AddRow("id", "bigint unsigned", "NO", nil, "auto_increment", nil, "").
+				AddRow("name", "varchar(255)", "NO", nil, "", "utf8mb4_unicode_ci", ""),
+		)
+		mock.ExpectQuery(testInspectIndexesQuery).WithArgs("users").
+			WillReturnRows(sqlmock.NewRows([]string{"INDEX_NAME", "NON_UNIQUE", "COLUMN_NAME"}).AddRow("PRIMARY", 0, "id"))
+		mock.ExpectQuery(testInspectForeignQuery).WithArgs("users").WillReturnRows(
+			sqlmock.NewRows([]string{"CONSTRAINT_NAME", "COLUMN_NAME", "REFERENCED_TABLE_NAME", "REFERENCED_COLUMN_NAME", "UPDATE_RULE", "DELETE_RULE"}),
+		)
+		mock.ExpectQuery(testInspectTableQuery).WithArgs("posts").WillReturnError(sql.ErrNoRows)
+	}
+
+	t.Run("it generates migration source", func(t *testing.T) {
+		db, mock, resetDB := testDBConnection(t)
+		defer resetDB()
+		mockDatabase(mock)
+
+		source, err := Generator{Package: "schema"}.Generate(db, "20261018_0001_sync", users, posts)
+
+		assert.Nil(t, err)
+		assert.Nil(t, mock.ExpectationsWereMet())
+		assert.Equal(t, `// Migration is generated by migrator.Generator, review it before running.
+
+package schema
+
+import "github.com/larapulse/migrator"
+
+var migration20261018_0001Sync = migrator.Migration{
+	Name: "20261018_0001_sync",
+	Up: func() migrator.Schema {
+		var s migrator.Schema
+
+		s.AlterTable("users", migrator.TableCommands{
+			migrator.AddColumnCommand{Name: "email", Column: migrator.String{Precision: 255}, After: "name"},
+			migrator.AddUniqueIndexCommand{Key: "users_email_unique", Columns: []string{"email"}},
+		})
+
+		postsTable := migrator.Table{Name: "posts"}
+		postsTable.Column("id", migrator.Integer{Prefix: "big", Unsigned: true, Autoincrement: true})
+		postsTable.Column("author_id", migrator.Integer{Prefix: "big", Unsigned: true})
+		postsTable.Column("title", migrator.String{Precision: 255})
+		postsTable.Column("created_at", migrator.Timable{Default: "CURRENT_TIMESTAMP(6)", Type: "timestamp", Precision: 6})
+		postsTable.Column("updated_at", migrator.Timable{Default: "CURRENT_TIMESTAMP(6)", OnUpdate: "CURRENT_TIMESTAMP(6)", Type: "timestamp", Precision: 6})
+		postsTable.Primary("id")
+		postsTable.Index("posts_title_idx", "title")
+		postsTable.Foreign("author_id", "id", "users", "", "cascade")
+		s.CreateTable(postsTable)
+
+		s.AlterTable("posts", migrator.TableCommands{
+			migrator.AddUniqueIndexCommand{Key: "posts_slug", Columns: []string{"title"}},
+		})
+
+		return s
+	},
+	Down: func() migrator.Schema {
+		var s migrator.Schema
+
+		s.DropTableIfExists("posts")
+		s.AlterTable("users", migrator.TableCommands{
+			migrator.DropIndexCommand("users_email_unique"),
+			migrator.DropColumnCommand("email"),
+		})
+
+		return s
+	},
+}
+`, string(source))
+	})
+
+	t.Run("it writes migration file once", func(t *testing.T) {
+		dir, err := ioutil.TempDir("", "migrations")
+		assert.Nil(t, err)
+		defer os.RemoveAll(dir)
+
+		db, mock, resetDB := testDBConnection(t)
+		defer resetDB()
+		mockDatabase(mock)
+		mockDatabase(mock)
+
+		g := Generator{Dir: dir}
+		path, err := g.Write(db, "20261018_0001_sync", users, posts)
+
+		assert.Nil(t, err)
+		assert.Equal(t, filepath.Join(dir, "20261018_0001_sync.go"), path)
+
+		content, _ := ioutil.ReadFile(path)
+		assert.Contains(t, string(content), "package migrations\n")
+
+		_, err = g.Write(db, "20261018_0001_sync", users, posts)
+		assert.True(t, os.IsExist(err))
+	})
+
+	t.Run("it reports no changes", func(t *testing.T) {
+		db, mock, resetDB := testDBConnection(t)
+		defer resetDB()
+
+		mock.ExpectQuery(testInspectTableQuery).WithArgs("tags").
+			WillReturnRows(testInspectTable(""))
+		mock.ExpectQuery(testInspectColumnsQuery).WithArgs("tags").
+			WillReturnRows(testInspectColumns().AddRow("name", "varchar(50)", "NO", nil, "", "utf8mb4_unicode_ci", ""))
+		mock.ExpectQuery(testInspectIndexesQuery).WithArgs("tags").WillReturnRows(sqlmock.NewRows([]string{"INDEX_NAME", "NON_UNIQUE", "COLUMN_NAME"}))
+		mock.ExpectQuery(testInspectForeignQuery).WithArgs("tags").WillReturnRows(
+			sqlmock.NewRows([]string{"CONSTRAINT_NAME", "COLUMN_NAME", "REFERENCED_TABLE_NAME", "REFERENCED_COLUMN_NAME", "UPDATE_RULE", "DELETE_RULE"}),
+		)
+
+		tags := Table{Name: "tags"}
+		tags.Varchar("name", 50)
+
+		_, err := Generator{}.Generate(db, "20261018_0002_tags", tags)
+
+		assert.Equal(t, ErrNoSchemaChanges, err)
+	})
+
+	t.Run("it refuses custom column types", func(t *testing.T) {
+		db, mock, resetDB := testDBConnection(t)
+		defer resetDB()
+
+		mock.ExpectQuery(testInspectTableQuery).WithArgs("logs").WillReturnError(sql.ErrNoRows)
+
+		logs := Table{Name: "logs"}
+		logs.Column("data", testGeneratedColumn("json NOT NULL"))
+
+		_, err := Generator{}.Generate(db, "20261018_0003_logs", logs)
+
+		assert.EqualError(
+			t,
+			err,
+			`Migration "20261018_0003_logs" can't be generated: Column "data" of table "logs" can't be generated: type migrator.testGeneratedColumn is not exported by migrator`,
+		)
+	})
+}
@@ -0,0 +1,469 @@
+package migrator
+
+import (
+	"database/sql"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// defaultCollation is a collation, that is used for char and text columns, when it's not set
+const defaultCollation = "utf8mb4_unicode_ci"
+
+// Inspect reads definition of the table from information_schema of the current MySQL database
+// and builds the same Table, that is accepted by Schema.CreateTable:
+//
+// - columns are typed as Integer, Floatable, Timable, String, Text, JSON, Enum, Bit or Binary
+// - indexes are read in the order of their names, with primary key first
+// - foreign keys are read with their referential actions, RESTRICT and NO ACTION are treated as default
+//
+// Columns of other types, e.g. spatial ones, are kept with their raw definition.
+// Default values of MariaDB columns are converted to the same format as MySQL ones.
+func Inspect(db *sql.DB, name string) (Table, error) {
+	return inspect(db, name)
+}
+
+func inspect(db executableSQL, name string) (Table, error) {
+	t, _, exists, err := inspectTable(db, name)
+	if err == nil && !exists {
+		err = fmt.Errorf(`Table "%s" does not exist`, name)
+	}
+
+	return t, err
+}
+
+// inspectTable reads definition of the table and version of the server, missing table is reported without error
+func inspectTable(db executableSQL, name string) (t Table, server serverVersion, exists bool, err error) {
+	t = Table{Name: name}
+	var version string
+
+	err = db.QueryRow(
+		"SELECT ENGINE, TABLE_COLLATION, TABLE_COMMENT, VERSION() FROM information_schema.TABLES WHERE TABLE_SCHEMA = DATABASE() AND TABLE_NAME = ?",
+		name,
+	).Scan(&t.Engine, &t.Collation, &t.Comment, &version)
+	if err == sql.ErrNoRows {
+		return t, server, false, nil
+	}
+	if err != nil {
+		return t, server, false, err
+	}
+	t.Charset = strings.Split(t.Collation, "_")[0]
+	server, _ = parseServerVersion(version)
+
+	if t.columns, err = inspectColumns(db, name, server.mariaDB); err != nil {
+		return t, server, true, err
+	}
+
+	if t.indexes, err = inspectIndexes(db, name); err != nil {
+		return t, server, true, err
+	}
+
+	if t.foreigns, err = inspectForeigns(db, name); err != nil {
+		return t, server, true, err
+	}
+
+	return t, server, true, nil
+}
+
+// inspectedColumn is a row of information_schema.COLUMNS
+type inspectedColumn struct {
+	name      string
+	typ       string
+	nullable  string
+	def       sql.NullString
+	extra     string
+	collation sql.NullString
+	comment   string
+}
+
+func inspectColumns(db executableSQL, table string, mariaDB bool) (columns, error) {
+	rows, err := db.Query(
+		"SELECT COLUMN_NAME, COLUMN_TYPE, IS_NULLABLE, COLUMN_DEFAULT, EXTRA, COLLATION_NAME, COLUMN_COMMENT FROM information_schema.COLUMNS "+
+			"WHERE TABLE_SCHEMA = DATABASE() AND TABLE_NAME = ? ORDER BY ORDINAL_POSITION",
+		table,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var result columns
+	for rows.Next() {
+		var c inspectedColumn
+		if err := rows.Scan(&c.name, &c.typ, &c.nullable, &c.def, &c.extra, &c.collation, &c.comment); err != nil {
+			return nil, err
+		}
+
+		if mariaDB {
+			c = c.fromMariaDB()
+		}
+
+		result = append(result, column{field: c.name, definition: c.definition()})
+	}
+
+	return result, rows.Err()
+}
+
+var mariaDBCurrentTimestamp = regexp.MustCompile(`(?i)current_timestamp(\(\d*\))?`)
+
+// fromMariaDB converts COLUMN_DEFAULT and EXTRA of MariaDB to the format of MySQL 8:
+// MariaDB quotes string literals, reports missing default as NULL string, doesn't mark expressions
+// with DEFAULT_GENERATED and returns current_timestamp() in lower case with parentheses.
+func (c inspectedColumn) fromMariaDB() inspectedColumn {
+	upper := func(v string) string {
+		return strings.TrimSuffix(strings.ToUpper(v), "()")
+	}
+	c.extra = mariaDBCurrentTimestamp.ReplaceAllStringFunc(c.extra, upper)
+
+	if !c.def.Valid {
+		return c
+	}
+
+	def := c.def.String
+	_, numeric := strconv.ParseFloat(def, 64)
+
+	switch {
+	case def == "NULL":
+		c.def = sql.NullString{}
+	case len(def) >= 2 && strings.HasPrefix(def, "'") && strings.HasSuffix(def, "'"):
+		c.def.String = strings.Replace(def[1:len(def)-1], "''", "'", -1)
+	case numeric == nil || strings.HasPrefix(strings.ToLower(def), "b'"):
+	default:
+		c.def.String = mariaDBCurrentTimestamp.ReplaceAllStringFunc(def, upper)
+		c.extra = strings.TrimSpace("DEFAULT_GENERATED " + c.extra)
+	}
+
+	return c
+}
+
+var inspectedType = regexp.MustCompile(`^([a-z]+)(?:\((.*)\))?((?: [a-z]+)*)$`)
+
+// definition builds typed column from COLUMN_TYPE, e.g. "bigint(20) unsigned", "decimal(15,2)" or "enum('on','off')"
+func (c inspectedColumn) definition() columnType {
+	match := inspectedType.FindStringSubmatch(strings.ToLower(c.typ))
+	if match == nil {
+		return c.raw()
+	}
+
+	typ, args, unsigned := match[1], match[2], strings.Contains(match[3], "unsigned")
+	precision, scale := inspectedPrecision(args)
+	nullable := c.nullable == "YES"
+	comment := c.comment
+	onUpdate := c.onUpdate()
+
+	switch typ {
+	case "tinyint", "smallint", "mediumint", "int", "integer", "bigint":
+		return Integer{
+			Default:       c.defaultValue(false),
+			Nullable:      nullable,
+			Comment:       comment,
+			OnUpdate:      onUpdate,
+			Prefix:        strings.TrimSuffix(strings.TrimSuffix(typ, "eger"), "int"),
+			Unsigned:      unsigned,
+			Precision:     precision,
+			Autoincrement: strings.Contains(strings.ToLower(c.extra), "auto_increment"),
+		}
+	case "float", "double", "real", "decimal", "numeric":
+		if typ == "float" {
+			typ = ""
+		}
+
+		return Floatable{
+			Default:   c.defaultValue(false),
+			Nullable:  nullable,
+			Comment:   comment,
+			OnUpdate:  onUpdate,
+			Type:      typ,
+			Unsigned:  unsigned,
+			Precision: precision,
+			Scale:     scale,
+		}
+	case "date", "time", "datetime", "timestamp", "year":
+		if typ == "year" {
+			precision = 0
+		}
+
+		return Timable{
+			Default:   c.timeDefault(),
+			Nullable:  nullable,
+			Comment:   comment,
+			OnUpdate:  onUpdate,
+			Type:      typ,
+			Precision: precision,
+		}
+	case "char", "varchar":
+		return String{
+			Default:   c.defaultValue(true),
+			Nullable:  nullable,
+			Comment:   comment,
+			OnUpdate:  onUpdate,
+			Collate:   c.collate(),
+			Fixed:     typ == "char",
+			Precision: precision,
+		}
+	case "tinytext", "text", "mediumtext", "longtext", "tinyblob", "blob", "mediumblob", "longblob":
+		blob := strings.HasSuffix(typ, "blob")
+
+		return Text{
+			Default:  c.defaultValue(true),
+			Nullable: nullable,
+			Comment:  comment,
+			OnUpdate: onUpdate,
+			Collate:  c.collate(),
+			Prefix:   strings.TrimSuffix(strings.TrimSuffix(typ, "text"), "blob"),
+			Blob:     blob,
+		}
+	case "json":
+		return JSON{Default: c.defaultValue(true), Nullable: nullable, Comment: comment, OnUpdate: onUpdate}
+	case "enum", "set":
+		return Enum{
+			Default:  c.defaultValue(true),
+			Nullable: nullable,
+			Comment:  comment,
+			OnUpdate: onUpdate,
+			Values:   inspectedValues(args),
+			Multiple: typ == "set",
+		}
+	case "bit":
+		return Bit{Default: c.defaultValue(false), Nullable: nullable, Comment: comment, OnUpdate: onUpdate, Precision: precision}
+	case "binary", "varbinary":
+		return Binary{
+			Default:   c.defaultValue(false),
+			Nullable:  nullable,
+			Comment:   comment,
+			OnUpdate:  onUpdate,
+			Fixed:     typ == "binary",
+			Precision: precision,
+		}
+	}
+
+	return c.raw()
+}
+
+// raw keeps definition of the column, that can't be represented by any of the column types
+func (c inspectedColumn) raw() columnType {
+	sql := c.typ
+
+	if c.nullable == "YES" {
+		sql += " NULL"
+	} else {
+		sql += " NOT NULL"
+	}
+
+	if c.def.Valid {
+		sql += " DEFAULT " + c.expression()
+	}
+
+	if onUpdate := c.onUpdate(); onUpdate != "" {
+		sql += " ON UPDATE " + onUpdate
+	}
+
+	if c.comment != "" {
+		sql += fmt.Sprintf(" COMMENT '%s'", c.comment)
+	}
+
+	return rawColumn(sql)
+}
+
+// defaultValue returns default value in the format of column types:
+// literal of string column is kept without quotes, empty one is reported as "<empty>",
+// default value expression is wrapped with parentheses
+func (c inspectedColumn) defaultValue(quoted bool) string {
+	if !c.def.Valid {
+		return ""
+	}
+
+	if c.generated() {
+		return c.expression()
+	}
+
+	if quoted && c.def.String == "" {
+		return "<empty>"
+	}
+
+	return c.def.String
+}
+
+// timeDefault returns CURRENT_TIMESTAMP as it is, other literals are quoted
+func (c inspectedColumn) timeDefault() string {
+	if !c.def.Valid {
+		return ""
+	}
+
+	if c.generated() || strings.HasPrefix(strings.ToUpper(c.def.String), "CURRENT_TIMESTAMP") {
+		return c.expression()
+	}
+
+	return fmt.Sprintf("'%s'", c.def.String)
+}
+
+func (c inspectedColumn) generated() bool {
+	return strings.Contains(strings.ToUpper(c.extra), "DEFAULT_GENERATED")
+}
+
+// expression wraps default value expression with parentheses, CURRENT_TIMESTAMP doesn't require them
+func (c inspectedColumn) expression() string {
+	def := c.def.String
+	if !c.generated() || strings.HasPrefix(strings.ToUpper(def), "CURRENT_TIMESTAMP") || isDefaultExpression(def) {
+		return def
+	}
+
+	return "(" + def + ")"
+}
+
+func (c inspectedColumn) onUpdate() string {
+	extra := strings.ToLower(c.extra)
+
+	if i := strings.Index(extra, "on update "); i >= 0 {
+		return strings.ToUpper(c.extra[i+len("on update "):])
+	}
+
+	return ""
+}
+
+// collate returns collation of the column, default collation is omitted
+func (c inspectedColumn) collate() string {
+	if !c.collation.Valid || c.collation.String == defaultCollation {
+		return ""
+	}
+
+	return c.collation.String
+}
+
+func inspectedPrecision(args string) (precision uint16, scale uint16) {
+	parts := strings.Split(args, ",")
+
+	p, err := strconv.ParseUint(strings.TrimSpace(parts[0]), 10, 16)
+	if err != nil {
+		return 0, 0
+	}
+	precision = uint16(p)
+
+	if len(parts) == 2 {
+		s, _ := strconv.ParseUint(strings.TrimSpace(parts[1]), 10, 16)
+		scale = uint16(s)
+	}
+
+	return precision, scale
+}
+
+// inspectedValues parses values of enum or set, e.g. "'on','off'", quotes are escaped by doubling
+func inspectedValues(args string) []string {
+	var values []string
+	var value strings.Builder
+	quoted := false
+
+	for i := 0; i < len(args); i++ {
+		switch {
+		case args[i] == '\'' && quoted && i+1 < len(args) && args[i+1] == '\'':
+			value.WriteByte('\'')
+			i++
+		case args[i] == '\'':
+			if quoted {
+				values = append(values, value.String())
+				value.Reset()
+			}
+			quoted = !quoted
+		case quoted:
+			value.WriteByte(args[i])
+		}
+	}
+
+	return values
+}
+
+func inspectIndexes(db executableSQL, table string) (keys, error) {
+	rows, err := db.Query(
+		"SELECT INDEX_NAME, NON_UNIQUE, COLUMN_NAME FROM information_schema.STATISTICS "+
+			"WHERE TABLE_SCHEMA = DATABASE() AND TABLE_NAME = ? ORDER BY INDEX_NAME, SEQ_IN_INDEX",
+		table,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var result keys
+	positions := map[string]int{}
+
+	for rows.Next() {
+		var name string
+		var nonUnique int
+		var col sql.NullString
+		if err := rows.Scan(&name, &nonUnique, &col); err != nil {
+			return nil, err
+		}
+
+		// functional key parts have no column
+		if !col.Valid {
+			continue
+		}
+
+		i, ok := positions[name]
+		if !ok {
+			key := Key{Name: name}
+			if name == "PRIMARY" {
+				key = Key{Type: "primary"}
+			} else if nonUnique == 0 {
+				key.Type = "unique"
+			}
+
+			i = len(result)
+			positions[name] = i
+			result = append(result, key)
+		}
+
+		result[i].Columns = append(result[i].Columns, col.String)
+	}
+
+	if i, ok := positions["PRIMARY"]; ok && i > 0 {
+		primary := result[i]
+		copy(result[1:i+1], result[:i])
+		result[0] = primary
+	}
+
+	return result, rows.Err()
+}
+
+func inspectForeigns(db executableSQL, table string) (foreigns, error) {
+	rows, err := db.Query(
+		"SELECT k.CONSTRAINT_NAME, k.COLUMN_NAME, k.REFERENCED_TABLE_NAME, k.REFERENCED_COLUMN_NAME, r.UPDATE_RULE, r.DELETE_RULE "+
+			"FROM information_schema.KEY_COLUMN_USAGE k JOIN information_schema.REFERENTIAL_CONSTRAINTS r "+
+			"ON r.CONSTRAINT_SCHEMA = k.CONSTRAINT_SCHEMA AND r.TABLE_NAME = k.TABLE_NAME AND r.CONSTRAINT_NAME = k.CONSTRAINT_NAME "+
+			"WHERE k.TABLE_SCHEMA = DATABASE() AND k.TABLE_NAME = ? AND k.REFERENCED_TABLE_NAME IS NOT NULL "+
+			"ORDER BY k.CONSTRAINT_NAME, k.ORDINAL_POSITION",
+		table,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var result foreigns
+	for rows.Next() {
+		var f Foreign
+		if err := rows.Scan(&f.Key, &f.Column, &f.On, &f.Reference, &f.OnUpdate, &f.OnDelete); err != nil {
+			return nil, err
+		}
+
+		if n := len(result); n > 0 && result[n-1].Key == f.Key {
+			return nil, fmt.Errorf(`Foreign key "%s" on multiple columns is not supported`, f.Key)
+		}
+
+		f.OnUpdate = referentialAction(f.OnUpdate)
+		f.OnDelete = referentialAction(f.OnDelete)
+		result = append(result, f)
+	}
+
+	return result, rows.Err()
+}
+
+// referentialAction omits RESTRICT and NO ACTION, that are the same default action in InnoDB
+func referentialAction(rule string) string {
+	if rule == "RESTRICT" || rule == "NO ACTION" {
+		return ""
+	}
+
+	return rule
+}
@@ -0,0 +1,316 @@
+package migrator
+
+import (
+	"database/sql"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	testInspectTableQuery   = `SELECT ENGINE, TABLE_COLLATION, TABLE_COMMENT, VERSION\(\) FROM information_schema.TABLES`
+	testInspectColumnsQuery = "SELECT COLUMN_NAME, COLUMN_TYPE, IS_NULLABLE, COLUMN_DEFAULT, EXTRA, COLLATION_NAME, COLUMN_COMMENT FROM information_schema.COLUMNS"
+	testInspectIndexesQuery = "SELECT INDEX_NAME, NON_UNIQUE, COLUMN_NAME FROM information_schema.STATISTICS"
+	testInspectForeignQuery = "SELECT k.CONSTRAINT_NAME, k.COLUMN_NAME, k.REFERENCED_TABLE_NAME"
+)
+
+func testInspectTable(comment string) *sqlmock.Rows {
+	return sqlmock.NewRows([]string{"ENGINE", "TABLE_COLLATION", "TABLE_COMMENT", "VERSION()"}).
+		AddRow("InnoDB", "utf8mb4_unicode_ci", comment, "8.0.36")
+}
+
+func testInspectColumns() *sqlmock.Rows {
+	return sqlmock.NewRows([]string{"COLUMN_NAME", "COLUMN_TYPE", "IS_NULLABLE", "COLUMN_DEFAULT", "EXTRA", "COLLATION_NAME", "COLUMN_COMMENT"})
+}
+
+func TestInspectedColumnDefinition(t *testing.T) {
+	null := sql.NullString{}
+	def := func(v string) sql.NullString { return sql.NullString{String: v, Valid: true} }
+
+	tests := []struct {
+		name   string
+		column inspectedColumn
+		want   columnType
+	}{
+		{
+			"autoincrement bigint",
+			inspectedColumn{typ: "bigint(20) unsigned", nullable: "NO", extra: "auto_increment"},
+			Integer{Prefix: "big", Unsigned: true, Precision: 20, Autoincrement: true},
+		},
+		{
+			"int without display width",
+			inspectedColumn{typ: "int", nullable: "YES", def: def("0"), comment: "counter"},
+			Integer{Nullable: true, Default: "0", Comment: "counter"},
+		},
+		{
+			"decimal",
+			inspectedColumn{typ: "decimal(15,2) unsigned", nullable: "NO", def: def("0.00")},
+			Floatable{Type: "decimal", Precision: 15, Scale: 2, Unsigned: true, Default: "0.00"},
+		},
+		{
+			"float",
+			inspectedColumn{typ: "float", nullable: "NO"},
+			Floatable{},
+		},
+		{
+			"timestamp with current timestamp",
+			inspectedColumn{typ: "timestamp(6)", nullable: "NO", def: def("CURRENT_TIMESTAMP(6)"), extra: "DEFAULT_GENERATED on update CURRENT_TIMESTAMP(6)"},
+			Timable{Type: "timestamp", Precision: 6, Default: "CURRENT_TIMESTAMP(6)", OnUpdate: "CURRENT_TIMESTAMP(6)"},
+		},
+		{
+			"date with literal",
+			inspectedColumn{typ: "date", nullable: "YES", def: def("2020-01-01")},
+			Timable{Type: "date", Nullable: true, Default: "'2020-01-01'"},
+		},
+		{
+			"year",
+			inspectedColumn{typ: "year(4)", nullable: "NO", def: null},
+			Timable{Type: "year"},
+		},
+		{
+			"uuid",
+			inspectedColumn{typ: "char(36)", nullable: "NO", def: def("uuid()"), extra: "DEFAULT_GENERATED", collation: def("utf8mb4_unicode_ci")},
+			String{Fixed: true, Precision: 36, Default: "(uuid())"},
+		},
+		{
+			"varchar with collation and empty default",
+			inspectedColumn{typ: "varchar(255)", nullable: "NO", def: def(""), collation: def("utf8mb4_general_ci")},
+			String{Precision: 255, Default: "<empty>", Collate: "utf8mb4_general_ci"},
+		},
+		{
+			"long text",
+			inspectedColumn{typ: "longtext", nullable: "YES", collation: def("utf8mb4_unicode_ci")},
+			Text{Prefix: "long", Nullable: true},
+		},
+		{
+			"blob",
+			inspectedColumn{typ: "mediumblob", nullable: "NO"},
+			Text{Prefix: "medium", Blob: true},
+		},
+		{
+			"json",
+			inspectedColumn{typ: "json", nullable: "YES", comment: "data"},
+			JSON{Nullable: true, Comment: "data"},
+		},
+		{
+			"enum",
+			inspectedColumn{typ: "enum('on','off','it''s')", nullable: "NO", def: def("off")},
+			Enum{Values: []string{"on", "off", "it's"}, Default: "off"},
+		},
+		{
+			"set",
+			inspectedColumn{typ: "set('a,b','c')", nullable: "NO"},
+			Enum{Values: []string{"a,b", "c"}, Multiple: true},
+		},
+		{
+			"bit",
+			inspectedColumn{typ: "bit(8)", nullable: "NO", def: def("b'1'")},
+			Bit{Precision: 8, Default: "b'1'"},
+		},
+		{
+			"binary id",
+			inspectedColumn{typ: "binary(16)", nullable: "NO", def: def("uuid_to_bin(uuid())"), extra: "DEFAULT_GENERATED"},
+			Binary{Fixed: true, Precision: 16, Default: "(uuid_to_bin(uuid()))"},
+		},
+		{
+			"spatial",
+			inspectedColumn{typ: "point", nullable: "NO", comment: "location"},
+			rawColumn("point NOT NULL COMMENT 'location'"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.column.definition())
+		})
+	}
+}
+
+func TestInspectedColumnFromMariaDB(t *testing.T) {
+	null := sql.NullString{}
+	def := func(v string) sql.NullString { return sql.NullString{String: v, Valid: true} }
+
+	tests := []struct {
+		name   string
+		column inspectedColumn
+		want   columnType
+	}{
+		{
+			"nullable without default",
+			inspectedColumn{typ: "int(11)", nullable: "YES", def: def("NULL")},
+			Integer{Precision: 11, Nullable: true},
+		},
+		{
+			"number",
+			inspectedColumn{typ: "int(11)", nullable: "NO", def: def("0")},
+			Integer{Precision: 11, Default: "0"},
+		},
+		{
+			"quoted string",
+			inspectedColumn{typ: "varchar(255)", nullable: "NO", def: def("'it''s'"), collation: def("utf8mb4_unicode_ci")},
+			String{Precision: 255, Default: "it's"},
+		},
+		{
+			"empty string",
+			inspectedColumn{typ: "varchar(255)", nullable: "NO", def: def("''"), collation: def("utf8mb4_unicode_ci")},
+			String{Precision: 255, Default: "<empty>"},
+		},
+		{
+			"current timestamp",
+			inspectedColumn{typ: "timestamp", nullable: "NO", def: def("current_timestamp()"), extra: "on update current_timestamp()"},
+			Timable{Type: "timestamp", Default: "CURRENT_TIMESTAMP", OnUpdate: "CURRENT_TIMESTAMP"},
+		},
+		{
+			"current timestamp with precision",
+			inspectedColumn{typ: "timestamp(6)", nullable: "NO", def: def("current_timestamp(6)")},
+			Timable{Type: "timestamp", Precision: 6, Default: "CURRENT_TIMESTAMP(6)"},
+		},
+		{
+			"expression",
+			inspectedColumn{typ: "char(36)", nullable: "NO", def: def("uuid()"), collation: def("utf8mb4_unicode_ci")},
+			String{Fixed: true, Precision: 36, Default: "(uuid())"},
+		},
+		{
+			"bit",
+			inspectedColumn{typ: "bit(1)", nullable: "NO", def: def("b'1'")},
+			Bit{Precision: 1, Default: "b'1'"},
+		},
+		{
+			"no default",
+			inspectedColumn{typ: "text", nullable: "NO", def: null},
+			Text{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.column.fromMariaDB().definition())
+		})
+	}
+}
+
+func TestInspect(t *testing.T) {
+	t.Run("it fails on missing table", func(t *testing.T) {
+		db, mock, resetDB := testDBConnection(t)
+		defer resetDB()
+
+		mock.ExpectQuery(testInspectTableQuery).WithArgs("posts").WillReturnError(sql.ErrNoRows)
+
+		_, err := Inspect(db, "posts")
+
+		assert.EqualError(t, err, `Table "posts" does not exist`)
+	})
+
+	t.Run("it fails on query error", func(t *testing.T) {
+		db, mock, resetDB := testDBConnection(t)
+		defer resetDB()
+
+		mock.ExpectQuery(testInspectTableQuery).WithArgs("posts").
+			WillReturnRows(testInspectTable(""))
+		mock.ExpectQuery(testInspectColumnsQuery).WithArgs("posts").WillReturnError(errTestDBQueryFailed)
+
+		_, err := Inspect(db, "posts")
+
+		assert.Equal(t, errTestDBQueryFailed, err)
+	})
+
+	t.Run("it refuses foreign key on multiple columns", func(t *testing.T) {
+		db, mock, resetDB := testDBConnection(t)
+		defer resetDB()
+
+		mock.ExpectQuery(testInspectTableQuery).WithArgs("posts").
+			WillReturnRows(testInspectTable(""))
+		mock.ExpectQuery(testInspectColumnsQuery).WithArgs("posts").WillReturnRows(testInspectColumns())
+		mock.ExpectQuery(testInspectIndexesQuery).WithArgs("posts").WillReturnRows(sqlmock.NewRows([]string{"INDEX_NAME", "NON_UNIQUE", "COLUMN_NAME"}))
+		mock.ExpectQuery(testInspectForeignQuery).WithArgs("posts").WillReturnRows(
+			sqlmock.NewRows([]string{"CONSTRAINT_NAME", "COLUMN_NAME", "REFERENCED_TABLE_NAME", "REFERENCED_COLUMN_NAME", "UPDATE_RULE", "DELETE_RULE"}).
+				AddRow("posts_author_foreign", "author_id", "users", "id", "RESTRICT", "RESTRICT").
+				AddRow("posts_author_foreign", "author_tenant", "users", "tenant", "RESTRICT", "RESTRICT"),
+		)
+
+		_, err := Inspect(db, "posts")
+
+		assert.EqualError(t, err, `Foreign key "posts_author_foreign" on multiple columns is not supported`)
+	})
+
+	t.Run("it converts default values of MariaDB", func(t *testing.T) {
+		db, mock, resetDB := testDBConnection(t)
+		defer resetDB()
+
+		mock.ExpectQuery(testInspectTableQuery).WithArgs("posts").WillReturnRows(
+			sqlmock.NewRows([]string{"ENGINE", "TABLE_COLLATION", "TABLE_COMMENT", "VERSION()"}).
+				AddRow("InnoDB", "utf8mb4_unicode_ci", "", "10.6.12-MariaDB-1:10.6.12"),
+		)
+		mock.ExpectQuery(testInspectColumnsQuery).WithArgs("posts").WillReturnRows(
+			testInspectColumns().
+				AddRow("status", "varchar(16)", "NO", "'draft'", "", "utf8mb4_unicode_ci", "").
+				AddRow("created_at", "timestamp", "NO", "current_timestamp()", "", nil, ""),
+		)
+		mock.ExpectQuery(testInspectIndexesQuery).WithArgs("posts").WillReturnRows(sqlmock.NewRows([]string{"INDEX_NAME", "NON_UNIQUE", "COLUMN_NAME"}))
+		mock.ExpectQuery(testInspectForeignQuery).WithArgs("posts").WillReturnRows(
+			sqlmock.NewRows([]string{"CONSTRAINT_NAME", "COLUMN_NAME", "REFERENCED_TABLE_NAME", "REFERENCED_COLUMN_NAME", "UPDATE_RULE", "DELETE_RULE"}),
+		)
+
+		table, err := Inspect(db, "posts")
+
+		assert.Nil(t, err)
+		assert.Equal(t, columns{
+			{"status", String{Precision: 16, Default: "draft"}},
+			{"created_at", Timable{Type: "timestamp", Default: "CURRENT_TIMESTAMP"}},
+		}, table.columns)
+	})
+
+	t.Run("it rebuilds the table", func(t *testing.T) {
+		db, mock, resetDB := testDBConnection(t)
+		defer resetDB()
+
+		expected := Table{Name: "posts", Engine: "InnoDB", Charset: "utf8mb4", Collation: "utf8mb4_unicode_ci", Comment: "blog posts"}
+		expected.ID("id")
+		expected.Varchar("title", 255)
+		expected.Column("status", Enum{Values: []string{"draft", "published"}, Default: "draft"})
+		expected.Foreign("author_id", "id", "users", "", "cascade")
+		expected.Unique("title")
+		expected.Timestamps()
+		expected.columns = append(expected.columns[:3], append(columns{{"author_id", Integer{Prefix: "big", Unsigned: true, Precision: 20}}}, expected.columns[3:]...)...)
+
+		mock.ExpectQuery(testInspectTableQuery).WithArgs("posts").
+			WillReturnRows(testInspectTable("blog posts"))
+		mock.ExpectQuery(testInspectColumnsQuery).WithArgs("posts").WillReturnRows(
+			testInspectColumns().
+				AddRow("id", "bigint unsigned", "NO", nil, "auto_increment", nil, "").
+				AddRow("title", "varchar(255)", "NO", nil, "", "utf8mb4_unicode_ci", "").
+				AddRow("status", "enum('draft','published')", "NO", "draft", "", "utf8mb4_unicode_ci", "").
+				AddRow("author_id", "bigint(20) unsigned", "NO", nil, "", nil, "").
+				AddRow("created_at", "timestamp(6)", "NO", "CURRENT_TIMESTAMP(6)", "DEFAULT_GENERATED", nil, "").
+				AddRow("updated_at", "timestamp(6)", "NO", "CURRENT_TIMESTAMP(6)", "DEFAULT_GENERATED on update CURRENT_TIMESTAMP(6)", nil, ""),
+		)
+		mock.ExpectQuery(testInspectIndexesQuery).WithArgs("posts").WillReturnRows(
+			sqlmock.NewRows([]string{"INDEX_NAME", "NON_UNIQUE", "COLUMN_NAME"}).
+				AddRow("posts_author_id_foreign", 1, "author_id").
+				AddRow("posts_title_unique", 0, "title").
+				AddRow("PRIMARY", 0, "id").
+				AddRow("posts_functional", 1, nil),
+		)
+		mock.ExpectQuery(testInspectForeignQuery).WithArgs("posts").WillReturnRows(
+			sqlmock.NewRows([]string{"CONSTRAINT_NAME", "COLUMN_NAME", "REFERENCED_TABLE_NAME", "REFERENCED_COLUMN_NAME", "UPDATE_RULE", "DELETE_RULE"}).
+				AddRow("posts_author_id_foreign", "author_id", "users", "id", "NO ACTION", "CASCADE"),
+		)
+
+		table, err := Inspect(db, "posts")
+
+		assert.Nil(t, err)
+		assert.Nil(t, mock.ExpectationsWereMet())
+		assert.Equal(t, "posts", table.Name)
+		assert.Equal(t, "blog posts", table.Comment)
+		assert.Equal(t, keys{
+			{Type: "primary", Columns: []string{"id"}},
+			{Name: "posts_author_id_foreign", Columns: []string{"author_id"}},
+			{Name: "posts_title_unique", Type: "unique", Columns: []string{"title"}},
+		}, table.indexes)
+		assert.Equal(t, foreigns{
+			{Key: "posts_author_id_foreign", Column: "author_id", Reference: "id", On: "users", OnDelete: "CASCADE"},
+		}, table.foreigns)
+		assert.Equal(t, createTableCommand{expected}.toSQL(), createTableCommand{table}.toSQL())
+	})
+}
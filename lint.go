@@ -0,0 +1,380 @@
+package migrator
+
+import (
+	"fmt"
+	"strings"
+)
+
+// maxIndexBytes is a maximum length of the index key prefix for COMPACT and REDUNDANT row formats of InnoDB
+const maxIndexBytes = 767
+
+// LintIssue describes a problem found in the migration by Lint.
+type LintIssue struct {
+	Migration string
+	Message   string
+}
+
+func (i LintIssue) String() string {
+	return fmt.Sprintf(`Migration "%s": %s`, i.Migration, i.Message)
+}
+
+// Lint renders Up and Down of every migration from the pool and reports common MySQL pitfalls:
+//
+// - missing Down, that can't be derived from Up, or Down, that doesn't reverse Up
+// - NOT NULL column added to existing table without default value
+// - foreign key without an index on its column
+// - char or varchar column under an index longer than 767 bytes
+// - Enum with default value, that is not listed in its values
+// - Timable with precision out of 0-6 range
+//
+// Every migration is checked on its own, issues of the whole pool are reported at once.
+func Lint(pool []Migration) (issues []LintIssue) {
+	indexed := indexedColumns{}
+
+	for _, item := range pool {
+		report := func(message string) {
+			issues = append(issues, LintIssue{Migration: item.Name, Message: message})
+		}
+
+		if item.Up == nil {
+			report("Up is missing")
+			continue
+		}
+
+		up := item.Up().pool
+		for _, c := range up {
+			for _, message := range lintCommand(c, indexed, true) {
+				report(message)
+			}
+		}
+
+		if item.Down == nil {
+			if _, err := reverseSchema(Schema{pool: up}); err != nil {
+				report(fmt.Sprintf("Down is missing and can't be derived from Up: %v", err))
+			}
+			continue
+		}
+
+		down := item.Down().pool
+		for _, c := range down {
+			for _, message := range lintCommand(c, indexed, false) {
+				report(message)
+			}
+		}
+
+		for _, message := range lintReverse(up, down) {
+			report(message)
+		}
+	}
+
+	return issues
+}
+
+// lintCommand checks columns, indexes and foreign keys of the command,
+// indexed tracks first columns of the indexes per table, so foreign keys can rely on indexes created earlier.
+func lintCommand(c command, indexed indexedColumns, up bool) (messages []string) {
+	switch v := c.(type) {
+	case createTableCommand:
+		defined := map[string]columnType{}
+		for _, col := range v.t.columns {
+			defined[col.field] = col.definition
+			messages = append(messages, lintColumn(v.t.Name, col.field, col.definition)...)
+		}
+
+		for _, key := range v.t.indexes {
+			indexed.add(v.t.Name, key.Columns)
+			messages = append(messages, lintIndex(v.t.Name, defined, key.Columns)...)
+		}
+
+		for _, f := range v.t.foreigns {
+			messages = append(messages, lintForeign(v.t.Name, f, indexed)...)
+		}
+	case alterTableCommand:
+		defined := map[string]columnType{}
+		var foreignKeys []Foreign
+
+		for _, tc := range v.pool {
+			switch cmd := tc.(type) {
+			case AddColumnCommand:
+				defined[cmd.Name] = cmd.Column
+				messages = append(messages, lintColumn(v.name, cmd.Name, cmd.Column)...)
+
+				if up && cmd.Column != nil && !hasDefault(cmd.Column) {
+					messages = append(messages, fmt.Sprintf(`NOT NULL column "%s" is added to table "%s" without default value`, cmd.Name, v.name))
+				}
+			case ModifyColumnCommand:
+				defined[cmd.Name] = cmd.Column
+				messages = append(messages, lintColumn(v.name, cmd.Name, cmd.Column)...)
+			case ChangeColumnCommand:
+				defined[cmd.To] = cmd.Column
+				messages = append(messages, lintColumn(v.name, cmd.To, cmd.Column)...)
+			case AddIndexCommand:
+				indexed.add(v.name, cmd.Columns)
+				messages = append(messages, lintIndex(v.name, defined, cmd.Columns)...)
+			case AddUniqueIndexCommand:
+				indexed.add(v.name, cmd.Columns)
+				messages = append(messages, lintIndex(v.name, defined, cmd.Columns)...)
+			case AddPrimaryIndexCommand:
+				indexed.add(v.name, []string{string(cmd)})
+				messages = append(messages, lintIndex(v.name, defined, []string{string(cmd)})...)
+			case AddCompositePrimaryIndexCommand:
+				indexed.add(v.name, cmd.Columns)
+				messages = append(messages, lintIndex(v.name, defined, cmd.Columns)...)
+			case AddForeignCommand:
+				foreignKeys = append(foreignKeys, cmd.Foreign)
+			}
+		}
+
+		for _, f := range foreignKeys {
+			messages = append(messages, lintForeign(v.name, f, indexed)...)
+		}
+	}
+
+	return messages
+}
+
+// indexedColumns holds first columns of the indexes per table
+type indexedColumns map[string]list
+
+func (i indexedColumns) add(table string, columns []string) {
+	if len(columns) > 0 {
+		i[table] = append(i[table], columns[0])
+	}
+}
+
+func lintColumn(table string, name string, c columnType) (messages []string) {
+	switch v := c.(type) {
+	case Enum:
+		if v.Default == "" || v.Default[:1] == "(" {
+			break
+		}
+
+		values := []string{v.Default}
+		if v.Multiple {
+			values = strings.Split(v.Default, ",")
+		}
+
+		for _, value := range values {
+			if value == "<empty>" {
+				value = ""
+			}
+
+			if !list(v.Values).has(value) {
+				messages = append(messages, fmt.Sprintf(`Default value "%s" of column "%s" on table "%s" is not listed in values`, value, name, table))
+			}
+		}
+	case Timable:
+		if v.Precision > 6 {
+			messages = append(messages, fmt.Sprintf(`Precision %d of column "%s" on table "%s" is out of 0-6 range and is ignored`, v.Precision, name, table))
+		}
+	}
+
+	return messages
+}
+
+func lintIndex(table string, defined map[string]columnType, columns []string) (messages []string) {
+	for _, name := range columns {
+		s, ok := defined[name].(String)
+		if !ok {
+			continue
+		}
+
+		if size := int(s.Precision) * bytesPerChar(s.Charset, s.Collate); size > maxIndexBytes {
+			messages = append(messages, fmt.Sprintf(`Column "%s" on table "%s" takes %d bytes, which exceeds index limit of %d bytes`, name, table, size, maxIndexBytes))
+		}
+	}
+
+	return messages
+}
+
+func lintForeign(table string, f Foreign, indexed indexedColumns) []string {
+	if f.Column == "" || indexed[table].has(f.Column) {
+		return nil
+	}
+
+	return []string{fmt.Sprintf(`Foreign key "%s" on table "%s" has no index on column "%s"`, f.Key, table, f.Column)}
+}
+
+// hasDefault reports whether column might be added to a table with existing rows,
+// unknown column types are considered safe
+func hasDefault(c columnType) bool {
+	switch v := c.(type) {
+	case Integer:
+		return v.Nullable || v.Default != "" || v.Autoincrement
+	case Floatable:
+		return v.Nullable || v.Default != ""
+	case Timable:
+		return v.Nullable || v.Default != ""
+	case String:
+		return v.Nullable || v.Default != ""
+	case Text:
+		return v.Nullable || v.Default != ""
+	case JSON:
+		return v.Nullable || v.Default != ""
+	case Enum:
+		return v.Nullable || v.Default != ""
+	case Bit:
+		return v.Nullable || v.Default != ""
+	case Binary:
+		return v.Nullable || v.Default != ""
+	}
+
+	return true
+}
+
+// bytesPerChar returns maximum length of the character in bytes, utf8mb4 is used by default
+func bytesPerChar(charset string, collation string) int {
+	if charset == "" && collation != "" {
+		charset = strings.Split(collation, "_")[0]
+	}
+
+	switch strings.ToLower(charset) {
+	case "latin1", "ascii", "binary":
+		return 1
+	case "ucs2":
+		return 2
+	case "utf8", "utf8mb3":
+		return 3
+	}
+
+	return 4
+}
+
+// lintReverse reports commands of Up, which are not reverted by Down
+func lintReverse(up []command, down []command) (messages []string) {
+	for _, c := range up {
+		switch v := c.(type) {
+		case createTableCommand:
+			if !dropsTable(down, v.t.Name) {
+				messages = append(messages, fmt.Sprintf(`Down doesn't drop table "%s"`, v.t.Name))
+			}
+		case dropTableCommand:
+			if !createsTable(down, v.table) {
+				messages = append(messages, fmt.Sprintf(`Down doesn't create table "%s"`, v.table))
+			}
+		case renameTableCommand:
+			if !renamesTable(down, v.new, v.old) {
+				messages = append(messages, fmt.Sprintf(`Down doesn't rename table "%s" back to "%s"`, v.new, v.old))
+			}
+		case alterTableCommand:
+			if dropsTable(down, v.name) {
+				continue
+			}
+
+			var reverts []command
+			for _, dc := range down {
+				if alter, ok := dc.(alterTableCommand); ok && alter.name == v.name {
+					reverts = append(reverts, alter.pool...)
+				}
+			}
+
+			for _, tc := range v.pool {
+				if !revertsTableCommand(reverts, tc) {
+					messages = append(messages, fmt.Sprintf(`Down doesn't reverse "%s" on table "%s"`, tc.toSQL(), v.name))
+				}
+			}
+		}
+	}
+
+	return messages
+}
+
+func revertsTableCommand(down []command, c command) bool {
+	switch c.(type) {
+	case AddColumnCommand, DropColumnCommand, RenameColumnCommand, ModifyColumnCommand, ChangeColumnCommand,
+		AddIndexCommand, AddUniqueIndexCommand, DropIndexCommand, AddForeignCommand, DropForeignCommand,
+		AddPrimaryIndexCommand, AddCompositePrimaryIndexCommand, DropPrimaryIndexCommand:
+	default:
+		// other commands can't be checked
+		return true
+	}
+
+	for _, dc := range down {
+		switch v := c.(type) {
+		case AddColumnCommand:
+			if d, ok := dc.(DropColumnCommand); ok && string(d) == v.Name {
+				return true
+			}
+		case DropColumnCommand:
+			if d, ok := dc.(AddColumnCommand); ok && d.Name == string(v) {
+				return true
+			}
+		case RenameColumnCommand:
+			if d, ok := dc.(RenameColumnCommand); ok && d.Old == v.New && d.New == v.Old {
+				return true
+			}
+		case ModifyColumnCommand:
+			if d, ok := dc.(ModifyColumnCommand); ok && d.Name == v.Name {
+				return true
+			}
+		case ChangeColumnCommand:
+			if d, ok := dc.(ChangeColumnCommand); ok && d.From == v.To && d.To == v.From {
+				return true
+			}
+		case AddIndexCommand:
+			if d, ok := dc.(DropIndexCommand); ok && string(d) == v.Name {
+				return true
+			}
+		case AddUniqueIndexCommand:
+			if d, ok := dc.(DropIndexCommand); ok && string(d) == v.Key {
+				return true
+			}
+		case DropIndexCommand:
+			if d, ok := dc.(AddIndexCommand); ok && d.Name == string(v) {
+				return true
+			}
+			if d, ok := dc.(AddUniqueIndexCommand); ok && d.Key == string(v) {
+				return true
+			}
+		case AddForeignCommand:
+			if d, ok := dc.(DropForeignCommand); ok && string(d) == v.Foreign.Key {
+				return true
+			}
+		case DropForeignCommand:
+			if d, ok := dc.(AddForeignCommand); ok && d.Foreign.Key == string(v) {
+				return true
+			}
+		case AddPrimaryIndexCommand, AddCompositePrimaryIndexCommand:
+			if _, ok := dc.(DropPrimaryIndexCommand); ok {
+				return true
+			}
+		case DropPrimaryIndexCommand:
+			switch dc.(type) {
+			case AddPrimaryIndexCommand, AddCompositePrimaryIndexCommand:
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+func dropsTable(commands []command, table string) bool {
+	for _, c := range commands {
+		if v, ok := c.(dropTableCommand); ok && v.table == table {
+			return true
+		}
+	}
+
+	return false
+}
+
+func createsTable(commands []command, table string) bool {
+	for _, c := range commands {
+		if v, ok := c.(createTableCommand); ok && v.t.Name == table {
+			return true
+		}
+	}
+
+	return false
+}
+
+func renamesTable(commands []command, old string, new string) bool {
+	for _, c := range commands {
+		if v, ok := c.(renameTableCommand); ok && v.old == old && v.new == new {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,177 @@
+package migrator
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLint(t *testing.T) {
+	t.Run("it reports missing Up and Down, that can't be derived", func(t *testing.T) {
+		issues := Lint([]Migration{
+			{Name: "no_up"},
+			{Name: "no_down", Up: func() Schema {
+				var s Schema
+				s.AlterTable("posts", TableCommands{DropColumnCommand("title")})
+				return s
+			}},
+			{Name: "derived", Up: func() Schema {
+				var s Schema
+				s.RenameTable("posts", "articles")
+				return s
+			}},
+		})
+
+		assert.Equal(t, []LintIssue{
+			{Migration: "no_up", Message: "Up is missing"},
+			{Migration: "no_down", Message: "Down is missing and can't be derived from Up: \"DROP COLUMN `title`\" is not reversible"},
+		}, issues)
+	})
+
+	t.Run("it passes reversible migration", func(t *testing.T) {
+		posts := Table{Name: "posts"}
+		posts.ID("id")
+		posts.Varchar("title", 191)
+		posts.Unique("title")
+
+		issues := Lint([]Migration{{
+			Name: "test",
+			Up: func() Schema {
+				var s Schema
+				s.CreateTable(posts)
+				s.AlterTable("comments", TableCommands{
+					AddColumnCommand{Name: "post_id", Column: Integer{Nullable: true}},
+					AddIndexCommand{Name: "idx_post", Columns: []string{"post_id"}},
+					AddForeignCommand{Foreign{Key: "fk_post", Column: "post_id", Reference: "id", On: "posts"}},
+					RenameColumnCommand{Old: "body", New: "content"},
+				})
+				s.RenameTable("users", "authors")
+				return s
+			},
+			Down: func() Schema {
+				var s Schema
+				s.RenameTable("authors", "users")
+				s.AlterTable("comments", TableCommands{
+					RenameColumnCommand{Old: "content", New: "body"},
+					DropForeignCommand("fk_post"),
+					DropIndexCommand("idx_post"),
+					DropColumnCommand("post_id"),
+				})
+				s.DropTableIfExists("posts")
+				return s
+			},
+		}})
+
+		assert.Len(t, issues, 0)
+	})
+
+	t.Run("it reports Down, that doesn't reverse Up", func(t *testing.T) {
+		issues := Lint([]Migration{{
+			Name: "test",
+			Up: func() Schema {
+				var s Schema
+				s.CreateTable(Table{Name: "posts"})
+				s.DropTable("logs", false, "")
+				s.RenameTable("users", "authors")
+				s.AlterTable("comments", TableCommands{DropColumnCommand("rating"), AddUniqueIndexCommand{Key: "idx", Columns: []string{"a"}}})
+				return s
+			},
+			Down: func() Schema {
+				var s Schema
+				s.AlterTable("comments", TableCommands{DropIndexCommand("idx")})
+				return s
+			},
+		}})
+
+		assert.Equal(t, []LintIssue{
+			{Migration: "test", Message: `Down doesn't drop table "posts"`},
+			{Migration: "test", Message: `Down doesn't create table "logs"`},
+			{Migration: "test", Message: `Down doesn't rename table "authors" back to "users"`},
+			{Migration: "test", Message: "Down doesn't reverse \"DROP COLUMN `rating`\" on table \"comments\""},
+		}, issues)
+	})
+
+	t.Run("it reports column pitfalls", func(t *testing.T) {
+		events := Table{Name: "events"}
+		events.Column("status", Enum{Values: []string{"new", "done"}, Default: "active"})
+		events.Column("flags", Enum{Values: []string{"a", "b"}, Default: "a,c", Multiple: true})
+		events.Column("empty", Enum{Values: []string{""}, Default: "<empty>"})
+		events.PreciseTimestamp("created_at", 9, false, "")
+		events.Varchar("title", 255)
+		events.Column("code", String{Precision: 255, Charset: "latin1"})
+		events.Index("idx_title", "title", "code")
+
+		issues := Lint([]Migration{{
+			Name: "test",
+			Up: func() Schema {
+				var s Schema
+				s.CreateTable(events)
+				s.AlterTable("comments", TableCommands{
+					AddColumnCommand{Name: "rating", Column: Integer{}},
+					AddColumnCommand{Name: "id", Column: Integer{Autoincrement: true}},
+					AddForeignCommand{Foreign{Key: "fk_user", Column: "user_id", Reference: "id", On: "users"}},
+				})
+				return s
+			},
+			Down: func() Schema {
+				var s Schema
+				s.DropTable("events", false, "")
+				s.DropTable("comments", false, "")
+				return s
+			},
+		}})
+
+		assert.Equal(t, []LintIssue{
+			{Migration: "test", Message: `Default value "active" of column "status" on table "events" is not listed in values`},
+			{Migration: "test", Message: `Default value "c" of column "flags" on table "events" is not listed in values`},
+			{Migration: "test", Message: `Precision 9 of column "created_at" on table "events" is out of 0-6 range and is ignored`},
+			{Migration: "test", Message: `Column "title" on table "events" takes 1020 bytes, which exceeds index limit of 767 bytes`},
+			{Migration: "test", Message: `NOT NULL column "rating" is added to table "comments" without default value`},
+			{Migration: "test", Message: `Foreign key "fk_user" on table "comments" has no index on column "user_id"`},
+		}, issues)
+	})
+
+	t.Run("it relies on indexes created by previous migrations", func(t *testing.T) {
+		issues := Lint([]Migration{
+			{
+				Name: "index",
+				Up: func() Schema {
+					var s Schema
+					s.AlterTable("comments", TableCommands{AddIndexCommand{Name: "idx_user", Columns: []string{"user_id", "created_at"}}})
+					return s
+				},
+				Down: func() Schema {
+					var s Schema
+					s.AlterTable("comments", TableCommands{DropIndexCommand("idx_user")})
+					return s
+				},
+			},
+			{
+				Name: "foreign",
+				Up: func() Schema {
+					var s Schema
+					s.AlterTable("comments", TableCommands{AddForeignCommand{Foreign{Key: "fk_user", Column: "user_id", Reference: "id", On: "users"}}})
+					return s
+				},
+				Down: func() Schema {
+					var s Schema
+					s.AlterTable("comments", TableCommands{DropForeignCommand("fk_user")})
+					return s
+				},
+			},
+		})
+
+		assert.Len(t, issues, 0)
+	})
+}
+
+func TestBytesPerChar(t *testing.T) {
+	assert.Equal(t, 4, bytesPerChar("", ""))
+	assert.Equal(t, 3, bytesPerChar("utf8", ""))
+	assert.Equal(t, 1, bytesPerChar("", "latin1_swedish_ci"))
+	assert.Equal(t, 4, bytesPerChar("", "utf8mb4_general_ci"))
+}
+
+func TestLintIssueString(t *testing.T) {
+	assert.Equal(t, `Migration "test": Down is missing`, LintIssue{Migration: "test", Message: "Down is missing"}.String())
+}
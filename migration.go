@@ -4,14 +4,26 @@ import "database/sql"
 
 type executableSQL interface {
 	Exec(query string, args ...interface{}) (sql.Result, error)
+	Query(query string, args ...interface{}) (*sql.Rows, error)
+	QueryRow(query string, args ...interface{}) *sql.Row
+}
+
+type transactableSQL interface {
+	executableSQL
+	Begin() (*sql.Tx, error)
 }
 
 // Migration represents migration entity
 //
 // Name 		should be a unique name to specify migration. It is up to you to choose the name you like
 // Up() 		should return Schema with prepared commands to be migrated
-// Down()		should return Schema with prepared commands to be reverted
+// Down()		should return Schema with prepared commands to be reverted, it is derived from Up() if not set
 // Transaction	optinal flag to enable transaction for migration
+// Session		optional session variables to be set while migration is running
+// Online		optional settings to alter tables through a shadow table copy
+// AllowTableCopy	optional flag to alter tables bigger than Migrator.MaxCopySize
+// AllowDestructive	optional flag to run BC incompatible commands, when Migrator.BlockDestructive is set
+// Replaces		optional names of migrations squashed into this one, see Generator.Squash
 //
 // Example:
 //		var migration = migrator.Migration{
@@ -38,21 +50,38 @@ type executableSQL interface {
 //			},
 //		}
 type Migration struct {
-	Name        string
-	Up          func() Schema
-	Down        func() Schema
-	Transaction bool
+	Name             string
+	Up               func() Schema
+	Down             func() Schema
+	Transaction      bool
+	Session          map[string]string
+	Online           *OnlineOptions
+	AllowTableCopy   bool
+	AllowDestructive bool
+	Replaces         []string
 }
 
-func (m Migration) exec(db *sql.DB, commands ...command) error {
+// exec runs migration commands and calls track to update migration table afterwards.
+// Within transactional migration track is a part of the same transaction.
+func (m Migration) exec(db transactableSQL, retry RetryPolicy, track func(executableSQL) error, commands ...command) error {
 	if m.Transaction {
-		return runInTransaction(db, commands...)
+		return retry.transaction(func() error {
+			return runInTransaction(db, track, commands...)
+		}, retry.retryableOn(db))
+	}
+
+	if err := retry.run(db, commands...); err != nil {
+		return err
 	}
 
-	return run(db, commands...)
+	if track != nil {
+		return track(db)
+	}
+
+	return nil
 }
 
-func runInTransaction(db *sql.DB, commands ...command) error {
+func runInTransaction(db transactableSQL, track func(executableSQL) error, commands ...command) error {
 	tx, err := db.Begin()
 	if err != nil {
 		return err
@@ -64,16 +93,35 @@ func runInTransaction(db *sql.DB, commands ...command) error {
 		return err
 	}
 
-	err = tx.Commit()
-	if err != nil {
-		return err
+	if track != nil {
+		if err := track(tx); err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return commitError{err}
 	}
 
 	return nil
 }
 
+// procedure is a command, that interacts with the database while running instead of being a single statement
+type procedure interface {
+	command
+	exec(db executableSQL) error
+}
+
 func run(db executableSQL, commands ...command) error {
 	for _, command := range commands {
+		if p, ok := command.(procedure); ok {
+			if err := p.exec(db); err != nil {
+				return err
+			}
+			continue
+		}
+
 		sql := command.toSQL()
 		if sql == "" {
 			return ErrNoSQLCommandsToRun
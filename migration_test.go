@@ -23,6 +23,22 @@ func (c testDummyCommand) toSQL() string {
 	return string(c)
 }
 
+type testProcedure []string
+
+func (p testProcedure) toSQL() string {
+	return ""
+}
+
+func (p testProcedure) exec(db executableSQL) error {
+	for _, sql := range p {
+		if _, err := db.Exec(sql); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
 func testDBConnection(t *testing.T) (db *sql.DB, mock sqlmock.Sqlmock, resetDB func()) {
 	db, mock, err := sqlmock.New()
 	if err != nil {
@@ -59,7 +75,7 @@ func TestMigrationExec(t *testing.T) {
 		mock.ExpectCommit()
 
 		// now we execute our method
-		if err := m.exec(db, commands...); err != nil {
+		if err := m.exec(db, RetryPolicy{}, nil, commands...); err != nil {
 			t.Errorf("error was not expected while running query: %s", err)
 		}
 	})
@@ -77,7 +93,7 @@ func TestMigrationExec(t *testing.T) {
 		mock.ExpectExec(commands[1].toSQL()).WillReturnResult(sqlmock.NewResult(2, 1))
 
 		// now we execute our method
-		if err := m.exec(db, commands...); err != nil {
+		if err := m.exec(db, RetryPolicy{}, nil, commands...); err != nil {
 			t.Errorf("error was not expected while running query: %s", err)
 		}
 	})
@@ -93,7 +109,7 @@ func TestRunInTransaction(t *testing.T) {
 		mock.ExpectBegin().WillReturnError(want)
 
 		// now we execute our method
-		got := runInTransaction(db, commands...)
+		got := runInTransaction(db, nil, commands...)
 		assert.Equal(t, want, got)
 	})
 
@@ -109,7 +125,7 @@ func TestRunInTransaction(t *testing.T) {
 		mock.ExpectRollback()
 
 		// now we execute our method
-		got := runInTransaction(db, commands...)
+		got := runInTransaction(db, nil, commands...)
 		assert.Equal(t, want, got)
 	})
 
@@ -124,8 +140,45 @@ func TestRunInTransaction(t *testing.T) {
 		mock.ExpectCommit().WillReturnError(want)
 
 		// now we execute our method
-		got := runInTransaction(db, commands...)
-		assert.Equal(t, want, got)
+		got := runInTransaction(db, nil, commands...)
+		assert.Equal(t, commitError{want}, got)
+	})
+
+	t.Run("it rolled back transaction if tracking failed", func(t *testing.T) {
+		db, mock, resetDB := testDBConnection(t)
+		defer resetDB()
+
+		commands := []command{testDummyCommand("run")}
+		track := func(tx executableSQL) error {
+			_, err := tx.Exec("track")
+			return err
+		}
+
+		mock.ExpectBegin()
+		mock.ExpectExec("run").WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectExec("track").WillReturnError(errTestDBExecFailed)
+		mock.ExpectRollback()
+
+		got := runInTransaction(db, track, commands...)
+		assert.Equal(t, errTestDBExecFailed, got)
+	})
+
+	t.Run("it executes all commands and tracking within transaction", func(t *testing.T) {
+		db, mock, resetDB := testDBConnection(t)
+		defer resetDB()
+
+		commands := []command{testDummyCommand("run")}
+		track := func(tx executableSQL) error {
+			_, err := tx.Exec("track")
+			return err
+		}
+
+		mock.ExpectBegin()
+		mock.ExpectExec("run").WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectExec("track").WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectCommit()
+
+		assert.Nil(t, runInTransaction(db, track, commands...))
 	})
 
 	t.Run("it executes all commands", func(t *testing.T) {
@@ -143,7 +196,7 @@ func TestRunInTransaction(t *testing.T) {
 		mock.ExpectCommit()
 
 		// now we execute our method
-		if err := runInTransaction(db, commands...); err != nil {
+		if err := runInTransaction(db, nil, commands...); err != nil {
 			t.Errorf("error was not expected while running query: %s", err)
 		}
 	})
@@ -201,4 +254,16 @@ func TestRun(t *testing.T) {
 
 		assert.Nil(t, err)
 	})
+
+	t.Run("it executes procedures", func(t *testing.T) {
+		db, mock, resetDB := testDBConnection(t)
+		defer resetDB()
+
+		mock.ExpectExec("first").WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec("second").WillReturnError(errTestDBExecFailed)
+
+		err := run(db, testProcedure{"first", "second"})
+
+		assert.Equal(t, errTestDBExecFailed, err)
+	})
 }
@@ -29,6 +29,9 @@ var (
 
 	// ErrNoSQLCommandsToRun returns when migration is invalid and has no commands in the pool
 	ErrNoSQLCommandsToRun = errors.New("There are no commands to be executed")
+
+	// ErrNoSchemaChanges returns when database already matches desired tables and there is nothing to generate
+	ErrNoSchemaChanges = errors.New("No schema changes to generate migration")
 )
 
 type migrationEntry struct {
@@ -46,8 +49,32 @@ type Migrator struct {
 	// Name of the table to track executed migrations
 	TableName string
 	// stack of migrations
-	Pool     []Migration
+	Pool []Migration
+	// Resumable enables tracking of executed commands for non-transactional migrations,
+	// so a migration that failed in the middle continues from the failed command on the next run
+	Resumable bool
+	// RevertPartial reverts completed commands of a partially applied migration
+	// with its Down() schema instead of resuming it. It requires Resumable to be set
+	RevertPartial bool
+	// Atomic runs all pending migrations and stores them in migration table within a single transaction.
+	// Migrations with commands, that cause an implicit commit, are refused
+	Atomic bool
+	// Retry policy for migrations failed on transient errors, e.g. deadlocks or lock wait timeouts
+	Retry RetryPolicy
+	// MaxCopySize refuses altering tables bigger than this size (in bytes), if table might be copied,
+	// unless migration allows it explicitly. Zero disables the check
+	MaxCopySize uint64
+	// BlockDestructive refuses BC incompatible commands, unless migration allows them explicitly
+	BlockDestructive bool
+	// Dialect renders commands and queries of migration table for the database, default: MySQL
+	Dialect Dialect
+	// ServerVersion of MySQL server, e.g. "5.7.44" or "10.6.12-MariaDB". Version is detected with `SELECT VERSION()`,
+	// if it isn't set and migrations contain commands, that depend on it
+	ServerVersion string
+
 	executed []migrationEntry
+	progress map[string]int
+	server   *serverVersion
 }
 
 // Migrate runs all migrations from pool and stores in migration table executed migration.
@@ -56,6 +83,10 @@ func (m Migrator) Migrate(db *sql.DB) (migrated []string, err error) {
 		return migrated, ErrNoMigrationDefined
 	}
 
+	if m.RevertPartial && !m.Resumable {
+		return migrated, errRevertPartialNotResumable
+	}
+
 	if err := m.checkMigrationPool(); err != nil {
 		return migrated, err
 	}
@@ -68,26 +99,65 @@ func (m Migrator) Migrate(db *sql.DB) (migrated []string, err error) {
 		return migrated, err
 	}
 
+	if m.Resumable {
+		if err := m.createProgressTable(db); err != nil {
+			return migrated, fmt.Errorf("Progress table failed to be created: %v", err)
+		}
+
+		if err := m.fetchProgress(db); err != nil {
+			return migrated, err
+		}
+	}
+
+	if err := m.coverReplaced(db); err != nil {
+		return migrated, err
+	}
+
 	batch := m.batch() + 1
-	table := m.table()
 
-	for _, item := range m.Pool {
-		if m.isExecuted(item.Name) {
-			continue
+	pending, schemas, err := m.pending()
+	if err != nil {
+		return migrated, err
+	}
+
+	if err := m.detectServer(db, pending, schemas); err != nil {
+		return migrated, err
+	}
+
+	if m.BlockDestructive {
+		if err := m.checkDestructive(pending, schemas); err != nil {
+			return migrated, err
 		}
+	}
 
-		s := item.Up()
-		if len(s.pool) == 0 {
-			return migrated, ErrNoSQLCommandsToRun
+	if m.MaxCopySize > 0 {
+		if err := m.checkCopySize(db, pending, schemas); err != nil {
+			return migrated, err
 		}
-		if err := item.exec(db, s.pool...); err != nil {
+	}
+
+	if m.Atomic {
+		return m.migrateAtomic(db, pending, schemas, batch)
+	}
+
+	for i, item := range pending {
+		commands, err := m.migrationCommands(item, schemas[i])
+		if err != nil {
 			return migrated, err
 		}
 
-		entry := migrationEntry{name: item.Name, batch: batch}
-		sql := fmt.Sprintf("INSERT INTO `%s` (`name`, `batch`) VALUES (\"%s\", %d)", table, entry.name, entry.batch)
+		entry := func(tx executableSQL) error {
+			return m.insertEntry(tx, item.Name, batch)
+		}
+
+		err = item.withSession(db, m.dialect(), func(db transactableSQL) error {
+			if m.Resumable && !item.Transaction {
+				return m.resume(db, item, commands, entry)
+			}
 
-		if _, err := db.Exec(sql); err != nil {
+			return item.exec(db, m.Retry, entry, commands...)
+		})
+		if err != nil {
 			return migrated, err
 		}
 
@@ -119,7 +189,6 @@ func (m Migrator) Rollback(db *sql.DB) (reverted []string, err error) {
 		return reverted, ErrEmptyRollbackStack
 	}
 
-	table := m.table()
 	revertable := m.lastBatchExecuted()
 
 	for i := len(revertable) - 1; i >= 0; i-- {
@@ -129,15 +198,36 @@ func (m Migrator) Rollback(db *sql.DB) (reverted []string, err error) {
 			item := m.Pool[j]
 
 			if item.Name == name {
-				s := item.Down()
+				s, err := item.down()
+				if err != nil {
+					return reverted, err
+				}
 				if len(s.pool) == 0 {
 					return reverted, ErrNoSQLCommandsToRun
 				}
-				if err := item.exec(db, s.pool...); err != nil {
+
+				id := revertable[i].id
+				entry := func(tx executableSQL) error {
+					if err := m.deleteEntry(tx, id); err != nil {
+						return err
+					}
+
+					return m.clearProgress(tx, name)
+				}
+
+				if err := m.detectServer(db, []Migration{item}, []Schema{s}); err != nil {
 					return reverted, err
 				}
 
-				if _, err := db.Exec(fmt.Sprintf("DELETE FROM %s WHERE id = ?", table), revertable[i].id); err != nil {
+				commands, err := m.migrationCommands(item, s)
+				if err != nil {
+					return reverted, err
+				}
+
+				err = item.withSession(db, m.dialect(), func(db transactableSQL) error {
+					return item.exec(db, m.Retry, entry, commands...)
+				})
+				if err != nil {
 					return reverted, err
 				}
 
@@ -171,8 +261,6 @@ func (m Migrator) Revert(db *sql.DB) (reverted []string, err error) {
 		return reverted, ErrEmptyRollbackStack
 	}
 
-	table := m.table()
-
 	for i := len(m.executed) - 1; i >= 0; i-- {
 		name := m.executed[i].name
 
@@ -180,15 +268,36 @@ func (m Migrator) Revert(db *sql.DB) (reverted []string, err error) {
 			item := m.Pool[j]
 
 			if item.Name == name {
-				s := item.Down()
+				s, err := item.down()
+				if err != nil {
+					return reverted, err
+				}
 				if len(s.pool) == 0 {
 					return reverted, ErrNoSQLCommandsToRun
 				}
-				if err := item.exec(db, s.pool...); err != nil {
+
+				id := m.executed[i].id
+				entry := func(tx executableSQL) error {
+					if err := m.deleteEntry(tx, id); err != nil {
+						return err
+					}
+
+					return m.clearProgress(tx, name)
+				}
+
+				if err := m.detectServer(db, []Migration{item}, []Schema{s}); err != nil {
+					return reverted, err
+				}
+
+				commands, err := m.migrationCommands(item, s)
+				if err != nil {
 					return reverted, err
 				}
 
-				if _, err := db.Exec(fmt.Sprintf("DELETE FROM %s WHERE id = ?", table), m.executed[i].id); err != nil {
+				err = item.withSession(db, m.dialect(), func(db transactableSQL) error {
+					return item.exec(db, m.Retry, entry, commands...)
+				})
+				if err != nil {
 					return reverted, err
 				}
 
@@ -200,21 +309,96 @@ func (m Migrator) Revert(db *sql.DB) (reverted []string, err error) {
 	return reverted, nil
 }
 
+// pending returns migrations, that were not executed yet, with their Up() schemas.
+// Schemas are validated before anything is executed.
+func (m Migrator) pending() (pending []Migration, schemas []Schema, err error) {
+	replaced := m.replaced()
+
+	for _, item := range m.Pool {
+		if m.isExecuted(item.Name) {
+			continue
+		}
+
+		// replaced migration is covered by its baseline, that is either executed or runs instead of it
+		if _, ok := replaced[item.Name]; ok {
+			continue
+		}
+
+		if applied := m.executedReplaced(item); len(applied) > 0 {
+			if len(applied) == len(item.Replaces) {
+				continue
+			}
+
+			return nil, nil, fmt.Errorf(
+				`Migration "%s" can't be applied, migrations it replaces are applied partially: "%s"`,
+				item.Name,
+				strings.Join(applied, `", "`),
+			)
+		}
+
+		s := item.Up()
+		if len(s.pool) == 0 {
+			return nil, nil, ErrNoSQLCommandsToRun
+		}
+
+		if item.Online != nil && item.Transaction {
+			return nil, nil, fmt.Errorf(`Migration "%s" can't alter tables online within transaction`, item.Name)
+		}
+
+		if item.Online != nil && !isMySQL(m.dialect()) {
+			return nil, nil, fmt.Errorf(`Migration "%s" can alter tables online with MySQL dialect only`, item.Name)
+		}
+
+		if err := s.validate(); err != nil {
+			return nil, nil, fmt.Errorf(`Migration "%s" is invalid: %v`, item.Name, err)
+		}
+
+		if _, err := m.migrationCommands(item, s); err != nil {
+			return nil, nil, fmt.Errorf(`Migration "%s" is invalid: %v`, item.Name, err)
+		}
+
+		pending = append(pending, item)
+		schemas = append(schemas, s)
+	}
+
+	return pending, schemas, nil
+}
+
+// migrationCommands renders the schema of the migration, AlterTable commands are wrapped to be run online
+// before resumable and regular execution split, so both of them alter tables online.
+func (m Migrator) migrationCommands(item Migration, s Schema) ([]command, error) {
+	commands, err := m.commands(s)
+	if err != nil || item.Online == nil {
+		return commands, err
+	}
+
+	return item.Online.wrap(commands)
+}
+
 func (m Migrator) checkMigrationPool() error {
-	var names []string
+	names := make(map[string]bool, len(m.Pool))
+	replaced := map[string]string{}
 
 	for _, item := range m.Pool {
 		if item.Name == "" {
 			return ErrMissingMigrationName
 		}
 
-		for _, exist := range names {
-			if exist == item.Name {
-				return fmt.Errorf(`Migration "%s" is duplicated in the pool`, exist)
-			}
+		if names[item.Name] {
+			return fmt.Errorf(`Migration "%s" is duplicated in the pool`, item.Name)
 		}
+		names[item.Name] = true
+
+		for _, name := range item.Replaces {
+			if name == item.Name {
+				return fmt.Errorf(`Migration "%s" can't replace itself`, item.Name)
+			}
 
-		names = append(names, item.Name)
+			if baseline, ok := replaced[name]; ok {
+				return fmt.Errorf(`Migration "%s" is replaced by both "%s" and "%s"`, name, baseline, item.Name)
+			}
+			replaced[name] = item.Name
+		}
 	}
 
 	return nil
@@ -225,26 +409,20 @@ func (m Migrator) createMigrationTable(db *sql.DB) error {
 		return nil
 	}
 
-	sql := fmt.Sprintf(
-		"CREATE TABLE %s (%s) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci",
-		m.table(),
-		strings.Join([]string{
-			"id int(10) unsigned NOT NULL AUTO_INCREMENT PRIMARY KEY",
-			"name varchar(255) COLLATE utf8mb4_unicode_ci NOT NULL",
-			"batch int(11) NOT NULL",
-			"applied_at timestamp(6) NULL DEFAULT CURRENT_TIMESTAMP(6)",
-		}, ", "),
-	)
-
-	_, err := db.Exec(sql)
+	d := m.dialect()
+	_, err := db.Exec(d.createMigrationTable(d.quote(m.table())))
 
 	return err
 }
 
 func (m Migrator) hasTable(db *sql.DB) bool {
-	_, hasTable := db.Query("SELECT * FROM " + m.table())
+	rows, err := db.Query("SELECT * FROM " + m.dialect().quote(m.table()))
+	if err != nil {
+		return false
+	}
+	rows.Close()
 
-	return hasTable == nil
+	return true
 }
 
 func (m Migrator) table() string {
@@ -269,10 +447,12 @@ func (m Migrator) batch() uint64 {
 }
 
 func (m *Migrator) fetchExecuted(db *sql.DB) error {
-	rows, err := db.Query("SELECT id, name, batch, applied_at FROM " + m.table() + " ORDER BY applied_at ASC")
+	rows, err := db.Query("SELECT id, name, batch, applied_at FROM " + m.dialect().quote(m.table()) + " ORDER BY applied_at ASC")
 	if err != nil {
 		return err
 	}
+	defer rows.Close()
+
 	m.executed = []migrationEntry{}
 
 	for rows.Next() {
@@ -288,6 +468,28 @@ func (m *Migrator) fetchExecuted(db *sql.DB) error {
 	return nil
 }
 
+func (m Migrator) insertEntry(db executableSQL, name string, batch uint64) error {
+	d := m.dialect()
+	sql := fmt.Sprintf(
+		"INSERT INTO %s (%s, %s) VALUES (%s, %d)",
+		d.quote(m.table()),
+		d.quote("name"),
+		d.quote("batch"),
+		d.literal(name),
+		batch,
+	)
+	_, err := db.Exec(sql)
+
+	return err
+}
+
+func (m Migrator) deleteEntry(db executableSQL, id uint64) error {
+	d := m.dialect()
+	_, err := db.Exec(d.rebind(fmt.Sprintf("DELETE FROM %s WHERE id = ?", d.quote(m.table()))), id)
+
+	return err
+}
+
 func (m Migrator) isExecuted(name string) bool {
 	for _, item := range m.executed {
 		if item.name == name {
@@ -41,7 +41,7 @@ func TestMigrate(t *testing.T) {
 		db, mock, resetDB := testDBConnection(t)
 		defer resetDB()
 
-		mock.ExpectQuery("SELECT").WillReturnRows().WillReturnError(errTestDBQueryFailed)
+		mock.ExpectQuery("SELECT").WillReturnRows(sqlmock.NewRows(nil)).WillReturnError(errTestDBQueryFailed)
 		mock.ExpectExec("CREATE").WillReturnError(errTestDBExecFailed)
 
 		migrated, err := m.Migrate(db)
@@ -57,8 +57,8 @@ func TestMigrate(t *testing.T) {
 		db, mock, resetDB := testDBConnection(t)
 		defer resetDB()
 
-		mock.ExpectQuery("SELECT").WillReturnRows()
-		mock.ExpectQuery("SELECT id, name, batch, applied_at FROM migrations").WillReturnError(errTestDBExecFailed)
+		mock.ExpectQuery("SELECT").WillReturnRows(sqlmock.NewRows(nil))
+		mock.ExpectQuery("SELECT id, name, batch, applied_at FROM `migrations`").WillReturnError(errTestDBExecFailed)
 
 		migrated, err := m.Migrate(db)
 
@@ -75,8 +75,8 @@ func TestMigrate(t *testing.T) {
 
 		rows := sqlmock.NewRows([]string{"id", "name", "batch", "applied_at"}).AddRow(1, "test", 1, time.Now())
 
-		mock.ExpectQuery("SELECT").WillReturnRows()
-		mock.ExpectQuery("SELECT id, name, batch, applied_at FROM migrations").WillReturnRows(rows)
+		mock.ExpectQuery("SELECT").WillReturnRows(sqlmock.NewRows(nil))
+		mock.ExpectQuery("SELECT id, name, batch, applied_at FROM `migrations`").WillReturnRows(rows)
 
 		migrated, err := m.Migrate(db)
 
@@ -95,8 +95,8 @@ func TestMigrate(t *testing.T) {
 
 		rows := sqlmock.NewRows([]string{"id", "name", "batch", "applied_at"}).AddRow(1, "new", 1, time.Now())
 
-		mock.ExpectQuery("SELECT").WillReturnRows()
-		mock.ExpectQuery("SELECT id, name, batch, applied_at FROM migrations").WillReturnRows(rows)
+		mock.ExpectQuery("SELECT").WillReturnRows(sqlmock.NewRows(nil))
+		mock.ExpectQuery("SELECT id, name, batch, applied_at FROM `migrations`").WillReturnRows(rows)
 
 		migrated, err := m.Migrate(db)
 
@@ -117,8 +117,8 @@ func TestMigrate(t *testing.T) {
 
 		rows := sqlmock.NewRows([]string{"id", "name", "batch", "applied_at"}).AddRow(1, "new", 1, time.Now())
 
-		mock.ExpectQuery("SELECT").WillReturnRows()
-		mock.ExpectQuery("SELECT id, name, batch, applied_at FROM migrations").WillReturnRows(rows)
+		mock.ExpectQuery("SELECT").WillReturnRows(sqlmock.NewRows(nil))
+		mock.ExpectQuery("SELECT id, name, batch, applied_at FROM `migrations`").WillReturnRows(rows)
 
 		migrated, err := m.Migrate(db)
 
@@ -139,8 +139,8 @@ func TestMigrate(t *testing.T) {
 
 		rows := sqlmock.NewRows([]string{"id", "name", "batch", "applied_at"}).AddRow(1, "new", 1, time.Now())
 
-		mock.ExpectQuery("SELECT").WillReturnRows()
-		mock.ExpectQuery("SELECT id, name, batch, applied_at FROM migrations").WillReturnRows(rows)
+		mock.ExpectQuery("SELECT").WillReturnRows(sqlmock.NewRows(nil))
+		mock.ExpectQuery("SELECT id, name, batch, applied_at FROM `migrations`").WillReturnRows(rows)
 		mock.ExpectExec("DROP").WillReturnResult(sqlmock.NewResult(1, 1))
 		mock.ExpectExec("INSERT").WillReturnError(errTestDBExecFailed)
 
@@ -163,8 +163,8 @@ func TestMigrate(t *testing.T) {
 
 		rows := sqlmock.NewRows([]string{"id", "name", "batch", "applied_at"}).AddRow(1, "new", 4, time.Now())
 
-		mock.ExpectQuery("SELECT").WillReturnRows()
-		mock.ExpectQuery("SELECT id, name, batch, applied_at FROM migrations").WillReturnRows(rows)
+		mock.ExpectQuery("SELECT").WillReturnRows(sqlmock.NewRows(nil))
+		mock.ExpectQuery("SELECT id, name, batch, applied_at FROM `migrations`").WillReturnRows(rows)
 		mock.ExpectExec("DROP").WillReturnResult(sqlmock.NewResult(1, 1))
 		mock.ExpectExec(`INSERT .* VALUES \("test", 5\)`).WillReturnResult(sqlmock.NewResult(1, 1))
 
@@ -174,6 +174,56 @@ func TestMigrate(t *testing.T) {
 		assert.Equal(t, migrated[0], "test")
 		assert.Nil(t, err)
 	})
+
+	t.Run("it validates pending migrations before execution", func(t *testing.T) {
+		m := Migrator{Pool: []Migration{
+			{Name: "first", Up: func() Schema {
+				var s Schema
+				s.DropTable("test", false, "")
+				return s
+			}},
+			{Name: "second", Up: func() Schema {
+				var s Schema
+				s.AlterTable("test", TableCommands{DropPrimaryIndexCommand{}}, AlterOptions{Algorithm: "INSTANT"})
+				return s
+			}},
+		}}
+		db, mock, resetDB := testDBConnection(t)
+		defer resetDB()
+
+		mock.ExpectQuery("SELECT").WillReturnRows(sqlmock.NewRows(nil))
+		mock.ExpectQuery("SELECT id, name, batch, applied_at FROM `migrations`").WillReturnRows(sqlmock.NewRows([]string{"id"}))
+
+		migrated, err := m.Migrate(db)
+
+		assert.Len(t, migrated, 0)
+		assert.EqualError(t, err, `Migration "second" is invalid: ALGORITHM=INSTANT is not supported for "DROP PRIMARY KEY" on table "test"`)
+	})
+
+	t.Run("it stores executed migration within migration transaction", func(t *testing.T) {
+		migration := Migration{Name: "test", Transaction: true, Up: func() Schema {
+			var s Schema
+			s.CustomCommand(testDummyCommand("UPDATE test SET active = 1"))
+			return s
+		}}
+		m := Migrator{Pool: []Migration{migration}}
+		db, mock, resetDB := testDBConnection(t)
+		defer resetDB()
+
+		rows := sqlmock.NewRows([]string{"id", "name", "batch", "applied_at"}).AddRow(1, "new", 4, time.Now())
+
+		mock.ExpectQuery("SELECT").WillReturnRows(sqlmock.NewRows(nil))
+		mock.ExpectQuery("SELECT id, name, batch, applied_at FROM `migrations`").WillReturnRows(rows)
+		mock.ExpectBegin()
+		mock.ExpectExec("UPDATE test").WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(`INSERT .* VALUES \("test", 5\)`).WillReturnError(errTestDBExecFailed)
+		mock.ExpectRollback()
+
+		migrated, err := m.Migrate(db)
+
+		assert.Len(t, migrated, 0)
+		assert.Equal(t, errTestDBExecFailed, err)
+	})
 }
 
 func TestRollback(t *testing.T) {
@@ -208,7 +258,7 @@ func TestRollback(t *testing.T) {
 		db, mock, resetDB := testDBConnection(t)
 		defer resetDB()
 
-		mock.ExpectQuery("SELECT").WillReturnRows().WillReturnError(errTestDBQueryFailed)
+		mock.ExpectQuery("SELECT").WillReturnRows(sqlmock.NewRows(nil)).WillReturnError(errTestDBQueryFailed)
 
 		reverted, err := m.Rollback(db)
 
@@ -223,8 +273,8 @@ func TestRollback(t *testing.T) {
 		db, mock, resetDB := testDBConnection(t)
 		defer resetDB()
 
-		mock.ExpectQuery("SELECT").WillReturnRows()
-		mock.ExpectQuery("SELECT id, name, batch, applied_at FROM migrations").WillReturnError(errTestDBExecFailed)
+		mock.ExpectQuery("SELECT").WillReturnRows(sqlmock.NewRows(nil))
+		mock.ExpectQuery("SELECT id, name, batch, applied_at FROM `migrations`").WillReturnError(errTestDBExecFailed)
 
 		reverted, err := m.Rollback(db)
 
@@ -239,8 +289,8 @@ func TestRollback(t *testing.T) {
 		db, mock, resetDB := testDBConnection(t)
 		defer resetDB()
 
-		mock.ExpectQuery("SELECT").WillReturnRows()
-		mock.ExpectQuery("SELECT id, name, batch, applied_at FROM migrations").WillReturnRows(sqlmock.NewRows([]string{}))
+		mock.ExpectQuery("SELECT").WillReturnRows(sqlmock.NewRows(nil))
+		mock.ExpectQuery("SELECT id, name, batch, applied_at FROM `migrations`").WillReturnRows(sqlmock.NewRows([]string{}))
 
 		reverted, err := m.Rollback(db)
 
@@ -261,8 +311,8 @@ func TestRollback(t *testing.T) {
 
 		rows := sqlmock.NewRows([]string{"id", "name", "batch", "applied_at"}).AddRow(1, "new", 1, time.Now())
 
-		mock.ExpectQuery("SELECT").WillReturnRows()
-		mock.ExpectQuery("SELECT id, name, batch, applied_at FROM migrations").WillReturnRows(rows)
+		mock.ExpectQuery("SELECT").WillReturnRows(sqlmock.NewRows(nil))
+		mock.ExpectQuery("SELECT id, name, batch, applied_at FROM `migrations`").WillReturnRows(rows)
 
 		reverted, err := m.Rollback(db)
 
@@ -281,8 +331,8 @@ func TestRollback(t *testing.T) {
 
 		rows := sqlmock.NewRows([]string{"id", "name", "batch", "applied_at"}).AddRow(1, "test", 1, time.Now())
 
-		mock.ExpectQuery("SELECT").WillReturnRows()
-		mock.ExpectQuery("SELECT id, name, batch, applied_at FROM migrations").WillReturnRows(rows)
+		mock.ExpectQuery("SELECT").WillReturnRows(sqlmock.NewRows(nil))
+		mock.ExpectQuery("SELECT id, name, batch, applied_at FROM `migrations`").WillReturnRows(rows)
 
 		reverted, err := m.Rollback(db)
 
@@ -303,8 +353,8 @@ func TestRollback(t *testing.T) {
 
 		rows := sqlmock.NewRows([]string{"id", "name", "batch", "applied_at"}).AddRow(1, "test", 1, time.Now())
 
-		mock.ExpectQuery("SELECT").WillReturnRows()
-		mock.ExpectQuery("SELECT id, name, batch, applied_at FROM migrations").WillReturnRows(rows)
+		mock.ExpectQuery("SELECT").WillReturnRows(sqlmock.NewRows(nil))
+		mock.ExpectQuery("SELECT id, name, batch, applied_at FROM `migrations`").WillReturnRows(rows)
 
 		reverted, err := m.Rollback(db)
 
@@ -325,8 +375,8 @@ func TestRollback(t *testing.T) {
 
 		rows := sqlmock.NewRows([]string{"id", "name", "batch", "applied_at"}).AddRow(1, "test", 1, time.Now())
 
-		mock.ExpectQuery("SELECT").WillReturnRows()
-		mock.ExpectQuery("SELECT id, name, batch, applied_at FROM migrations").WillReturnRows(rows)
+		mock.ExpectQuery("SELECT").WillReturnRows(sqlmock.NewRows(nil))
+		mock.ExpectQuery("SELECT id, name, batch, applied_at FROM `migrations`").WillReturnRows(rows)
 		mock.ExpectExec("DROP").WillReturnResult(sqlmock.NewResult(1, 1))
 		mock.ExpectExec("DELETE").WillReturnError(errTestDBExecFailed)
 
@@ -351,10 +401,10 @@ func TestRollback(t *testing.T) {
 			AddRow(1, "test", 4, time.Now()).
 			AddRow(2, "new", 3, time.Now())
 
-		mock.ExpectQuery("SELECT").WillReturnRows()
-		mock.ExpectQuery("SELECT id, name, batch, applied_at FROM migrations").WillReturnRows(rows)
+		mock.ExpectQuery("SELECT").WillReturnRows(sqlmock.NewRows(nil))
+		mock.ExpectQuery("SELECT id, name, batch, applied_at FROM `migrations`").WillReturnRows(rows)
 		mock.ExpectExec("DROP").WillReturnResult(sqlmock.NewResult(1, 1))
-		mock.ExpectExec("DELETE FROM migrations WHERE id = ?").WithArgs(1).WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectExec("DELETE FROM `migrations` WHERE id = ?").WithArgs(1).WillReturnResult(sqlmock.NewResult(1, 1))
 
 		reverted, err := m.Rollback(db)
 
@@ -364,6 +414,33 @@ func TestRollback(t *testing.T) {
 	})
 }
 
+func TestRollbackInTransaction(t *testing.T) {
+	t.Run("it removes executed migration info within migration transaction", func(t *testing.T) {
+		migration := Migration{Name: "test", Transaction: true, Down: func() Schema {
+			var s Schema
+			s.CustomCommand(testDummyCommand("UPDATE test SET active = 0"))
+			return s
+		}}
+		m := Migrator{Pool: []Migration{migration}}
+		db, mock, resetDB := testDBConnection(t)
+		defer resetDB()
+
+		rows := sqlmock.NewRows([]string{"id", "name", "batch", "applied_at"}).AddRow(1, "test", 1, time.Now())
+
+		mock.ExpectQuery("SELECT").WillReturnRows(sqlmock.NewRows(nil))
+		mock.ExpectQuery("SELECT id, name, batch, applied_at FROM `migrations`").WillReturnRows(rows)
+		mock.ExpectBegin()
+		mock.ExpectExec("UPDATE test").WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec("DELETE FROM `migrations` WHERE id = ?").WithArgs(1).WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		reverted, err := m.Rollback(db)
+
+		assert.Nil(t, err)
+		assert.Equal(t, []string{"test"}, reverted)
+	})
+}
+
 func TestRevert(t *testing.T) {
 	t.Run("it fails when migration pool is empty", func(t *testing.T) {
 		m := Migrator{}
@@ -396,7 +473,7 @@ func TestRevert(t *testing.T) {
 		db, mock, resetDB := testDBConnection(t)
 		defer resetDB()
 
-		mock.ExpectQuery("SELECT").WillReturnRows().WillReturnError(errTestDBQueryFailed)
+		mock.ExpectQuery("SELECT").WillReturnRows(sqlmock.NewRows(nil)).WillReturnError(errTestDBQueryFailed)
 
 		reverted, err := m.Revert(db)
 
@@ -411,8 +488,8 @@ func TestRevert(t *testing.T) {
 		db, mock, resetDB := testDBConnection(t)
 		defer resetDB()
 
-		mock.ExpectQuery("SELECT").WillReturnRows()
-		mock.ExpectQuery("SELECT id, name, batch, applied_at FROM migrations").WillReturnError(errTestDBExecFailed)
+		mock.ExpectQuery("SELECT").WillReturnRows(sqlmock.NewRows(nil))
+		mock.ExpectQuery("SELECT id, name, batch, applied_at FROM `migrations`").WillReturnError(errTestDBExecFailed)
 
 		reverted, err := m.Revert(db)
 
@@ -427,8 +504,8 @@ func TestRevert(t *testing.T) {
 		db, mock, resetDB := testDBConnection(t)
 		defer resetDB()
 
-		mock.ExpectQuery("SELECT").WillReturnRows()
-		mock.ExpectQuery("SELECT id, name, batch, applied_at FROM migrations").WillReturnRows(sqlmock.NewRows([]string{}))
+		mock.ExpectQuery("SELECT").WillReturnRows(sqlmock.NewRows(nil))
+		mock.ExpectQuery("SELECT id, name, batch, applied_at FROM `migrations`").WillReturnRows(sqlmock.NewRows([]string{}))
 
 		reverted, err := m.Revert(db)
 
@@ -449,8 +526,8 @@ func TestRevert(t *testing.T) {
 
 		rows := sqlmock.NewRows([]string{"id", "name", "batch", "applied_at"}).AddRow(1, "new", 1, time.Now())
 
-		mock.ExpectQuery("SELECT").WillReturnRows()
-		mock.ExpectQuery("SELECT id, name, batch, applied_at FROM migrations").WillReturnRows(rows)
+		mock.ExpectQuery("SELECT").WillReturnRows(sqlmock.NewRows(nil))
+		mock.ExpectQuery("SELECT id, name, batch, applied_at FROM `migrations`").WillReturnRows(rows)
 
 		reverted, err := m.Revert(db)
 
@@ -469,8 +546,8 @@ func TestRevert(t *testing.T) {
 
 		rows := sqlmock.NewRows([]string{"id", "name", "batch", "applied_at"}).AddRow(1, "test", 1, time.Now())
 
-		mock.ExpectQuery("SELECT").WillReturnRows()
-		mock.ExpectQuery("SELECT id, name, batch, applied_at FROM migrations").WillReturnRows(rows)
+		mock.ExpectQuery("SELECT").WillReturnRows(sqlmock.NewRows(nil))
+		mock.ExpectQuery("SELECT id, name, batch, applied_at FROM `migrations`").WillReturnRows(rows)
 
 		reverted, err := m.Revert(db)
 
@@ -491,8 +568,8 @@ func TestRevert(t *testing.T) {
 
 		rows := sqlmock.NewRows([]string{"id", "name", "batch", "applied_at"}).AddRow(1, "test", 1, time.Now())
 
-		mock.ExpectQuery("SELECT").WillReturnRows()
-		mock.ExpectQuery("SELECT id, name, batch, applied_at FROM migrations").WillReturnRows(rows)
+		mock.ExpectQuery("SELECT").WillReturnRows(sqlmock.NewRows(nil))
+		mock.ExpectQuery("SELECT id, name, batch, applied_at FROM `migrations`").WillReturnRows(rows)
 
 		reverted, err := m.Revert(db)
 
@@ -513,8 +590,8 @@ func TestRevert(t *testing.T) {
 
 		rows := sqlmock.NewRows([]string{"id", "name", "batch", "applied_at"}).AddRow(1, "test", 1, time.Now())
 
-		mock.ExpectQuery("SELECT").WillReturnRows()
-		mock.ExpectQuery("SELECT id, name, batch, applied_at FROM migrations").WillReturnRows(rows)
+		mock.ExpectQuery("SELECT").WillReturnRows(sqlmock.NewRows(nil))
+		mock.ExpectQuery("SELECT id, name, batch, applied_at FROM `migrations`").WillReturnRows(rows)
 		mock.ExpectExec("DROP").WillReturnResult(sqlmock.NewResult(1, 1))
 		mock.ExpectExec("DELETE").WillReturnError(errTestDBExecFailed)
 
@@ -545,12 +622,12 @@ func TestRevert(t *testing.T) {
 			AddRow(1, "test", 4, time.Now()).
 			AddRow(2, "new", 3, time.Now())
 
-		mock.ExpectQuery("SELECT").WillReturnRows()
-		mock.ExpectQuery("SELECT id, name, batch, applied_at FROM migrations").WillReturnRows(rows)
+		mock.ExpectQuery("SELECT").WillReturnRows(sqlmock.NewRows(nil))
+		mock.ExpectQuery("SELECT id, name, batch, applied_at FROM `migrations`").WillReturnRows(rows)
 		mock.ExpectExec("DROP").WillReturnResult(sqlmock.NewResult(1, 1))
-		mock.ExpectExec("DELETE FROM migrations WHERE id = ?").WithArgs(2).WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectExec("DELETE FROM `migrations` WHERE id = ?").WithArgs(2).WillReturnResult(sqlmock.NewResult(1, 1))
 		mock.ExpectExec("DROP").WillReturnResult(sqlmock.NewResult(1, 1))
-		mock.ExpectExec("DELETE FROM migrations WHERE id = ?").WithArgs(1).WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectExec("DELETE FROM `migrations` WHERE id = ?").WithArgs(1).WillReturnResult(sqlmock.NewResult(1, 1))
 
 		reverted, err := m.Revert(db)
 
@@ -612,7 +689,7 @@ func TestCreateMigrationTable(t *testing.T) {
 		db, mock, resetDB := testDBConnection(t)
 		defer resetDB()
 
-		mock.ExpectQuery(`SELECT \* FROM migrations`).WillReturnRows().WillReturnError(nil)
+		mock.ExpectQuery("SELECT \\* FROM `migrations`").WillReturnRows(sqlmock.NewRows(nil)).WillReturnError(nil)
 
 		err := m.createMigrationTable(db)
 
@@ -624,8 +701,8 @@ func TestCreateMigrationTable(t *testing.T) {
 		db, mock, resetDB := testDBConnection(t)
 		defer resetDB()
 
-		mock.ExpectQuery(`SELECT \* FROM migrations`).WillReturnError(errTestDBQueryFailed)
-		sql := `CREATE TABLE migrations \(id int\(10\) unsigned NOT NULL AUTO_INCREMENT PRIMARY KEY, name varchar\(255\) COLLATE utf8mb4_unicode_ci NOT NULL, batch int\(11\) NOT NULL, applied_at timestamp\(6\) NULL DEFAULT CURRENT_TIMESTAMP\(6\)\) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci`
+		mock.ExpectQuery("SELECT \\* FROM `migrations`").WillReturnError(errTestDBQueryFailed)
+		sql := "CREATE TABLE `migrations` \\(id int\\(10\\) unsigned NOT NULL AUTO_INCREMENT PRIMARY KEY, name varchar\\(255\\) COLLATE utf8mb4_unicode_ci NOT NULL, batch int\\(11\\) NOT NULL, applied_at timestamp\\(6\\) NULL DEFAULT CURRENT_TIMESTAMP\\(6\\)\\) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci"
 		mock.ExpectExec(sql).WillReturnResult(sqlmock.NewResult(1, 1))
 
 		err := m.createMigrationTable(db)
@@ -638,8 +715,8 @@ func TestCreateMigrationTable(t *testing.T) {
 		db, mock, resetDB := testDBConnection(t)
 		defer resetDB()
 
-		mock.ExpectQuery(`SELECT \* FROM migrations`).WillReturnError(errTestDBQueryFailed)
-		sql := `CREATE TABLE migrations \(` +
+		mock.ExpectQuery("SELECT \\* FROM `migrations`").WillReturnError(errTestDBQueryFailed)
+		sql := "CREATE TABLE `migrations` \\(" +
 			`id int\(10\) unsigned NOT NULL AUTO_INCREMENT PRIMARY KEY, ` +
 			`name varchar\(255\) COLLATE utf8mb4_unicode_ci NOT NULL, ` +
 			`batch int\(11\) NOT NULL, applied_at timestamp\(6\) NULL DEFAULT CURRENT_TIMESTAMP\(6\)\) ` +
@@ -659,7 +736,7 @@ func TestHasTable(t *testing.T) {
 		db, mock, resetDB := testDBConnection(t)
 		defer resetDB()
 
-		mock.ExpectQuery(`SELECT \* FROM migrations`).WillReturnRows().WillReturnError(nil)
+		mock.ExpectQuery("SELECT \\* FROM `migrations`").WillReturnRows(sqlmock.NewRows(nil)).WillReturnError(nil)
 		got := m.hasTable(db)
 
 		assert.Equal(t, true, got)
@@ -670,7 +747,7 @@ func TestHasTable(t *testing.T) {
 		db, mock, resetDB := testDBConnection(t)
 		defer resetDB()
 
-		mock.ExpectQuery(`SELECT \* FROM migrations`).WillReturnError(errTestDBQueryFailed)
+		mock.ExpectQuery("SELECT \\* FROM `migrations`").WillReturnError(errTestDBQueryFailed)
 		got := m.hasTable(db)
 
 		assert.Equal(t, false, got)
@@ -733,7 +810,7 @@ func TestPoolExecuted(t *testing.T) {
 		db, mock, resetDB := testDBConnection(t)
 		defer resetDB()
 
-		mock.ExpectQuery("SELECT id, name, batch, applied_at FROM migrations").WillReturnError(errTestDBQueryFailed)
+		mock.ExpectQuery("SELECT id, name, batch, applied_at FROM `migrations`").WillReturnError(errTestDBQueryFailed)
 
 		err := m.fetchExecuted(db)
 
@@ -751,7 +828,7 @@ func TestPoolExecuted(t *testing.T) {
 			AddRow(1, "first", 1, time.Now()).
 			AddRow(2, "second", 1, "test")
 
-		mock.ExpectQuery("SELECT id, name, batch, applied_at FROM migrations").WillReturnRows(rows)
+		mock.ExpectQuery("SELECT id, name, batch, applied_at FROM `migrations`").WillReturnRows(rows)
 
 		got := m.fetchExecuted(db)
 
@@ -769,7 +846,7 @@ func TestPoolExecuted(t *testing.T) {
 			AddRow(1, "first", 1, time.Now()).
 			AddRow(2, "second", 1, time.Now())
 
-		mock.ExpectQuery("SELECT id, name, batch, applied_at FROM migrations").WillReturnRows(rows)
+		mock.ExpectQuery("SELECT id, name, batch, applied_at FROM `migrations`").WillReturnRows(rows)
 
 		err := m.fetchExecuted(db)
 
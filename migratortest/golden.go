@@ -0,0 +1,146 @@
+package migratortest
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/larapulse/migrator"
+)
+
+// update flag is namespaced, so it doesn't clash with `-update` flag of golden files of the tested package
+var update = flag.Bool("migratortest.update", false, "update golden files of migrations")
+
+// Golden compares SQL of Up() and Down() schemas of every migration from the pool
+// with golden files `testdata/{name}.up.sql` and `testdata/{name}.down.sql`.
+// Run tests with `-migratortest.update` flag to create or refresh golden files.
+// Down() schema derived from Up() is compared for migrations without Down(),
+// golden file is skipped, when it can't be derived.
+//
+// Example:
+//		func TestMigrationsSQL(t *testing.T) {
+//			migratortest.Golden(t, migrations)
+//		}
+func Golden(t testing.TB, pool []migrator.Migration) {
+	t.Helper()
+
+	if err := checkGolden("testdata", pool, *update); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func checkGolden(dir string, pool []migrator.Migration, update bool) error {
+	if update {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return err
+		}
+	}
+
+	var mismatches []string
+
+	for _, item := range pool {
+		var schemas []goldenSchema
+
+		if item.Up != nil {
+			schemas = append(schemas, goldenSchema{".up.sql", item.Up()})
+		}
+
+		if down, err := item.DownSchema(); err == nil {
+			schemas = append(schemas, goldenSchema{".down.sql", down})
+		}
+
+		for _, s := range schemas {
+			path := filepath.Join(dir, item.Name+s.suffix)
+			actual := render(s.schema)
+
+			if update {
+				if err := ioutil.WriteFile(path, []byte(actual), 0644); err != nil {
+					return err
+				}
+				continue
+			}
+
+			expected, err := ioutil.ReadFile(path)
+			if err != nil {
+				return fmt.Errorf("Golden file %s can't be read, run tests with -migratortest.update flag to create it: %v", path, err)
+			}
+
+			if diff := lineDiff(string(expected), actual); diff != "" {
+				mismatches = append(mismatches, fmt.Sprintf("%s:\n%s", path, diff))
+			}
+		}
+	}
+
+	if len(mismatches) > 0 {
+		return fmt.Errorf("SQL differs from golden files, run tests with -migratortest.update flag to refresh them\n%s", strings.Join(mismatches, "\n"))
+	}
+
+	return nil
+}
+
+type goldenSchema struct {
+	suffix string
+	schema migrator.Schema
+}
+
+// render joins statements of the schema, so every statement is terminated with semicolon on its own line
+func render(s migrator.Schema) string {
+	var sql string
+
+	for _, statement := range s.Statements() {
+		sql += statement + ";\n"
+	}
+
+	return sql
+}
+
+// lineDiff returns unified lines of expected and actual text, removed lines are prefixed with "-"
+// and added ones with "+", empty string is returned for equal texts.
+func lineDiff(expected string, actual string) string {
+	if expected == actual {
+		return ""
+	}
+
+	a := strings.Split(strings.TrimSuffix(expected, "\n"), "\n")
+	b := strings.Split(strings.TrimSuffix(actual, "\n"), "\n")
+
+	// longest common subsequence of lines
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else if lcs[i+1][j] >= lcs[i][j+1] {
+				lcs[i][j] = lcs[i+1][j]
+			} else {
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+
+	var diff []string
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		switch {
+		case i < len(a) && j < len(b) && a[i] == b[j]:
+			diff = append(diff, "  "+a[i])
+			i++
+			j++
+		case i < len(a) && (j == len(b) || lcs[i+1][j] >= lcs[i][j+1]):
+			diff = append(diff, "- "+a[i])
+			i++
+		default:
+			diff = append(diff, "+ "+b[j])
+			j++
+		}
+	}
+
+	return strings.Join(diff, "\n")
+}
@@ -0,0 +1,109 @@
+package migratortest
+
+import (
+	"flag"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/larapulse/migrator"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGoldenUpdateFlag(t *testing.T) {
+	assert.Nil(t, flag.Lookup("update"))
+	assert.NotNil(t, flag.Lookup("migratortest.update"))
+}
+
+func TestCheckGolden(t *testing.T) {
+	pool := []migrator.Migration{
+		{
+			Name: "create_posts",
+			Up: func() migrator.Schema {
+				var s migrator.Schema
+				s.CreateTable(migrator.Table{Name: "posts"})
+				s.AlterTable("posts", migrator.TableCommands{migrator.AddColumnCommand{Name: "rating", Column: migrator.Integer{}}})
+				return s
+			},
+			Down: func() migrator.Schema {
+				var s migrator.Schema
+				s.DropTableIfExists("posts")
+				return s
+			},
+		},
+		testMigration,
+		{
+			Name: "drop_logs",
+			Up: func() migrator.Schema {
+				var s migrator.Schema
+				s.DropTable("logs", false, "")
+				return s
+			},
+		},
+	}
+
+	dir, err := ioutil.TempDir("", "migratortest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	testdata := filepath.Join(dir, "testdata")
+
+	t.Run("it fails without golden files", func(t *testing.T) {
+		err := checkGolden(testdata, pool, false)
+
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "run tests with -migratortest.update flag to create it")
+	})
+
+	t.Run("it creates golden files on update", func(t *testing.T) {
+		assert.Nil(t, checkGolden(testdata, pool, true))
+
+		up, _ := ioutil.ReadFile(filepath.Join(testdata, "create_posts.up.sql"))
+		down, _ := ioutil.ReadFile(filepath.Join(testdata, "create_posts.down.sql"))
+
+		assert.Equal(t, "CREATE TABLE `posts` (`id` bigint(20) unsigned NOT NULL AUTO_INCREMENT) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;\n"+
+			"ALTER TABLE `posts` ADD COLUMN `rating` int NOT NULL;\n", string(up))
+		assert.Equal(t, "DROP TABLE IF EXISTS `posts`;\n", string(down))
+		assert.FileExists(t, filepath.Join(testdata, "add_rating.up.sql"))
+		derived, _ := ioutil.ReadFile(filepath.Join(testdata, "add_rating.down.sql"))
+		assert.Equal(t, "ALTER TABLE `posts` DROP COLUMN `rating`;\n", string(derived), "Down() is derived from Up()")
+		assert.FileExists(t, filepath.Join(testdata, "drop_logs.up.sql"))
+		_, err := os.Stat(filepath.Join(testdata, "drop_logs.down.sql"))
+		assert.True(t, os.IsNotExist(err), "Down() can't be derived")
+	})
+
+	t.Run("it passes with matching golden files", func(t *testing.T) {
+		assert.Nil(t, checkGolden(testdata, pool, false))
+	})
+
+	t.Run("it fails with diff on mismatch", func(t *testing.T) {
+		changed := []migrator.Migration{{
+			Name: "create_posts",
+			Up: func() migrator.Schema {
+				var s migrator.Schema
+				s.CreateTable(migrator.Table{Name: "posts"})
+				s.AlterTable("posts", migrator.TableCommands{migrator.AddColumnCommand{Name: "rating", Column: migrator.Integer{Nullable: true}}})
+				return s
+			},
+		}}
+
+		err := checkGolden(testdata, changed, false)
+
+		assert.EqualError(t, err, "SQL differs from golden files, run tests with -migratortest.update flag to refresh them\n"+
+			filepath.Join(testdata, "create_posts.up.sql")+":\n"+
+			"  CREATE TABLE `posts` (`id` bigint(20) unsigned NOT NULL AUTO_INCREMENT) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;\n"+
+			"- ALTER TABLE `posts` ADD COLUMN `rating` int NOT NULL;\n"+
+			"+ ALTER TABLE `posts` ADD COLUMN `rating` int NULL;\n"+
+			filepath.Join(testdata, "create_posts.down.sql")+":\n"+
+			"+ ALTER TABLE `posts` DROP COLUMN `rating`;\n"+
+			"  DROP TABLE IF EXISTS `posts`;")
+	})
+}
+
+func TestLineDiff(t *testing.T) {
+	assert.Equal(t, "", lineDiff("a\nb\n", "a\nb\n"))
+	assert.Equal(t, "  a\n- b\n+ c\n  d", lineDiff("a\nb\nd\n", "a\nc\nd\n"))
+	assert.Equal(t, "  a\n+ b", lineDiff("a\n", "a\nb\n"))
+}
@@ -0,0 +1,308 @@
+package migratortest
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	insertEntry = regexp.MustCompile("^INSERT INTO `?(\\w+)`? \\(`name`, `batch`\\) VALUES \\(\"(.*)\", (\\d+)\\)$")
+	deleteEntry = regexp.MustCompile("^DELETE FROM `?(\\w+)`? WHERE id = \\?$")
+)
+
+// Recorder is an in-memory fake database, that records every statement sent by migrator.
+// State of migration table is simulated, so Migrate, Rollback and Revert work as on the real database.
+// Any other query returns no rows.
+//
+// Example:
+//		r := migratortest.NewRecorder()
+//		m := migrator.Migrator{Pool: migrations}
+//		m.Migrate(r.DB())
+//
+//		r.Statements() // []string{"SELECT * FROM `migrations`", "CREATE TABLE `migrations` ...", ...}
+type Recorder struct {
+	// TableName of migration table, default: migrations
+	TableName string
+	// Version of the server reported by `SELECT VERSION()`, default: 8.0.36
+	Version string
+
+	mu         sync.Mutex
+	statements []string
+	created    bool
+	entries    []entry
+	lastID     int64
+	snapshot   []entry
+}
+
+type entry struct {
+	id    int64
+	name  string
+	batch int64
+}
+
+// NewRecorder creates a recorder with default migration table.
+func NewRecorder() *Recorder {
+	return &Recorder{TableName: "migrations"}
+}
+
+// DB returns connection pool to be passed to migrator.
+func (r *Recorder) DB() *sql.DB {
+	return sql.OpenDB(r)
+}
+
+// Seed stores migrations in migration table as executed within the batch.
+func (r *Recorder) Seed(batch uint64, names ...string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.created = true
+	for _, name := range names {
+		r.lastID++
+		r.entries = append(r.entries, entry{id: r.lastID, name: name, batch: int64(batch)})
+	}
+}
+
+// Statements returns all recorded statements in order they were sent.
+// Transactions are recorded as BEGIN, COMMIT and ROLLBACK statements.
+func (r *Recorder) Statements() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return append([]string(nil), r.statements...)
+}
+
+// Applied returns names of migrations stored in migration table.
+func (r *Recorder) Applied() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var names []string
+	for _, e := range r.entries {
+		names = append(names, e.name)
+	}
+
+	return names
+}
+
+// Reset forgets recorded statements, state of migration table is kept.
+func (r *Recorder) Reset() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.statements = nil
+}
+
+// Connect implements driver.Connector.
+func (r *Recorder) Connect(context.Context) (driver.Conn, error) {
+	return recorderConn{r}, nil
+}
+
+// Driver implements driver.Connector.
+func (r *Recorder) Driver() driver.Driver {
+	return recorderDriver{r}
+}
+
+func (r *Recorder) table() string {
+	if r.TableName == "" {
+		return "migrations"
+	}
+
+	return r.TableName
+}
+
+func (r *Recorder) record(query string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.statements = append(r.statements, query)
+}
+
+func (r *Recorder) exec(query string, args []driver.Value) (driver.Result, error) {
+	r.record(query)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if strings.HasPrefix(query, "CREATE TABLE `"+r.table()+"` ") {
+		r.created = true
+		return driver.RowsAffected(0), nil
+	}
+
+	if m := insertEntry.FindStringSubmatch(query); m != nil && m[1] == r.table() {
+		if !r.created {
+			return nil, fmt.Errorf("Table '%s' doesn't exist", r.table())
+		}
+
+		batch, _ := strconv.ParseInt(m[3], 10, 64)
+		r.lastID++
+		r.entries = append(r.entries, entry{id: r.lastID, name: m[2], batch: batch})
+
+		return driver.RowsAffected(1), nil
+	}
+
+	if m := deleteEntry.FindStringSubmatch(query); m != nil && m[1] == r.table() && len(args) == 1 {
+		var entries []entry
+		for _, e := range r.entries {
+			if fmt.Sprint(e.id) != fmt.Sprint(args[0]) {
+				entries = append(entries, e)
+			}
+		}
+
+		affected := int64(len(r.entries) - len(entries))
+		r.entries = entries
+
+		return driver.RowsAffected(affected), nil
+	}
+
+	return driver.RowsAffected(0), nil
+}
+
+func (r *Recorder) query(query string) (driver.Rows, error) {
+	r.record(query)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	switch {
+	case query == "SELECT * FROM `"+r.table()+"`":
+		if !r.created {
+			return nil, fmt.Errorf("Table '%s' doesn't exist", r.table())
+		}
+
+		return &recorderRows{columns: []string{"id"}}, nil
+	case strings.HasPrefix(query, "SELECT id, name, batch, applied_at FROM `"+r.table()+"` "):
+		rows := &recorderRows{columns: []string{"id", "name", "batch", "applied_at"}}
+		for _, e := range r.entries {
+			rows.values = append(rows.values, []driver.Value{e.id, e.name, e.batch, time.Time{}})
+		}
+
+		return rows, nil
+	case query == "SELECT VERSION()":
+		version := r.Version
+		if version == "" {
+			version = "8.0.36"
+		}
+
+		return &recorderRows{columns: []string{"VERSION()"}, values: [][]driver.Value{{version}}}, nil
+	}
+
+	return &recorderRows{}, nil
+}
+
+func (r *Recorder) begin() {
+	r.record("BEGIN")
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.snapshot = append([]entry(nil), r.entries...)
+}
+
+func (r *Recorder) end(commit bool) {
+	if commit {
+		r.record("COMMIT")
+		return
+	}
+
+	r.record("ROLLBACK")
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.entries = r.snapshot
+}
+
+type recorderDriver struct {
+	r *Recorder
+}
+
+func (d recorderDriver) Open(string) (driver.Conn, error) {
+	return recorderConn(d), nil
+}
+
+type recorderConn struct {
+	r *Recorder
+}
+
+func (c recorderConn) Prepare(query string) (driver.Stmt, error) {
+	return recorderStmt{c.r, query}, nil
+}
+
+func (c recorderConn) Close() error {
+	return nil
+}
+
+func (c recorderConn) Begin() (driver.Tx, error) {
+	c.r.begin()
+
+	return recorderTx(c), nil
+}
+
+type recorderTx struct {
+	r *Recorder
+}
+
+func (tx recorderTx) Commit() error {
+	tx.r.end(true)
+
+	return nil
+}
+
+func (tx recorderTx) Rollback() error {
+	tx.r.end(false)
+
+	return nil
+}
+
+type recorderStmt struct {
+	r     *Recorder
+	query string
+}
+
+func (s recorderStmt) Close() error {
+	return nil
+}
+
+func (s recorderStmt) NumInput() int {
+	return -1
+}
+
+func (s recorderStmt) Exec(args []driver.Value) (driver.Result, error) {
+	return s.r.exec(s.query, args)
+}
+
+func (s recorderStmt) Query(args []driver.Value) (driver.Rows, error) {
+	return s.r.query(s.query)
+}
+
+type recorderRows struct {
+	columns []string
+	values  [][]driver.Value
+}
+
+func (r *recorderRows) Columns() []string {
+	return r.columns
+}
+
+func (r *recorderRows) Close() error {
+	return nil
+}
+
+func (r *recorderRows) Next(dest []driver.Value) error {
+	if len(r.values) == 0 {
+		return io.EOF
+	}
+
+	copy(dest, r.values[0])
+	r.values = r.values[1:]
+
+	return nil
+}
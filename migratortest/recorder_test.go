@@ -0,0 +1,127 @@
+package migratortest
+
+import (
+	"testing"
+
+	"github.com/larapulse/migrator"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRecorder(t *testing.T) {
+	posts := migrator.Migration{
+		Name: "create_posts",
+		Up: func() migrator.Schema {
+			var s migrator.Schema
+			s.CreateTable(migrator.Table{Name: "posts"})
+			return s
+		},
+	}
+	rating := migrator.Migration{
+		Name:        "add_rating",
+		Transaction: true,
+		Up: func() migrator.Schema {
+			var s migrator.Schema
+			s.AlterTable("posts", migrator.TableCommands{migrator.AddColumnCommand{Name: "rating", Column: migrator.Integer{Nullable: true}}})
+			return s
+		},
+	}
+
+	t.Run("it records migrated statements and stores executed migrations", func(t *testing.T) {
+		r := NewRecorder()
+		m := migrator.Migrator{Pool: []migrator.Migration{posts, rating}}
+
+		migrated, err := m.Migrate(r.DB())
+
+		assert.Nil(t, err)
+		assert.Equal(t, []string{"create_posts", "add_rating"}, migrated)
+		assert.Equal(t, []string{"create_posts", "add_rating"}, r.Applied())
+		assert.Equal(t, []string{
+			"SELECT * FROM `migrations`",
+			"CREATE TABLE `migrations` (id int(10) unsigned NOT NULL AUTO_INCREMENT PRIMARY KEY, name varchar(255) COLLATE utf8mb4_unicode_ci NOT NULL, batch int(11) NOT NULL, applied_at timestamp(6) NULL DEFAULT CURRENT_TIMESTAMP(6)) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci",
+			"SELECT id, name, batch, applied_at FROM `migrations` ORDER BY applied_at ASC",
+			"CREATE TABLE `posts` (`id` bigint(20) unsigned NOT NULL AUTO_INCREMENT) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci",
+			"INSERT INTO `migrations` (`name`, `batch`) VALUES (\"create_posts\", 1)",
+			"BEGIN",
+			"ALTER TABLE `posts` ADD COLUMN `rating` int NULL",
+			"INSERT INTO `migrations` (`name`, `batch`) VALUES (\"add_rating\", 1)",
+			"COMMIT",
+		}, r.Statements())
+	})
+
+	t.Run("it rolls back the last batch", func(t *testing.T) {
+		r := NewRecorder()
+		r.Seed(1, "create_posts")
+		r.Seed(2, "add_rating")
+		m := migrator.Migrator{Pool: []migrator.Migration{posts, rating}}
+
+		reverted, err := m.Rollback(r.DB())
+
+		assert.Nil(t, err)
+		assert.Equal(t, []string{"add_rating"}, reverted)
+		assert.Equal(t, []string{"create_posts"}, r.Applied())
+		assert.Equal(t, []string{
+			"SELECT * FROM `migrations`",
+			"SELECT id, name, batch, applied_at FROM `migrations` ORDER BY applied_at ASC",
+			"BEGIN",
+			"ALTER TABLE `posts` DROP COLUMN `rating`",
+			"DELETE FROM `migrations` WHERE id = ?",
+			"COMMIT",
+		}, r.Statements())
+	})
+
+	t.Run("it reverts all migrations with custom migration table", func(t *testing.T) {
+		r := &Recorder{TableName: "schema_migrations"}
+		r.Seed(1, "create_posts", "add_rating")
+		m := migrator.Migrator{TableName: "schema_migrations", Pool: []migrator.Migration{posts, rating}}
+
+		reverted, err := m.Revert(r.DB())
+
+		assert.Nil(t, err)
+		assert.Equal(t, []string{"add_rating", "create_posts"}, reverted)
+		assert.Len(t, r.Applied(), 0)
+		assert.Contains(t, r.Statements(), "DROP TABLE IF EXISTS `posts`")
+	})
+
+	t.Run("it doesn't store migration from rolled back transaction", func(t *testing.T) {
+		r := NewRecorder()
+		r.Seed(1, "create_posts")
+		db := r.DB()
+
+		tx, _ := db.Begin()
+		tx.Exec("INSERT INTO `migrations` (`name`, `batch`) VALUES (\"add_rating\", 2)")
+		tx.Rollback()
+
+		assert.Equal(t, []string{"create_posts"}, r.Applied())
+		assert.Equal(t, []string{"BEGIN", "INSERT INTO `migrations` (`name`, `batch`) VALUES (\"add_rating\", 2)", "ROLLBACK"}, r.Statements())
+	})
+
+	t.Run("it reports server version", func(t *testing.T) {
+		rename := migrator.Migration{
+			Name: "rename_title",
+			Up: func() migrator.Schema {
+				var s migrator.Schema
+				s.AlterTable("posts", migrator.TableCommands{migrator.RenameColumnCommand{Old: "title", New: "name"}})
+				return s
+			},
+		}
+		r := NewRecorder()
+		r.Seed(1, "create_posts")
+
+		m := migrator.Migrator{Pool: []migrator.Migration{posts, rename}}
+		migrated, err := m.Migrate(r.DB())
+
+		assert.Nil(t, err)
+		assert.Equal(t, []string{"rename_title"}, migrated)
+		assert.Contains(t, r.Statements(), "SELECT VERSION()")
+		assert.Contains(t, r.Statements(), "ALTER TABLE `posts` RENAME COLUMN `title` TO `name`")
+	})
+
+	t.Run("it resets recorded statements", func(t *testing.T) {
+		r := NewRecorder()
+		r.DB().Exec("SELECT 1")
+
+		r.Reset()
+
+		assert.Len(t, r.Statements(), 0)
+	})
+}
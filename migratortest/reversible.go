@@ -0,0 +1,201 @@
+// Package migratortest provides utilities to test migrations with a real or a fake database.
+package migratortest
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/larapulse/migrator"
+)
+
+// TableName is the name of migration table used by the helpers of this package
+const TableName = "migratortest_migrations"
+
+// Reversible fails the test, if any migration from the pool can't be reverted cleanly.
+// See CheckReversible for details.
+//
+// Example:
+//		func TestMigrations(t *testing.T) {
+//			db, _ := sql.Open("mysql", "root:secret@tcp(127.0.0.1:3306)/scratch")
+//			migratortest.Reversible(t, db, migrations)
+//		}
+func Reversible(t testing.TB, db *sql.DB, pool []migrator.Migration) {
+	t.Helper()
+
+	if err := CheckReversible(db, pool); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// CheckReversible applies migrations from the pool one by one on the scratch database.
+// Every migration is reverted with its Down() schema and applied again,
+// database schema is compared with the snapshot from information_schema after every step.
+//
+// Database is expected to be empty, migrations are left applied afterwards.
+func CheckReversible(db *sql.DB, pool []migrator.Migration) error {
+	for i, item := range pool {
+		m := migrator.Migrator{TableName: TableName, Pool: pool[:i+1]}
+
+		before, err := Snapshot(db)
+		if err != nil {
+			return err
+		}
+
+		if err := migrate(db, m, item.Name); err != nil {
+			return err
+		}
+
+		after, err := Snapshot(db)
+		if err != nil {
+			return err
+		}
+
+		reverted, err := m.Rollback(db)
+		if err != nil {
+			return fmt.Errorf(`Migration "%s" failed to be reverted: %v`, item.Name, err)
+		}
+		if len(reverted) != 1 || reverted[0] != item.Name {
+			return fmt.Errorf(`Migration "%s" was expected to be reverted, got %v`, item.Name, reverted)
+		}
+
+		current, err := Snapshot(db)
+		if err != nil {
+			return err
+		}
+
+		if diff := before.Diff(current); diff != "" {
+			return fmt.Errorf("Migration \"%s\" is not reversible, schema differs after Down():\n%s", item.Name, diff)
+		}
+
+		if err := migrate(db, m, item.Name); err != nil {
+			return err
+		}
+
+		current, err = Snapshot(db)
+		if err != nil {
+			return err
+		}
+
+		if diff := after.Diff(current); diff != "" {
+			return fmt.Errorf("Migration \"%s\" is not repeatable, schema differs after Up() is applied again:\n%s", item.Name, diff)
+		}
+	}
+
+	return nil
+}
+
+// migrate applies the last migration of the pool and makes sure nothing else was applied
+func migrate(db *sql.DB, m migrator.Migrator, name string) error {
+	migrated, err := m.Migrate(db)
+	if err != nil {
+		return fmt.Errorf(`Migration "%s" failed to be applied: %v`, name, err)
+	}
+
+	if len(migrated) != 1 || migrated[0] != name {
+		return fmt.Errorf(`Migration "%s" was expected to be applied, got %v, database is expected to be empty`, name, migrated)
+	}
+
+	return nil
+}
+
+// Schema is a snapshot of the database schema, one line per column, index column and foreign key column.
+type Schema []string
+
+var snapshotQueries = []string{
+	"SELECT 'column', TABLE_NAME, COLUMN_NAME, COLUMN_TYPE, IS_NULLABLE, COLUMN_DEFAULT, EXTRA, COLLATION_NAME " +
+		"FROM information_schema.COLUMNS WHERE TABLE_SCHEMA = DATABASE() AND TABLE_NAME <> ? " +
+		"ORDER BY TABLE_NAME, ORDINAL_POSITION",
+	"SELECT 'index', TABLE_NAME, INDEX_NAME, NON_UNIQUE, SEQ_IN_INDEX, COLUMN_NAME " +
+		"FROM information_schema.STATISTICS WHERE TABLE_SCHEMA = DATABASE() AND TABLE_NAME <> ? " +
+		"ORDER BY TABLE_NAME, INDEX_NAME, SEQ_IN_INDEX",
+	"SELECT 'foreign', TABLE_NAME, CONSTRAINT_NAME, COLUMN_NAME, REFERENCED_TABLE_NAME, REFERENCED_COLUMN_NAME " +
+		"FROM information_schema.KEY_COLUMN_USAGE WHERE TABLE_SCHEMA = DATABASE() AND TABLE_NAME <> ? AND REFERENCED_TABLE_NAME IS NOT NULL " +
+		"ORDER BY TABLE_NAME, CONSTRAINT_NAME, ORDINAL_POSITION",
+}
+
+// Snapshot reads columns, indexes and foreign keys of the current database from information_schema,
+// migration table of this package is skipped.
+func Snapshot(db *sql.DB) (Schema, error) {
+	var s Schema
+
+	for _, query := range snapshotQueries {
+		lines, err := readLines(db, query)
+		if err != nil {
+			return nil, err
+		}
+
+		s = append(s, lines...)
+	}
+
+	return s, nil
+}
+
+func readLines(db *sql.DB, query string) ([]string, error) {
+	rows, err := db.Query(query, TableName)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return nil, err
+	}
+
+	var lines []string
+	for rows.Next() {
+		values := make([]sql.NullString, len(columns))
+		pointers := make([]interface{}, len(columns))
+		for i := range values {
+			pointers[i] = &values[i]
+		}
+
+		if err := rows.Scan(pointers...); err != nil {
+			return nil, err
+		}
+
+		fields := make([]string, len(values))
+		for i, v := range values {
+			fields[i] = "NULL"
+			if v.Valid {
+				fields[i] = v.String
+			}
+		}
+
+		lines = append(lines, strings.Join(fields, " "))
+	}
+
+	return lines, rows.Err()
+}
+
+// Diff returns lines missing in the other schema prefixed with "-" and extra lines prefixed with "+",
+// empty string is returned for equal schemas.
+func (s Schema) Diff(other Schema) string {
+	var diff []string
+
+	for _, line := range s {
+		if !other.has(line) {
+			diff = append(diff, "- "+line)
+		}
+	}
+
+	for _, line := range other {
+		if !s.has(line) {
+			diff = append(diff, "+ "+line)
+		}
+	}
+
+	return strings.Join(diff, "\n")
+}
+
+func (s Schema) has(line string) bool {
+	for _, item := range s {
+		if item == line {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,110 @@
+package migratortest
+
+import (
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/larapulse/migrator"
+	"github.com/stretchr/testify/assert"
+)
+
+var errTestDBQueryFailed = errors.New("DB query failed")
+
+func testDBConnection(t *testing.T) (db *sql.DB, mock sqlmock.Sqlmock, resetDB func()) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+
+	return db, mock, func() {
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("there were unfulfilled expectations: %s", err)
+		}
+		db.Close()
+	}
+}
+
+var testMigration = migrator.Migration{
+	Name: "add_rating",
+	Up: func() migrator.Schema {
+		var s migrator.Schema
+		s.AlterTable("posts", migrator.TableCommands{migrator.AddColumnCommand{Name: "rating", Column: migrator.Integer{}}})
+		return s
+	},
+}
+
+func expectSnapshot(mock sqlmock.Sqlmock, columns ...string) {
+	rows := sqlmock.NewRows([]string{"kind", "table", "column", "type", "nullable", "default", "extra", "collation"})
+	for _, column := range columns {
+		rows.AddRow("column", "posts", column, "int", "NO", nil, "", nil)
+	}
+
+	mock.ExpectQuery("information_schema.COLUMNS").WithArgs(TableName).WillReturnRows(rows)
+	mock.ExpectQuery("information_schema.STATISTICS").WithArgs(TableName).WillReturnRows(sqlmock.NewRows([]string{"kind"}))
+	mock.ExpectQuery("information_schema.KEY_COLUMN_USAGE").WithArgs(TableName).WillReturnRows(sqlmock.NewRows([]string{"kind"}))
+}
+
+func expectMigrate(mock sqlmock.Sqlmock) {
+	mock.ExpectQuery("SELECT \\* FROM `" + TableName + "`").WillReturnRows(sqlmock.NewRows(nil))
+	mock.ExpectQuery("SELECT id, name, batch, applied_at FROM `" + TableName + "`").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name", "batch", "applied_at"}))
+	mock.ExpectExec("ALTER TABLE `posts` ADD COLUMN `rating` int NOT NULL").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("INSERT INTO").WillReturnResult(sqlmock.NewResult(1, 1))
+}
+
+func expectRollback(mock sqlmock.Sqlmock) {
+	mock.ExpectQuery("SELECT \\* FROM `" + TableName + "`").WillReturnRows(sqlmock.NewRows(nil))
+	mock.ExpectQuery("SELECT id, name, batch, applied_at FROM `" + TableName + "`").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name", "batch", "applied_at"}).AddRow(1, "add_rating", 1, time.Now()))
+	mock.ExpectExec("ALTER TABLE `posts` DROP COLUMN `rating`").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("DELETE FROM `" + TableName + "`").WithArgs(1).WillReturnResult(sqlmock.NewResult(0, 1))
+}
+
+func TestCheckReversible(t *testing.T) {
+	t.Run("it applies, reverts and applies migration again", func(t *testing.T) {
+		db, mock, resetDB := testDBConnection(t)
+		defer resetDB()
+
+		expectSnapshot(mock, "id")
+		expectMigrate(mock)
+		expectSnapshot(mock, "id", "rating")
+		expectRollback(mock)
+		expectSnapshot(mock, "id")
+		expectMigrate(mock)
+		expectSnapshot(mock, "id", "rating")
+
+		assert.Nil(t, CheckReversible(db, []migrator.Migration{testMigration}))
+	})
+
+	t.Run("it fails when schema differs after Down()", func(t *testing.T) {
+		db, mock, resetDB := testDBConnection(t)
+		defer resetDB()
+
+		expectSnapshot(mock, "id")
+		expectMigrate(mock)
+		expectSnapshot(mock, "id", "rating")
+		expectRollback(mock)
+		expectSnapshot(mock, "id", "rating")
+
+		err := CheckReversible(db, []migrator.Migration{testMigration})
+
+		assert.EqualError(t, err, "Migration \"add_rating\" is not reversible, schema differs after Down():\n+ column posts rating int NO NULL  NULL")
+	})
+
+	t.Run("it fails when snapshot can't be read", func(t *testing.T) {
+		db, mock, resetDB := testDBConnection(t)
+		defer resetDB()
+
+		mock.ExpectQuery("information_schema.COLUMNS").WillReturnError(errTestDBQueryFailed)
+
+		assert.Equal(t, errTestDBQueryFailed, CheckReversible(db, []migrator.Migration{testMigration}))
+	})
+}
+
+func TestSchemaDiff(t *testing.T) {
+	assert.Equal(t, "", Schema{"a", "b"}.Diff(Schema{"b", "a"}))
+	assert.Equal(t, "- a\n+ c", Schema{"a", "b"}.Diff(Schema{"b", "c"}))
+}
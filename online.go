@@ -0,0 +1,322 @@
+package migrator
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// OnlineOptions enables altering tables without blocking them, similar to gh-ost or pt-online-schema-change.
+//
+// Every AlterTable command of the migration is applied to a shadow table `_{table}_new`,
+// which is filled with rows of the original table in chunks by primary key and kept in sync by triggers.
+// Finally tables are swapped with atomic RENAME TABLE.
+//
+// - Key			numeric primary key column to copy rows by, default: id
+// - ChunkSize		amount of rows to be copied at once, default: 1000
+// - Throttle		pause between copying chunks
+// - BeforeCutover	optional hook called before tables are swapped, migration is aborted if it returns an error
+// - KeepOldTable	keeps original table as `_{table}_old` after cutover
+//
+// Example:
+//		var migration = migrator.Migration{
+//			Name:   "19700101_0004_add_rating_to_comments",
+//			Up:     up,
+//			Down:   down,
+//			Online: &migrator.OnlineOptions{ChunkSize: 5000, Throttle: 100 * time.Millisecond},
+//		}
+type OnlineOptions struct {
+	Key           string
+	ChunkSize     uint64
+	Throttle      time.Duration
+	BeforeCutover func() error
+	KeepOldTable  bool
+}
+
+// wrap replaces AlterTable commands with their online variant.
+// Columns, renamed with `CHANGE` on servers without `RENAME COLUMN`, can't be altered online.
+func (o *OnlineOptions) wrap(commands []command) ([]command, error) {
+	var result []command
+
+	for _, c := range commands {
+		switch v := c.(type) {
+		case alterTableCommand:
+			c = onlineAlterTableCommand{v, *o}
+		case renameColumnFallback:
+			return nil, fmt.Errorf(`Table "%s" can't be altered online: RENAME COLUMN is not supported by the server`, v.alter.name)
+		}
+
+		result = append(result, c)
+	}
+
+	return result, nil
+}
+
+type onlineAlterTableCommand struct {
+	alter   alterTableCommand
+	options OnlineOptions
+}
+
+func (c onlineAlterTableCommand) toSQL() string {
+	return c.alter.toSQL()
+}
+
+func (c onlineAlterTableCommand) key() string {
+	if c.options.Key == "" {
+		return "id"
+	}
+
+	return c.options.Key
+}
+
+func (c onlineAlterTableCommand) chunkSize() uint64 {
+	if c.options.ChunkSize == 0 {
+		return 1000
+	}
+
+	return c.options.ChunkSize
+}
+
+func (c onlineAlterTableCommand) shadowTable() string {
+	return "_" + c.alter.name + "_new"
+}
+
+func (c onlineAlterTableCommand) oldTable() string {
+	return "_" + c.alter.name + "_old"
+}
+
+func (c onlineAlterTableCommand) triggers() []string {
+	return []string{"_" + c.alter.name + "_ins", "_" + c.alter.name + "_upd", "_" + c.alter.name + "_del"}
+}
+
+func (c onlineAlterTableCommand) exec(db executableSQL) error {
+	if c.alter.toSQL() == "" {
+		return ErrNoSQLCommandsToRun
+	}
+
+	if err := c.checkForeigns(db); err != nil {
+		return err
+	}
+
+	shadow := alterTableCommand{name: c.shadowTable(), pool: c.alter.pool}
+
+	if _, err := db.Exec(fmt.Sprintf("CREATE TABLE `%s` LIKE `%s`", c.shadowTable(), c.alter.name)); err != nil {
+		return err
+	}
+
+	err := c.copy(db, shadow)
+	if err == nil && c.options.BeforeCutover != nil {
+		err = c.options.BeforeCutover()
+	}
+	if err != nil {
+		c.cleanup(db)
+		return err
+	}
+
+	sql := fmt.Sprintf("RENAME TABLE `%s` TO `%s`, `%s` TO `%s`", c.alter.name, c.oldTable(), c.shadowTable(), c.alter.name)
+	if _, err := db.Exec(sql); err != nil {
+		c.cleanup(db)
+		return err
+	}
+
+	if err := c.dropTriggers(db); err != nil {
+		return err
+	}
+
+	if c.options.KeepOldTable {
+		return nil
+	}
+
+	_, err = db.Exec(fmt.Sprintf("DROP TABLE `%s`", c.oldTable()))
+
+	return err
+}
+
+// checkForeigns refuses tables with foreign keys or referenced by them,
+// as shadow table is created without foreign keys and referencing ones follow the original table on cutover
+func (c onlineAlterTableCommand) checkForeigns(db executableSQL) error {
+	var constraint, table string
+	err := db.QueryRow(
+		"SELECT CONSTRAINT_NAME, TABLE_NAME FROM information_schema.KEY_COLUMN_USAGE "+
+			"WHERE TABLE_SCHEMA = DATABASE() AND REFERENCED_TABLE_NAME IS NOT NULL AND (TABLE_NAME = ? OR REFERENCED_TABLE_NAME = ?) LIMIT 1",
+		c.alter.name,
+		c.alter.name,
+	).Scan(&constraint, &table)
+	if err == sql.ErrNoRows {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	return fmt.Errorf(`Table "%s" can't be altered online: foreign key "%s" of table "%s" would be lost on cutover`, c.alter.name, constraint, table)
+}
+
+// copy alters shadow table, creates triggers to sync changes and copies existing rows in chunks
+func (c onlineAlterTableCommand) copy(db executableSQL, shadow alterTableCommand) error {
+	if _, err := db.Exec(shadow.toSQL()); err != nil {
+		return err
+	}
+
+	source, target, err := c.columns(db)
+	if err != nil {
+		return err
+	}
+
+	if err := c.createTriggers(db, source, target); err != nil {
+		return err
+	}
+
+	var min, max *uint64
+	sql := fmt.Sprintf("SELECT MIN(`%s`), MAX(`%s`) FROM `%s`", c.key(), c.key(), c.alter.name)
+	if err := db.QueryRow(sql).Scan(&min, &max); err != nil {
+		return err
+	}
+
+	if min == nil || max == nil {
+		return nil
+	}
+
+	sql = fmt.Sprintf(
+		"INSERT IGNORE INTO `%s` (`%s`) SELECT `%s` FROM `%s` WHERE `%s` BETWEEN ? AND ?",
+		c.shadowTable(),
+		strings.Join(target, "`, `"),
+		strings.Join(source, "`, `"),
+		c.alter.name,
+		c.key(),
+	)
+
+	end := chunkEndSQL("`"+c.alter.name+"`", "`"+c.key()+"`", c.chunkSize())
+
+	for from := *min; ; {
+		to, err := chunkEnd(db, end, from, *max, c.chunkSize())
+		if err != nil {
+			return err
+		}
+
+		if _, err := db.Exec(sql, from, to); err != nil {
+			return err
+		}
+
+		if to >= *max {
+			return nil
+		}
+
+		if c.options.Throttle > 0 {
+			sleep(c.options.Throttle)
+		}
+
+		from = to + 1
+	}
+}
+
+// columns returns column names of the original table, that still exist in the shadow table, and their new names
+func (c onlineAlterTableCommand) columns(db executableSQL) (source []string, target []string, err error) {
+	original, err := tableColumns(db, c.alter.name)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	altered, err := tableColumns(db, c.shadowTable())
+	if err != nil {
+		return nil, nil, err
+	}
+
+	renamed := map[string]string{}
+	for _, tc := range c.alter.pool {
+		switch v := tc.(type) {
+		case RenameColumnCommand:
+			renamed[v.Old] = v.New
+		case ChangeColumnCommand:
+			renamed[v.From] = v.To
+		}
+	}
+
+	for _, name := range original {
+		newName := name
+		if to, ok := renamed[name]; ok {
+			newName = to
+		}
+
+		if list(altered).has(newName) {
+			source = append(source, name)
+			target = append(target, newName)
+		}
+	}
+
+	if len(source) == 0 {
+		return nil, nil, fmt.Errorf(`Table "%s" has no columns to be copied`, c.alter.name)
+	}
+
+	return source, target, nil
+}
+
+func (c onlineAlterTableCommand) createTriggers(db executableSQL, source []string, target []string) error {
+	var values []string
+	for _, name := range source {
+		values = append(values, "NEW.`"+name+"`")
+	}
+
+	replace := fmt.Sprintf(
+		"REPLACE INTO `%s` (`%s`) VALUES (%s)",
+		c.shadowTable(),
+		strings.Join(target, "`, `"),
+		strings.Join(values, ", "),
+	)
+	remove := fmt.Sprintf("DELETE FROM `%s` WHERE `%s` = OLD.`%s`", c.shadowTable(), c.key(), c.key())
+	names := c.triggers()
+
+	triggers := []string{
+		fmt.Sprintf("CREATE TRIGGER `%s` AFTER INSERT ON `%s` FOR EACH ROW %s", names[0], c.alter.name, replace),
+		fmt.Sprintf("CREATE TRIGGER `%s` AFTER UPDATE ON `%s` FOR EACH ROW BEGIN %s; %s; END", names[1], c.alter.name, remove, replace),
+		fmt.Sprintf("CREATE TRIGGER `%s` AFTER DELETE ON `%s` FOR EACH ROW %s", names[2], c.alter.name, remove),
+	}
+
+	for _, sql := range triggers {
+		if _, err := db.Exec(sql); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (c onlineAlterTableCommand) dropTriggers(db executableSQL) error {
+	for _, name := range c.triggers() {
+		if _, err := db.Exec(fmt.Sprintf("DROP TRIGGER IF EXISTS `%s`", name)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// cleanup removes triggers and shadow table after failure, so migration can be run again
+func (c onlineAlterTableCommand) cleanup(db executableSQL) {
+	c.dropTriggers(db)
+	db.Exec(fmt.Sprintf("DROP TABLE IF EXISTS `%s`", c.shadowTable()))
+}
+
+func tableColumns(db executableSQL, table string) ([]string, error) {
+	rows, err := db.Query(
+		"SELECT COLUMN_NAME FROM information_schema.COLUMNS WHERE TABLE_SCHEMA = DATABASE() AND TABLE_NAME = ? ORDER BY ORDINAL_POSITION",
+		table,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var columns []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, err
+		}
+
+		columns = append(columns, name)
+	}
+
+	return columns, rows.Err()
+}
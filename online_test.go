@@ -0,0 +1,256 @@
+package migrator
+
+import (
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOnlineOptionsWrap(t *testing.T) {
+	o := &OnlineOptions{ChunkSize: 10}
+	alter := alterTableCommand{name: "posts", pool: TableCommands{DropColumnCommand("title")}}
+
+	commands, err := o.wrap([]command{testDummyCommand("test"), alter})
+
+	assert.Nil(t, err)
+	assert.Equal(t, []command{testDummyCommand("test"), onlineAlterTableCommand{alter, *o}}, commands)
+
+	_, err = o.wrap([]command{renameColumnFallback{alter}})
+
+	assert.EqualError(t, err, `Table "posts" can't be altered online: RENAME COLUMN is not supported by the server`)
+}
+
+func TestOnlineAlterTableCommand(t *testing.T) {
+	alter := alterTableCommand{name: "posts", pool: TableCommands{
+		AddColumnCommand{Name: "rating", Column: Integer{}},
+		RenameColumnCommand{Old: "body", New: "content"},
+		DropColumnCommand("summary"),
+	}}
+	columnsQuery := `SELECT COLUMN_NAME FROM information_schema.COLUMNS WHERE TABLE_SCHEMA = DATABASE\(\) AND TABLE_NAME = \?`
+	foreignsQuery := `SELECT CONSTRAINT_NAME, TABLE_NAME FROM information_schema.KEY_COLUMN_USAGE`
+
+	expectNoForeigns := func(mock sqlmock.Sqlmock) {
+		mock.ExpectQuery(foreignsQuery).WithArgs("posts", "posts").WillReturnRows(sqlmock.NewRows([]string{"CONSTRAINT_NAME", "TABLE_NAME"}))
+	}
+	expectCopy := func(mock sqlmock.Sqlmock) {
+		expectNoForeigns(mock)
+		mock.ExpectExec("CREATE TABLE `_posts_new` LIKE `posts`").WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec("ALTER TABLE `_posts_new` ADD COLUMN `rating` int NOT NULL, RENAME COLUMN `body` TO `content`, DROP COLUMN `summary`").
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectQuery(columnsQuery).WithArgs("posts").
+			WillReturnRows(sqlmock.NewRows([]string{"COLUMN_NAME"}).AddRow("id").AddRow("body").AddRow("summary"))
+		mock.ExpectQuery(columnsQuery).WithArgs("_posts_new").
+			WillReturnRows(sqlmock.NewRows([]string{"COLUMN_NAME"}).AddRow("id").AddRow("content").AddRow("rating"))
+		mock.ExpectExec("CREATE TRIGGER `_posts_ins` AFTER INSERT ON `posts` FOR EACH ROW " +
+			"REPLACE INTO `_posts_new` \\(`id`, `content`\\) VALUES \\(NEW.`id`, NEW.`body`\\)").
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec("CREATE TRIGGER `_posts_upd` AFTER UPDATE ON `posts` FOR EACH ROW " +
+			"BEGIN DELETE FROM `_posts_new` WHERE `id` = OLD.`id`; REPLACE INTO `_posts_new` .*; END").
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec("CREATE TRIGGER `_posts_del` AFTER DELETE ON `posts` FOR EACH ROW " +
+			"DELETE FROM `_posts_new` WHERE `id` = OLD.`id`").
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectQuery("SELECT MIN\\(`id`\\), MAX\\(`id`\\) FROM `posts`").
+			WillReturnRows(sqlmock.NewRows([]string{"min", "max"}).AddRow(1, 250))
+		chunkEnd := "SELECT `id` FROM `posts` WHERE `id` >= \\? ORDER BY `id` LIMIT 1 OFFSET 9"
+		copyChunk := "INSERT IGNORE INTO `_posts_new` \\(`id`, `content`\\) SELECT `id`, `body` FROM `posts` WHERE `id` BETWEEN \\? AND \\?"
+		mock.ExpectQuery(chunkEnd).WithArgs(1).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(10))
+		mock.ExpectExec(copyChunk).WithArgs(1, 10).WillReturnResult(sqlmock.NewResult(0, 10))
+		mock.ExpectQuery(chunkEnd).WithArgs(11).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(200))
+		mock.ExpectExec(copyChunk).WithArgs(11, 200).WillReturnResult(sqlmock.NewResult(0, 10))
+		mock.ExpectQuery(chunkEnd).WithArgs(201).WillReturnError(sql.ErrNoRows)
+		mock.ExpectExec(copyChunk).WithArgs(201, 250).WillReturnResult(sqlmock.NewResult(0, 5))
+	}
+	expectDropTriggers := func(mock sqlmock.Sqlmock) {
+		for _, name := range []string{"_posts_ins", "_posts_upd", "_posts_del"} {
+			mock.ExpectExec("DROP TRIGGER IF EXISTS `" + name + "`").WillReturnResult(sqlmock.NewResult(0, 0))
+		}
+	}
+
+	t.Run("it fails on empty alter command", func(t *testing.T) {
+		db, _, resetDB := testDBConnection(t)
+		defer resetDB()
+
+		c := onlineAlterTableCommand{alter: alterTableCommand{name: "posts"}}
+
+		assert.Equal(t, ErrNoSQLCommandsToRun, c.exec(db))
+	})
+
+	t.Run("it copies table in chunks and swaps tables", func(t *testing.T) {
+		defer func(s func(time.Duration)) { sleep = s }(sleep)
+		var pauses int
+		sleep = func(time.Duration) { pauses++ }
+
+		db, mock, resetDB := testDBConnection(t)
+		defer resetDB()
+
+		expectCopy(mock)
+		mock.ExpectExec("RENAME TABLE `posts` TO `_posts_old`, `_posts_new` TO `posts`").WillReturnResult(sqlmock.NewResult(0, 0))
+		expectDropTriggers(mock)
+		mock.ExpectExec("DROP TABLE `_posts_old`").WillReturnResult(sqlmock.NewResult(0, 0))
+
+		c := onlineAlterTableCommand{alter, OnlineOptions{ChunkSize: 10, Throttle: time.Second}}
+
+		assert.Nil(t, c.exec(db))
+		assert.Equal(t, 2, pauses)
+	})
+
+	t.Run("it keeps old table", func(t *testing.T) {
+		db, mock, resetDB := testDBConnection(t)
+		defer resetDB()
+
+		expectCopy(mock)
+		mock.ExpectExec("RENAME TABLE").WillReturnResult(sqlmock.NewResult(0, 0))
+		expectDropTriggers(mock)
+
+		c := onlineAlterTableCommand{alter, OnlineOptions{ChunkSize: 10, KeepOldTable: true}}
+
+		assert.Nil(t, c.exec(db))
+	})
+
+	t.Run("it skips copying of empty table", func(t *testing.T) {
+		db, mock, resetDB := testDBConnection(t)
+		defer resetDB()
+
+		c := onlineAlterTableCommand{alter: alterTableCommand{name: "posts", pool: TableCommands{DropColumnCommand("summary")}}}
+
+		expectNoForeigns(mock)
+		mock.ExpectExec("CREATE TABLE `_posts_new` LIKE `posts`").WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec("ALTER TABLE `_posts_new` DROP COLUMN `summary`").WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectQuery(columnsQuery).WithArgs("posts").
+			WillReturnRows(sqlmock.NewRows([]string{"COLUMN_NAME"}).AddRow("id").AddRow("summary"))
+		mock.ExpectQuery(columnsQuery).WithArgs("_posts_new").
+			WillReturnRows(sqlmock.NewRows([]string{"COLUMN_NAME"}).AddRow("id"))
+		mock.ExpectExec("CREATE TRIGGER").WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec("CREATE TRIGGER").WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec("CREATE TRIGGER").WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectQuery("SELECT MIN").WillReturnRows(sqlmock.NewRows([]string{"min", "max"}).AddRow(nil, nil))
+		mock.ExpectExec("RENAME TABLE").WillReturnResult(sqlmock.NewResult(0, 0))
+		expectDropTriggers(mock)
+		mock.ExpectExec("DROP TABLE `_posts_old`").WillReturnResult(sqlmock.NewResult(0, 0))
+
+		assert.Nil(t, c.exec(db))
+	})
+
+	t.Run("it cleans up when cutover is aborted", func(t *testing.T) {
+		db, mock, resetDB := testDBConnection(t)
+		defer resetDB()
+
+		abort := errors.New("cutover is not allowed")
+
+		expectCopy(mock)
+		expectDropTriggers(mock)
+		mock.ExpectExec("DROP TABLE IF EXISTS `_posts_new`").WillReturnResult(sqlmock.NewResult(0, 0))
+
+		c := onlineAlterTableCommand{alter, OnlineOptions{ChunkSize: 10, BeforeCutover: func() error { return abort }}}
+
+		assert.Equal(t, abort, c.exec(db))
+	})
+
+	t.Run("it refuses tables with foreign keys", func(t *testing.T) {
+		db, mock, resetDB := testDBConnection(t)
+		defer resetDB()
+
+		mock.ExpectQuery(foreignsQuery).WithArgs("posts", "posts").
+			WillReturnRows(sqlmock.NewRows([]string{"CONSTRAINT_NAME", "TABLE_NAME"}).AddRow("comments_post_id_foreign", "comments"))
+
+		c := onlineAlterTableCommand{alter: alter}
+
+		assert.EqualError(t, c.exec(db), `Table "posts" can't be altered online: foreign key "comments_post_id_foreign" of table "comments" would be lost on cutover`)
+		assert.Nil(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("it cleans up when copying fails", func(t *testing.T) {
+		db, mock, resetDB := testDBConnection(t)
+		defer resetDB()
+
+		expectNoForeigns(mock)
+		mock.ExpectExec("CREATE TABLE `_posts_new` LIKE `posts`").WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec("ALTER TABLE `_posts_new`").WillReturnError(errTestDBExecFailed)
+		expectDropTriggers(mock)
+		mock.ExpectExec("DROP TABLE IF EXISTS `_posts_new`").WillReturnResult(sqlmock.NewResult(0, 0))
+
+		c := onlineAlterTableCommand{alter: alter}
+
+		assert.Equal(t, errTestDBExecFailed, c.exec(db))
+	})
+
+	t.Run("it fails when no columns can be copied", func(t *testing.T) {
+		db, mock, resetDB := testDBConnection(t)
+		defer resetDB()
+
+		mock.ExpectQuery(columnsQuery).WithArgs("posts").WillReturnRows(sqlmock.NewRows([]string{"COLUMN_NAME"}).AddRow("body"))
+		mock.ExpectQuery(columnsQuery).WithArgs("_posts_new").WillReturnRows(sqlmock.NewRows([]string{"COLUMN_NAME"}).AddRow("id"))
+
+		_, _, err := onlineAlterTableCommand{alter: alterTableCommand{name: "posts"}}.columns(db)
+
+		assert.EqualError(t, err, `Table "posts" has no columns to be copied`)
+	})
+}
+
+func TestMigrateOnline(t *testing.T) {
+	t.Run("it refuses online migration within transaction", func(t *testing.T) {
+		migration := Migration{Name: "test", Transaction: true, Online: &OnlineOptions{}, Up: func() Schema {
+			var s Schema
+			s.AlterTable("posts", TableCommands{DropColumnCommand("title")})
+			return s
+		}}
+		m := Migrator{Pool: []Migration{migration}}
+		db, mock, resetDB := testDBConnection(t)
+		defer resetDB()
+
+		mock.ExpectQuery("SELECT").WillReturnRows(sqlmock.NewRows(nil))
+		mock.ExpectQuery("SELECT id, name, batch, applied_at FROM `migrations`").WillReturnRows(sqlmock.NewRows([]string{"id"}))
+
+		migrated, err := m.Migrate(db)
+
+		assert.Len(t, migrated, 0)
+		assert.EqualError(t, err, `Migration "test" can't alter tables online within transaction`)
+	})
+	t.Run("it alters tables online within resumable migration", func(t *testing.T) {
+		migration := Migration{Name: "test", Online: &OnlineOptions{}, Up: func() Schema {
+			var s Schema
+			s.AlterTable("posts", TableCommands{DropColumnCommand("title")})
+			return s
+		}}
+		m := Migrator{Pool: []Migration{migration}, Resumable: true}
+		db, mock, resetDB := testDBConnection(t)
+		defer resetDB()
+
+		mock.ExpectQuery("SELECT").WillReturnRows(sqlmock.NewRows(nil))
+		mock.ExpectQuery("SELECT id, name, batch, applied_at FROM `migrations`").WillReturnRows(sqlmock.NewRows([]string{"id"}))
+		mock.ExpectExec("CREATE TABLE IF NOT EXISTS `migrations_progress`").WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectQuery("SELECT name, step FROM `migrations_progress`").WillReturnRows(sqlmock.NewRows([]string{"name", "step"}))
+		mock.ExpectExec("INSERT INTO `migrations_progress`").WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectQuery("SELECT CONSTRAINT_NAME, TABLE_NAME FROM information_schema.KEY_COLUMN_USAGE").
+			WillReturnRows(sqlmock.NewRows([]string{"CONSTRAINT_NAME", "TABLE_NAME"}).AddRow("comments_post_id_foreign", "comments"))
+
+		_, err := m.Migrate(db)
+
+		assert.EqualError(t, err, `Table "posts" can't be altered online: foreign key "comments_post_id_foreign" of table "comments" would be lost on cutover`)
+		assert.Nil(t, mock.ExpectationsWereMet())
+	})
+	t.Run("it refuses renaming columns online on old server", func(t *testing.T) {
+		migration := Migration{Name: "test", Online: &OnlineOptions{}, Up: func() Schema {
+			var s Schema
+			s.AlterTable("posts", TableCommands{RenameColumnCommand{Old: "body", New: "content"}})
+			return s
+		}}
+		m := Migrator{Pool: []Migration{migration}, ServerVersion: "5.7.30"}
+		db, mock, resetDB := testDBConnection(t)
+		defer resetDB()
+
+		mock.ExpectQuery("SELECT").WillReturnRows(sqlmock.NewRows(nil))
+		mock.ExpectQuery("SELECT id, name, batch, applied_at FROM `migrations`").WillReturnRows(sqlmock.NewRows([]string{"id"}))
+
+		migrated, err := m.Migrate(db)
+
+		assert.Len(t, migrated, 0)
+		assert.EqualError(t, err, `Migration "test" can't be run: Table "posts" can't be altered online: RENAME COLUMN is not supported by the server`)
+		assert.Nil(t, mock.ExpectationsWereMet())
+	})
+}
@@ -0,0 +1,343 @@
+package migrator
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// postgresDialect renders commands for PostgreSQL.
+//
+// MySQL specific options are skipped: table engine, charset and collation,
+// `unsigned` and display width of numeric columns, `ON UPDATE` of columns.
+// Enum is rendered as varchar with CHECK constraint.
+type postgresDialect struct {
+	standardDialect
+}
+
+func (d postgresDialect) rebind(query string) string {
+	return rebindNumbered(query)
+}
+
+// commitsImplicitly always returns false, DDL is transactional in PostgreSQL
+func (d postgresDialect) commitsImplicitly(c command) bool {
+	return false
+}
+
+func (d postgresDialect) createMigrationTable(table string) string {
+	return fmt.Sprintf(
+		"CREATE TABLE %s (%s)",
+		table,
+		strings.Join([]string{
+			"id serial PRIMARY KEY",
+			"name varchar(255) NOT NULL",
+			"batch integer NOT NULL",
+			"applied_at timestamp(6) NULL DEFAULT CURRENT_TIMESTAMP(6)",
+		}, ", "),
+	)
+}
+
+func (d postgresDialect) createProgressTable(table string) string {
+	return fmt.Sprintf(
+		"CREATE TABLE IF NOT EXISTS %s (%s)",
+		table,
+		strings.Join([]string{
+			"name varchar(255) NOT NULL PRIMARY KEY",
+			"step integer NOT NULL",
+		}, ", "),
+	)
+}
+
+func (d postgresDialect) sessionVariable(name string) string {
+	return fmt.Sprintf("SELECT current_setting('%s')", name)
+}
+
+func (d postgresDialect) setSessionVariable(name string, value string) string {
+	return fmt.Sprintf("SET SESSION %s = %s", name, value)
+}
+
+func (d postgresDialect) render(c command) ([]string, error) {
+	switch v := c.(type) {
+	case createTableCommand:
+		return d.createTable(v.t)
+	case dropTableCommand:
+		sql := "DROP TABLE"
+		if v.soft {
+			sql += " IF EXISTS"
+		}
+		sql += " " + d.quote(v.table)
+
+		if option := strings.ToUpper(v.option); list([]string{"RESTRICT", "CASCADE"}).has(option) {
+			sql += " " + option
+		}
+
+		return []string{sql}, nil
+	case renameTableCommand:
+		return []string{fmt.Sprintf("ALTER TABLE %s RENAME TO %s", d.quote(v.old), d.quote(v.new))}, nil
+	case alterTableCommand:
+		return d.alterTable(v)
+	}
+
+	return []string{c.toSQL()}, nil
+}
+
+func (d postgresDialect) createTable(t Table) ([]string, error) {
+	if t.Name == "" {
+		return nil, ErrNoSQLCommandsToRun
+	}
+
+	var definitions, indexes, comments []string
+
+	for _, col := range t.columns {
+		pc, err := d.column(col.field, col.definition)
+		if err != nil {
+			return nil, err
+		}
+		definitions = append(definitions, d.quote(col.field)+" "+pc.definition())
+
+		if pc.comment != "" {
+			comments = append(comments, d.columnComment(t.Name, col.field, pc.comment))
+		}
+	}
+
+	if len(definitions) == 0 {
+		definitions = append(definitions, d.quote("id")+" bigint NOT NULL GENERATED BY DEFAULT AS IDENTITY")
+	}
+
+	for _, key := range t.indexes {
+		if len(key.Columns) == 0 {
+			continue
+		}
+
+		switch strings.ToUpper(key.Type) {
+		case "PRIMARY":
+			definitions = append(definitions, "PRIMARY KEY ("+d.quoteList(key.Columns)+")")
+		case "UNIQUE":
+			indexes = append(indexes, d.createIndex(t.Name, key.Name, key.Columns, true))
+		default:
+			indexes = append(indexes, d.createIndex(t.Name, key.Name, key.Columns, false))
+		}
+	}
+
+	for _, f := range t.foreigns {
+		if sql := d.foreign(f); sql != "" {
+			definitions = append(definitions, sql)
+		}
+	}
+
+	statements := []string{fmt.Sprintf("CREATE TABLE %s (%s)", d.quote(t.Name), strings.Join(definitions, ", "))}
+	statements = append(statements, indexes...)
+
+	if t.Comment != "" {
+		statements = append(statements, fmt.Sprintf("COMMENT ON TABLE %s IS %s", d.quote(t.Name), d.literal(t.Comment)))
+	}
+
+	return append(statements, comments...), nil
+}
+
+func (d postgresDialect) alterTable(c alterTableCommand) ([]string, error) {
+	if c.name == "" || len(c.pool) == 0 {
+		return nil, ErrNoSQLCommandsToRun
+	}
+
+	if c.options.Algorithm != "" || c.options.Lock != "" {
+		return nil, fmt.Errorf(`ALGORITHM and LOCK options are not supported by PostgreSQL to alter table "%s"`, c.name)
+	}
+
+	var statements []string
+	alter := "ALTER TABLE " + d.quote(c.name) + " "
+
+	for _, tc := range c.pool {
+		switch v := tc.(type) {
+		case AddColumnCommand:
+			if v.After != "" || v.First {
+				return nil, fmt.Errorf(`Column position is not supported by PostgreSQL to add column "%s"`, v.Name)
+			}
+
+			pc, err := d.column(v.Name, v.Column)
+			if err != nil {
+				return nil, err
+			}
+			statements = append(statements, alter+"ADD COLUMN "+d.quote(v.Name)+" "+pc.definition())
+			if pc.comment != "" {
+				statements = append(statements, d.columnComment(c.name, v.Name, pc.comment))
+			}
+		case RenameColumnCommand:
+			statements = append(statements, alter+fmt.Sprintf("RENAME COLUMN %s TO %s", d.quote(v.Old), d.quote(v.New)))
+		case ModifyColumnCommand:
+			modify, err := d.modifyColumn(c.name, v.Name, v.Column)
+			if err != nil {
+				return nil, err
+			}
+			statements = append(statements, modify...)
+		case ChangeColumnCommand:
+			modify, err := d.modifyColumn(c.name, v.To, v.Column)
+			if err != nil {
+				return nil, err
+			}
+			if v.From != v.To {
+				statements = append(statements, alter+fmt.Sprintf("RENAME COLUMN %s TO %s", d.quote(v.From), d.quote(v.To)))
+			}
+			statements = append(statements, modify...)
+		case DropColumnCommand:
+			statements = append(statements, alter+"DROP COLUMN "+d.quote(string(v)))
+		case AddIndexCommand:
+			statements = append(statements, d.createIndex(c.name, v.Name, v.Columns, false))
+		case AddUniqueIndexCommand:
+			statements = append(statements, d.createIndex(c.name, v.Key, v.Columns, true))
+		case DropIndexCommand:
+			statements = append(statements, "DROP INDEX "+d.quote(string(v)))
+		case AddForeignCommand:
+			statements = append(statements, alter+"ADD "+d.foreign(v.Foreign))
+		case DropForeignCommand:
+			statements = append(statements, alter+"DROP CONSTRAINT "+d.quote(string(v)))
+		case AddPrimaryIndexCommand:
+			statements = append(statements, alter+"ADD PRIMARY KEY ("+d.quote(string(v))+")")
+		case AddCompositePrimaryIndexCommand:
+			statements = append(statements, alter+"ADD PRIMARY KEY ("+d.quoteList(v.Columns)+")")
+		case DropPrimaryIndexCommand:
+			statements = append(statements, alter+"DROP CONSTRAINT "+d.quote(c.name+"_pkey"))
+		default:
+			statements = append(statements, alter+tc.toSQL())
+		}
+	}
+
+	return statements, nil
+}
+
+func (d postgresDialect) modifyColumn(table string, name string, c columnType) ([]string, error) {
+	pc, err := d.column(name, c)
+	if err != nil {
+		return nil, err
+	}
+	column := "ALTER COLUMN " + d.quote(name)
+
+	actions := []string{column + " TYPE " + pc.typ}
+
+	if pc.nullable {
+		actions = append(actions, column+" DROP NOT NULL")
+	} else {
+		actions = append(actions, column+" SET NOT NULL")
+	}
+
+	if pc.def != "" {
+		actions = append(actions, column+" SET DEFAULT "+pc.def)
+	} else {
+		actions = append(actions, column+" DROP DEFAULT")
+	}
+
+	statements := []string{"ALTER TABLE " + d.quote(table) + " " + strings.Join(actions, ", ")}
+	if pc.comment != "" {
+		statements = append(statements, d.columnComment(table, name, pc.comment))
+	}
+
+	return statements, nil
+}
+
+func (d postgresDialect) columnComment(table string, column string, comment string) string {
+	return fmt.Sprintf("COMMENT ON COLUMN %s.%s IS %s", d.quote(table), d.quote(column), d.literal(comment))
+}
+
+// column converts column type to PostgreSQL, custom column types are rendered as they are
+func (d postgresDialect) column(name string, c columnType) (columnDefinition, error) {
+	var pc columnDefinition
+
+	switch v := c.(type) {
+	case Integer:
+		switch v.Prefix {
+		case "tiny", "small":
+			pc.typ = "smallint"
+		case "big":
+			pc.typ = "bigint"
+		default:
+			pc.typ = "integer"
+		}
+		pc.nullable, pc.def, pc.comment = v.Nullable, v.Default, v.Comment
+		if v.Autoincrement {
+			pc.identity = "GENERATED BY DEFAULT AS IDENTITY"
+		}
+	case Floatable:
+		switch strings.ToLower(v.Type) {
+		case "double":
+			pc.typ = "double precision"
+		case "decimal", "numeric":
+			pc.typ = "numeric"
+			if v.Precision > 0 {
+				pc.typ += fmt.Sprintf("(%d,%d)", v.Precision, v.Scale)
+			}
+		default:
+			pc.typ = "real"
+		}
+		pc.nullable, pc.def, pc.comment = v.Nullable, v.Default, v.Comment
+	case Timable:
+		switch strings.ToLower(v.Type) {
+		case "date":
+			pc.typ = "date"
+		case "year":
+			pc.typ = "smallint"
+		case "time":
+			pc.typ = "time"
+		default:
+			pc.typ = "timestamp"
+		}
+		if pc.typ != "date" && pc.typ != "smallint" && v.Precision > 0 && v.Precision <= 6 {
+			pc.typ += "(" + strconv.Itoa(int(v.Precision)) + ")"
+		}
+		pc.nullable, pc.def, pc.comment = v.Nullable, v.Default, v.Comment
+	case String:
+		pc.typ = "varchar"
+		if v.Fixed {
+			pc.typ = "char"
+		}
+		if v.Precision > 0 {
+			pc.typ += "(" + strconv.Itoa(int(v.Precision)) + ")"
+		}
+		pc.nullable, pc.def, pc.comment = v.Nullable, d.stringDefault(v.Default), v.Comment
+	case Text:
+		pc.typ = "text"
+		if v.Blob {
+			pc.typ = "bytea"
+		}
+		pc.nullable, pc.def, pc.comment = v.Nullable, d.stringDefault(v.Default), v.Comment
+	case JSON:
+		pc.typ = "jsonb"
+		pc.nullable, pc.def, pc.comment = v.Nullable, d.stringDefault(v.Default), v.Comment
+	case Enum:
+		if v.Multiple {
+			return pc, fmt.Errorf(`Set column "%s" is not supported by PostgreSQL`, name)
+		}
+
+		pc.typ = "varchar(255)"
+		var values []string
+		for _, value := range v.Values {
+			values = append(values, d.literal(value))
+		}
+		pc.check = fmt.Sprintf("CHECK (%s IN (%s))", d.quote(name), strings.Join(values, ", "))
+		pc.nullable, pc.def, pc.comment = v.Nullable, d.stringDefault(v.Default), v.Comment
+	case Bit:
+		pc.typ = "bit"
+		if v.Precision > 0 {
+			pc.typ += "(" + strconv.Itoa(int(v.Precision)) + ")"
+		}
+		pc.nullable, pc.def, pc.comment = v.Nullable, v.Default, v.Comment
+	case Binary:
+		pc.typ = "bytea"
+		pc.nullable, pc.def, pc.comment = v.Nullable, v.Default, v.Comment
+
+		switch {
+		case strings.EqualFold(v.Default, "(UUID_TO_BIN(UUID()))"):
+			pc.def = "decode(replace(gen_random_uuid()::text, '-', ''), 'hex')"
+		case isDefaultExpression(v.Default):
+			return pc, fmt.Errorf(`Default value %s of binary column "%s" is not supported by PostgreSQL`, v.Default, name)
+		}
+	default:
+		pc.raw = c.buildRow()
+		pc.typ = pc.raw
+	}
+
+	if pc.def == "(UUID())" {
+		pc.def = "gen_random_uuid()"
+	}
+
+	return pc, nil
+}
@@ -0,0 +1,207 @@
+package migrator
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPostgresDialect(t *testing.T) {
+	assert.Equal(t, `"posts"`, PostgreSQL.quote("posts"))
+	assert.Equal(t, `'it''s'`, PostgreSQL.literal("it's"))
+	assert.Equal(t, "DELETE FROM a WHERE id = $1", PostgreSQL.rebind("DELETE FROM a WHERE id = ?"))
+	assert.False(t, PostgreSQL.commitsImplicitly(alterTableCommand{}))
+	assert.Equal(t, "SELECT current_setting('lock_timeout')", PostgreSQL.sessionVariable("lock_timeout"))
+	assert.Equal(
+		t,
+		"CREATE TABLE migrations (id serial PRIMARY KEY, name varchar(255) NOT NULL, batch integer NOT NULL, applied_at timestamp(6) NULL DEFAULT CURRENT_TIMESTAMP(6))",
+		PostgreSQL.createMigrationTable("migrations"),
+	)
+	assert.Equal(
+		t,
+		"CREATE TABLE IF NOT EXISTS migrations_progress (name varchar(255) NOT NULL PRIMARY KEY, step integer NOT NULL)",
+		PostgreSQL.createProgressTable("migrations_progress"),
+	)
+}
+
+func TestPostgresCreateTable(t *testing.T) {
+	t.Run("it fails without table name", func(t *testing.T) {
+		_, err := PostgreSQL.render(createTableCommand{Table{}})
+
+		assert.Equal(t, ErrNoSQLCommandsToRun, err)
+	})
+
+	t.Run("it renders identity column for empty table", func(t *testing.T) {
+		statements, err := PostgreSQL.render(createTableCommand{Table{Name: "posts", Engine: "InnoDB", Charset: "utf8mb4"}})
+
+		assert.Nil(t, err)
+		assert.Equal(t, []string{`CREATE TABLE "posts" ("id" bigint NOT NULL GENERATED BY DEFAULT AS IDENTITY)`}, statements)
+	})
+
+	t.Run("it renders indexes, foreign keys and comments separately", func(t *testing.T) {
+		table := Table{Name: "comments", Comment: "Comments of posts"}
+		table.ID("id")
+		table.Column("post_id", Integer{Prefix: "big", Unsigned: true})
+		table.Column("body", Text{Comment: "Markdown"})
+		table.UUID("uuid", "(UUID())", false)
+		table.Timestamps()
+		table.Unique("uuid")
+		table.Foreign("post_id", "id", "posts", "cascade", "cascade")
+
+		statements, err := PostgreSQL.render(createTableCommand{table})
+
+		assert.Nil(t, err)
+		assert.Equal(t, []string{
+			`CREATE TABLE "comments" (` +
+				`"id" bigint NOT NULL GENERATED BY DEFAULT AS IDENTITY, ` +
+				`"post_id" bigint NOT NULL, ` +
+				`"body" text NOT NULL, ` +
+				`"uuid" char(36) NOT NULL DEFAULT gen_random_uuid(), ` +
+				`"created_at" timestamp(6) NOT NULL DEFAULT CURRENT_TIMESTAMP(6), ` +
+				`"updated_at" timestamp(6) NOT NULL DEFAULT CURRENT_TIMESTAMP(6), ` +
+				`PRIMARY KEY ("id"), ` +
+				`CONSTRAINT "comments_post_id_foreign" FOREIGN KEY ("post_id") REFERENCES "posts" ("id") ON DELETE CASCADE ON UPDATE CASCADE)`,
+			`CREATE UNIQUE INDEX "comments_uuid_unique" ON "comments" ("uuid")`,
+			`CREATE INDEX "comments_post_id_foreign" ON "comments" ("post_id")`,
+			`COMMENT ON TABLE "comments" IS 'Comments of posts'`,
+			`COMMENT ON COLUMN "comments"."body" IS 'Markdown'`,
+		}, statements)
+	})
+
+	t.Run("it fails on set column", func(t *testing.T) {
+		table := Table{Name: "posts"}
+		table.Column("tags", Enum{Values: []string{"a", "b"}, Multiple: true})
+
+		_, err := PostgreSQL.render(createTableCommand{table})
+
+		assert.EqualError(t, err, `Set column "tags" is not supported by PostgreSQL`)
+	})
+
+	t.Run("it generates binary unique id", func(t *testing.T) {
+		table := Table{Name: "posts"}
+		table.BinaryID("id")
+
+		statements, err := PostgreSQL.render(createTableCommand{table})
+
+		assert.Nil(t, err)
+		assert.Equal(t, []string{
+			`CREATE TABLE "posts" (` +
+				`"id" bytea NOT NULL DEFAULT decode(replace(gen_random_uuid()::text, '-', ''), 'hex'), ` +
+				`PRIMARY KEY ("id"))`,
+		}, statements)
+	})
+
+	t.Run("it fails on MySQL expression default of binary column", func(t *testing.T) {
+		table := Table{Name: "posts"}
+		table.Column("hash", Binary{Default: "(UNHEX(SHA2('', 256)))"})
+
+		_, err := PostgreSQL.render(createTableCommand{table})
+
+		assert.EqualError(t, err, `Default value (UNHEX(SHA2('', 256))) of binary column "hash" is not supported by PostgreSQL`)
+	})
+}
+
+func TestPostgresTableCommands(t *testing.T) {
+	t.Run("it renders drop and rename of the table", func(t *testing.T) {
+		statements, _ := PostgreSQL.render(dropTableCommand{table: "posts", soft: true, option: "cascade"})
+		assert.Equal(t, []string{`DROP TABLE IF EXISTS "posts" CASCADE`}, statements)
+
+		statements, _ = PostgreSQL.render(renameTableCommand{old: "posts", new: "articles"})
+		assert.Equal(t, []string{`ALTER TABLE "posts" RENAME TO "articles"`}, statements)
+	})
+
+	t.Run("it renders custom commands as they are", func(t *testing.T) {
+		statements, err := PostgreSQL.render(testDummyCommand("UPDATE posts SET active = true"))
+
+		assert.Nil(t, err)
+		assert.Equal(t, []string{"UPDATE posts SET active = true"}, statements)
+	})
+
+	t.Run("it refuses online DDL options", func(t *testing.T) {
+		_, err := PostgreSQL.render(alterTableCommand{name: "posts", pool: TableCommands{DropColumnCommand("title")}, options: AlterOptions{Lock: "NONE"}})
+
+		assert.EqualError(t, err, `ALGORITHM and LOCK options are not supported by PostgreSQL to alter table "posts"`)
+	})
+
+	t.Run("it renders every table command separately", func(t *testing.T) {
+		statements, err := PostgreSQL.render(alterTableCommand{name: "posts", pool: TableCommands{
+			AddColumnCommand{Name: "rating", Column: Integer{Prefix: "tiny", Unsigned: true, Default: "0", Comment: "Stars"}},
+			RenameColumnCommand{Old: "title", New: "name"},
+			ModifyColumnCommand{Name: "status", Column: Enum{Values: []string{"draft", "published"}, Default: "draft"}},
+			ChangeColumnCommand{From: "price", To: "amount", Column: Floatable{Type: "decimal", Precision: 10, Scale: 2, Nullable: true}},
+			DropColumnCommand("legacy"),
+			AddIndexCommand{Name: "idx_rating", Columns: []string{"rating"}},
+			AddUniqueIndexCommand{Key: "posts_slug_unique", Columns: []string{"slug"}},
+			DropIndexCommand("idx_old"),
+			AddForeignCommand{Foreign{Key: "posts_user_id_foreign", Column: "user_id", Reference: "id", On: "users", OnDelete: "set null"}},
+			DropForeignCommand("posts_author_id_foreign"),
+			DropPrimaryIndexCommand{},
+			AddPrimaryIndexCommand("uuid"),
+		}})
+
+		assert.Nil(t, err)
+		assert.Equal(t, []string{
+			`ALTER TABLE "posts" ADD COLUMN "rating" smallint NOT NULL DEFAULT 0`,
+			`COMMENT ON COLUMN "posts"."rating" IS 'Stars'`,
+			`ALTER TABLE "posts" RENAME COLUMN "title" TO "name"`,
+			`ALTER TABLE "posts" ALTER COLUMN "status" TYPE varchar(255), ALTER COLUMN "status" SET NOT NULL, ALTER COLUMN "status" SET DEFAULT 'draft'`,
+			`ALTER TABLE "posts" RENAME COLUMN "price" TO "amount"`,
+			`ALTER TABLE "posts" ALTER COLUMN "amount" TYPE numeric(10,2), ALTER COLUMN "amount" DROP NOT NULL, ALTER COLUMN "amount" DROP DEFAULT`,
+			`ALTER TABLE "posts" DROP COLUMN "legacy"`,
+			`CREATE INDEX "idx_rating" ON "posts" ("rating")`,
+			`CREATE UNIQUE INDEX "posts_slug_unique" ON "posts" ("slug")`,
+			`DROP INDEX "idx_old"`,
+			`ALTER TABLE "posts" ADD CONSTRAINT "posts_user_id_foreign" FOREIGN KEY ("user_id") REFERENCES "users" ("id") ON DELETE SET NULL`,
+			`ALTER TABLE "posts" DROP CONSTRAINT "posts_author_id_foreign"`,
+			`ALTER TABLE "posts" DROP CONSTRAINT "posts_pkey"`,
+			`ALTER TABLE "posts" ADD PRIMARY KEY ("uuid")`,
+		}, statements)
+	})
+
+	t.Run("it renders composite primary key", func(t *testing.T) {
+		statements, err := PostgreSQL.render(alterTableCommand{name: "post_tags", pool: TableCommands{
+			AddCompositePrimaryIndexCommand{Columns: []string{"post_id", "tag_id"}},
+		}})
+
+		assert.Nil(t, err)
+		assert.Equal(t, []string{`ALTER TABLE "post_tags" ADD PRIMARY KEY ("post_id", "tag_id")`}, statements)
+	})
+
+	t.Run("it refuses column position", func(t *testing.T) {
+		_, err := PostgreSQL.render(alterTableCommand{name: "posts", pool: TableCommands{
+			AddColumnCommand{Name: "rating", Column: Integer{}, After: "title"},
+		}})
+
+		assert.EqualError(t, err, `Column position is not supported by PostgreSQL to add column "rating"`)
+	})
+}
+
+func TestPostgresColumn(t *testing.T) {
+	cases := []struct {
+		column   columnType
+		expected string
+	}{
+		{Integer{}, "integer NOT NULL"},
+		{Integer{Prefix: "medium", Nullable: true}, "integer NULL"},
+		{Integer{Prefix: "big", Autoincrement: true}, "bigint NOT NULL GENERATED BY DEFAULT AS IDENTITY"},
+		{Floatable{}, "real NOT NULL"},
+		{Floatable{Type: "double", Default: "0.5"}, "double precision NOT NULL DEFAULT 0.5"},
+		{Timable{Type: "datetime", Precision: 3}, "timestamp(3) NOT NULL"},
+		{Timable{Type: "date", Nullable: true}, "date NULL"},
+		{Timable{Type: "year"}, "smallint NOT NULL"},
+		{String{Precision: 255, Charset: "utf8mb4", Default: "<empty>"}, "varchar(255) NOT NULL DEFAULT ''"},
+		{Text{Prefix: "long", Blob: true, Nullable: true}, "bytea NULL"},
+		{JSON{}, "jsonb NOT NULL"},
+		{Enum{Values: []string{"on", "off"}, Nullable: true}, `varchar(255) NULL CHECK ("col" IN ('on', 'off'))`},
+		{Bit{Precision: 8}, "bit(8) NOT NULL"},
+		{Binary{Fixed: true, Precision: 16}, "bytea NOT NULL"},
+		{testColumnType("custom type"), "custom type"},
+	}
+
+	for _, c := range cases {
+		pc, err := PostgreSQL.(postgresDialect).column("col", c.column)
+
+		assert.Nil(t, err)
+		assert.Equal(t, c.expected, pc.definition())
+	}
+}
@@ -0,0 +1,151 @@
+package migrator
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+)
+
+var errRevertPartialNotResumable = errors.New("RevertPartial requires Resumable to be set")
+
+// progressTable keeps amount of executed commands of non-transactional migrations,
+// that are currently running or failed in the middle.
+func (m Migrator) progressTable() string {
+	return m.table() + "_progress"
+}
+
+func (m Migrator) createProgressTable(db *sql.DB) error {
+	d := m.dialect()
+	_, err := db.Exec(d.createProgressTable(d.quote(m.progressTable())))
+
+	return err
+}
+
+// fetchProgress reads progress of partially applied migrations.
+// Progress of executed migrations is stale, e.g. left when migration was tracked but progress failed to be cleared,
+// so it is discarded instead of being resumed.
+func (m *Migrator) fetchProgress(db *sql.DB) error {
+	rows, err := db.Query("SELECT name, step FROM " + m.dialect().quote(m.progressTable()))
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	m.progress = map[string]int{}
+	var stale []string
+
+	for rows.Next() {
+		var name string
+		var step int
+
+		if err := rows.Scan(&name, &step); err != nil {
+			return err
+		}
+
+		if m.isExecuted(name) {
+			stale = append(stale, name)
+			continue
+		}
+
+		m.progress[name] = step
+	}
+
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	rows.Close()
+
+	for _, name := range stale {
+		if err := m.deleteProgress(db, name); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// resume executes commands of non-transactional migration one by one and stores amount of executed ones.
+// If migration was partially applied before, execution continues from the failed command,
+// or completed commands are reverted first, when RevertPartial is set.
+// Progress is cleared after track updates migration table.
+func (m Migrator) resume(db executableSQL, item Migration, commands []command, track func(executableSQL) error) error {
+	d := m.dialect()
+	step, started := m.progress[item.Name]
+
+	if started && m.RevertPartial {
+		if err := m.revertPartial(db, item, len(commands), step); err != nil {
+			return err
+		}
+
+		step = 0
+	}
+
+	if !started {
+		if _, err := db.Exec(d.rebind("INSERT INTO "+d.quote(m.progressTable())+" (name, step) VALUES (?, 0)"), item.Name); err != nil {
+			return err
+		}
+	}
+
+	for i := step; i < len(commands); i++ {
+		if err := m.Retry.run(db, commands[i]); err != nil {
+			return err
+		}
+
+		if _, err := db.Exec(d.rebind("UPDATE "+d.quote(m.progressTable())+" SET step = ? WHERE name = ?"), i+1, item.Name); err != nil {
+			return err
+		}
+	}
+
+	if err := track(db); err != nil {
+		return err
+	}
+
+	return m.deleteProgress(db, item.Name)
+}
+
+// revertPartial runs the last commands of Down() schema to revert completed commands of Up() schema.
+// Down() schema is expected to mirror Up() schema in reverse order.
+func (m Migrator) revertPartial(db executableSQL, item Migration, total int, step int) error {
+	if step == 0 {
+		return nil
+	}
+
+	s, err := item.down()
+	if err != nil {
+		return err
+	}
+
+	if len(s.pool) != total {
+		return fmt.Errorf(`Migration "%s" can't be partially reverted: Down() doesn't mirror Up()`, item.Name)
+	}
+
+	commands, err := m.commands(s)
+	if err != nil {
+		return err
+	}
+
+	if err := run(db, commands[total-step:]...); err != nil {
+		return err
+	}
+
+	d := m.dialect()
+	_, err = db.Exec(d.rebind("UPDATE "+d.quote(m.progressTable())+" SET step = 0 WHERE name = ?"), item.Name)
+
+	return err
+}
+
+// clearProgress deletes progress of the migration, when it's applied or reverted otherwise than by resume
+func (m Migrator) clearProgress(db executableSQL, name string) error {
+	if !m.Resumable {
+		return nil
+	}
+
+	return m.deleteProgress(db, name)
+}
+
+func (m Migrator) deleteProgress(db executableSQL, name string) error {
+	d := m.dialect()
+	_, err := db.Exec(d.rebind("DELETE FROM "+d.quote(m.progressTable())+" WHERE name = ?"), name)
+
+	return err
+}
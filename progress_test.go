@@ -0,0 +1,311 @@
+package migrator
+
+import (
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProgressTable(t *testing.T) {
+	m := Migrator{TableName: "_migrations"}
+
+	assert.Equal(t, "_migrations_progress", m.progressTable())
+}
+
+func TestCreateProgressTable(t *testing.T) {
+	t.Run("it creates progress table", func(t *testing.T) {
+		m := Migrator{}
+		db, mock, resetDB := testDBConnection(t)
+		defer resetDB()
+
+		sql := "CREATE TABLE IF NOT EXISTS `migrations_progress` \\(" +
+			`name varchar\(255\) COLLATE utf8mb4_unicode_ci NOT NULL PRIMARY KEY, step int\(11\) NOT NULL\) ` +
+			`ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci`
+		mock.ExpectExec(sql).WillReturnResult(sqlmock.NewResult(0, 0))
+
+		assert.Nil(t, m.createProgressTable(db))
+	})
+
+	t.Run("it fails creating table", func(t *testing.T) {
+		m := Migrator{}
+		db, mock, resetDB := testDBConnection(t)
+		defer resetDB()
+
+		mock.ExpectExec("CREATE TABLE IF NOT EXISTS `migrations_progress`").WillReturnError(errTestDBExecFailed)
+
+		assert.Equal(t, errTestDBExecFailed, m.createProgressTable(db))
+	})
+}
+
+func TestFetchProgress(t *testing.T) {
+	t.Run("it fails executing query", func(t *testing.T) {
+		m := Migrator{}
+		db, mock, resetDB := testDBConnection(t)
+		defer resetDB()
+
+		mock.ExpectQuery("SELECT name, step FROM `migrations_progress`").WillReturnError(errTestDBQueryFailed)
+
+		assert.Equal(t, errTestDBQueryFailed, m.fetchProgress(db))
+	})
+
+	t.Run("it returns progress of started migrations", func(t *testing.T) {
+		m := Migrator{}
+		db, mock, resetDB := testDBConnection(t)
+		defer resetDB()
+
+		rows := sqlmock.NewRows([]string{"name", "step"}).AddRow("test", 2).AddRow("new", 0)
+		mock.ExpectQuery("SELECT name, step FROM `migrations_progress`").WillReturnRows(rows)
+
+		assert.Nil(t, m.fetchProgress(db))
+		assert.Equal(t, map[string]int{"test": 2, "new": 0}, m.progress)
+	})
+
+	t.Run("it discards progress of executed migrations", func(t *testing.T) {
+		m := Migrator{executed: []migrationEntry{{id: 1, name: "done", batch: 1}}}
+		db, mock, resetDB := testDBConnection(t)
+		defer resetDB()
+
+		rows := sqlmock.NewRows([]string{"name", "step"}).AddRow("done", 3).AddRow("test", 1)
+		mock.ExpectQuery("SELECT name, step FROM `migrations_progress`").WillReturnRows(rows)
+		mock.ExpectExec("DELETE FROM `migrations_progress` WHERE name = \\?").WithArgs("done").WillReturnResult(sqlmock.NewResult(0, 1))
+
+		assert.Nil(t, m.fetchProgress(db))
+		assert.Equal(t, map[string]int{"test": 1}, m.progress)
+	})
+}
+
+func TestResume(t *testing.T) {
+	commands := []command{testDummyCommand("first"), testDummyCommand("second"), testDummyCommand("third")}
+	track := func(db executableSQL) error {
+		_, err := db.Exec("INSERT INTO `migrations`")
+		return err
+	}
+
+	t.Run("it stores progress after each command", func(t *testing.T) {
+		m := Migrator{}
+		db, mock, resetDB := testDBConnection(t)
+		defer resetDB()
+
+		mock.ExpectExec("INSERT INTO `migrations_progress` \\(name, step\\) VALUES \\(\\?, 0\\)").
+			WithArgs("test").WillReturnResult(sqlmock.NewResult(1, 1))
+		for i, c := range commands {
+			mock.ExpectExec(c.toSQL()).WillReturnResult(sqlmock.NewResult(0, 0))
+			mock.ExpectExec("UPDATE `migrations_progress` SET step = \\? WHERE name = \\?").
+				WithArgs(i+1, "test").WillReturnResult(sqlmock.NewResult(0, 1))
+		}
+		mock.ExpectExec("INSERT INTO `migrations`").WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectExec("DELETE FROM `migrations_progress` WHERE name = \\?").WithArgs("test").WillReturnResult(sqlmock.NewResult(0, 1))
+
+		assert.Nil(t, m.resume(db, Migration{Name: "test"}, commands, track))
+	})
+
+	t.Run("it stops on the failed command", func(t *testing.T) {
+		m := Migrator{}
+		db, mock, resetDB := testDBConnection(t)
+		defer resetDB()
+
+		mock.ExpectExec("INSERT INTO `migrations_progress`").WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectExec("first").WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec("UPDATE `migrations_progress`").WithArgs(1, "test").WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec("second").WillReturnError(errTestDBExecFailed)
+
+		assert.Equal(t, errTestDBExecFailed, m.resume(db, Migration{Name: "test"}, commands, track))
+	})
+
+	t.Run("it continues from the failed command", func(t *testing.T) {
+		m := Migrator{progress: map[string]int{"test": 1}}
+		db, mock, resetDB := testDBConnection(t)
+		defer resetDB()
+
+		mock.ExpectExec("second").WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec("UPDATE `migrations_progress`").WithArgs(2, "test").WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec("third").WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec("UPDATE `migrations_progress`").WithArgs(3, "test").WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec("INSERT INTO `migrations`").WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectExec("DELETE FROM `migrations_progress` WHERE name = \\?").WithArgs("test").WillReturnResult(sqlmock.NewResult(0, 1))
+
+		assert.Nil(t, m.resume(db, Migration{Name: "test"}, commands, track))
+	})
+
+	t.Run("it reverts completed commands before running migration again", func(t *testing.T) {
+		m := Migrator{RevertPartial: true, progress: map[string]int{"test": 2}}
+		db, mock, resetDB := testDBConnection(t)
+		defer resetDB()
+
+		item := Migration{Name: "test", Down: func() Schema {
+			return Schema{pool: []command{
+				testDummyCommand("undo third"),
+				testDummyCommand("undo second"),
+				testDummyCommand("undo first"),
+			}}
+		}}
+
+		mock.ExpectExec("undo second").WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec("undo first").WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec("UPDATE `migrations_progress` SET step = 0 WHERE name = \\?").
+			WithArgs("test").WillReturnResult(sqlmock.NewResult(0, 1))
+		for i, c := range commands {
+			mock.ExpectExec(c.toSQL()).WillReturnResult(sqlmock.NewResult(0, 0))
+			mock.ExpectExec("UPDATE `migrations_progress`").WithArgs(i+1, "test").WillReturnResult(sqlmock.NewResult(0, 1))
+		}
+		mock.ExpectExec("INSERT INTO `migrations`").WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectExec("DELETE FROM `migrations_progress` WHERE name = \\?").WithArgs("test").WillReturnResult(sqlmock.NewResult(0, 1))
+
+		assert.Nil(t, m.resume(db, item, commands, track))
+	})
+
+	t.Run("it keeps progress when migration can't be stored", func(t *testing.T) {
+		m := Migrator{progress: map[string]int{"test": 3}}
+		db, mock, resetDB := testDBConnection(t)
+		defer resetDB()
+
+		mock.ExpectExec("INSERT INTO `migrations`").WillReturnError(errTestDBExecFailed)
+
+		assert.Equal(t, errTestDBExecFailed, m.resume(db, Migration{Name: "test"}, commands, track))
+	})
+
+	t.Run("it refuses to revert when Down() doesn't mirror Up()", func(t *testing.T) {
+		m := Migrator{RevertPartial: true, progress: map[string]int{"test": 2}}
+		db, _, resetDB := testDBConnection(t)
+		defer resetDB()
+
+		item := Migration{Name: "test", Down: func() Schema {
+			return Schema{pool: []command{testDummyCommand("undo all")}}
+		}}
+
+		err := m.resume(db, item, commands, track)
+
+		assert.Error(t, err)
+		assert.Equal(t, `Migration "test" can't be partially reverted: Down() doesn't mirror Up()`, err.Error())
+	})
+
+	t.Run("it refuses to revert without Down(), that can't be derived", func(t *testing.T) {
+		m := Migrator{RevertPartial: true, progress: map[string]int{"test": 2}}
+		db, _, resetDB := testDBConnection(t)
+		defer resetDB()
+
+		item := Migration{Name: "test", Up: func() Schema {
+			return Schema{pool: commands}
+		}}
+
+		err := m.resume(db, item, commands, track)
+
+		assert.Error(t, err)
+		assert.Equal(t, `Migration "test" can't be reversed automatically: "third" is not reversible`, err.Error())
+	})
+}
+
+func TestMigrateResumable(t *testing.T) {
+	t.Run("it resumes partially applied migration and clears its progress", func(t *testing.T) {
+		migration := Migration{Name: "test", Up: func() Schema {
+			var s Schema
+			s.CreateTable(Table{Name: "test"})
+			s.DropTable("old", false, "")
+			return s
+		}}
+		m := Migrator{Pool: []Migration{migration}, Resumable: true}
+		db, mock, resetDB := testDBConnection(t)
+		defer resetDB()
+
+		rows := sqlmock.NewRows([]string{"id", "name", "batch", "applied_at"}).AddRow(1, "new", 1, time.Now())
+		progress := sqlmock.NewRows([]string{"name", "step"}).AddRow("test", 1)
+
+		mock.ExpectQuery("SELECT").WillReturnRows(sqlmock.NewRows(nil))
+		mock.ExpectQuery("SELECT id, name, batch, applied_at FROM `migrations`").WillReturnRows(rows)
+		mock.ExpectExec("CREATE TABLE IF NOT EXISTS `migrations_progress`").WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectQuery("SELECT name, step FROM `migrations_progress`").WillReturnRows(progress)
+		mock.ExpectExec("DROP TABLE `old`").WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec("UPDATE `migrations_progress`").WithArgs(2, "test").WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(`INSERT .* VALUES \("test", 2\)`).WillReturnResult(sqlmock.NewResult(2, 1))
+		mock.ExpectExec("DELETE FROM `migrations_progress` WHERE name = \\?").WithArgs("test").WillReturnResult(sqlmock.NewResult(0, 1))
+
+		migrated, err := m.Migrate(db)
+
+		assert.Nil(t, err)
+		assert.Equal(t, []string{"test"}, migrated)
+	})
+
+	t.Run("it fails when progress table creation failed", func(t *testing.T) {
+		m := Migrator{Pool: []Migration{{Name: "test"}}, Resumable: true}
+		db, mock, resetDB := testDBConnection(t)
+		defer resetDB()
+
+		mock.ExpectQuery("SELECT").WillReturnRows(sqlmock.NewRows(nil))
+		mock.ExpectQuery("SELECT id, name, batch, applied_at FROM `migrations`").WillReturnRows(sqlmock.NewRows([]string{"id"}))
+		mock.ExpectExec("CREATE TABLE IF NOT EXISTS `migrations_progress`").WillReturnError(errTestDBExecFailed)
+
+		migrated, err := m.Migrate(db)
+
+		assert.Len(t, migrated, 0)
+		assert.Error(t, err)
+		assert.Equal(t, "Progress table failed to be created: "+errTestDBExecFailed.Error(), err.Error())
+	})
+
+	t.Run("it refuses RevertPartial without Resumable", func(t *testing.T) {
+		m := Migrator{Pool: []Migration{{Name: "test"}}, RevertPartial: true}
+		db, _, resetDB := testDBConnection(t)
+		defer resetDB()
+
+		migrated, err := m.Migrate(db)
+
+		assert.Len(t, migrated, 0)
+		assert.Equal(t, errRevertPartialNotResumable, err)
+	})
+}
+
+func TestClearProgress(t *testing.T) {
+	migration := Migration{Name: "test", Up: func() Schema {
+		var s Schema
+		s.CreateTable(Table{Name: "test"})
+		return s
+	}}
+
+	t.Run("it clears progress of rolled back migration", func(t *testing.T) {
+		m := Migrator{Pool: []Migration{migration}, Resumable: true}
+		db, mock, resetDB := testDBConnection(t)
+		defer resetDB()
+
+		rows := sqlmock.NewRows([]string{"id", "name", "batch", "applied_at"}).AddRow(1, "test", 1, time.Now())
+
+		mock.ExpectQuery("SELECT").WillReturnRows(sqlmock.NewRows(nil))
+		mock.ExpectQuery("SELECT id, name, batch, applied_at FROM `migrations`").WillReturnRows(rows)
+		mock.ExpectExec("DROP TABLE IF EXISTS `test`").WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec("DELETE FROM `migrations` WHERE id = \\?").WithArgs(1).WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec("DELETE FROM `migrations_progress` WHERE name = \\?").WithArgs("test").WillReturnResult(sqlmock.NewResult(0, 1))
+
+		reverted, err := m.Rollback(db)
+
+		assert.Nil(t, err)
+		assert.Equal(t, []string{"test"}, reverted)
+	})
+
+	t.Run("it clears progress of migration marked as applied", func(t *testing.T) {
+		m := Migrator{Pool: []Migration{migration}, Resumable: true}
+		db, mock, resetDB := testDBConnection(t)
+		defer resetDB()
+
+		mock.ExpectQuery("SELECT").WillReturnRows(sqlmock.NewRows(nil))
+		mock.ExpectQuery("SELECT id, name, batch, applied_at FROM `migrations`").WillReturnRows(sqlmock.NewRows([]string{"id"}))
+		mock.ExpectExec(`INSERT .* VALUES \("test", 1\)`).WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectExec("DELETE FROM `migrations_progress` WHERE name = \\?").WithArgs("test").WillReturnResult(sqlmock.NewResult(0, 1))
+
+		assert.Nil(t, m.MarkApplied(db, "test"))
+	})
+
+	t.Run("it clears progress of migration marked as reverted", func(t *testing.T) {
+		m := Migrator{Resumable: true}
+		db, mock, resetDB := testDBConnection(t)
+		defer resetDB()
+
+		rows := sqlmock.NewRows([]string{"id", "name", "batch", "applied_at"}).AddRow(1, "test", 1, time.Now())
+
+		mock.ExpectQuery("SELECT").WillReturnRows(sqlmock.NewRows(nil))
+		mock.ExpectQuery("SELECT id, name, batch, applied_at FROM `migrations`").WillReturnRows(rows)
+		mock.ExpectExec("DELETE FROM `migrations` WHERE id = \\?").WithArgs(1).WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec("DELETE FROM `migrations_progress` WHERE name = \\?").WithArgs("test").WillReturnResult(sqlmock.NewResult(0, 1))
+
+		assert.Nil(t, m.MarkReverted(db, "test"))
+	})
+}
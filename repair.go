@@ -0,0 +1,128 @@
+package migrator
+
+import (
+	"database/sql"
+	"fmt"
+)
+
+// MarkApplied stores migration in migration table as executed without running its commands.
+// Migration is stored within a new batch, so it can be rolled back as usual.
+//
+// It might be useful to fix migration table state after manual intervention on the database.
+func (m Migrator) MarkApplied(db *sql.DB, name string) error {
+	if len(m.Pool) == 0 {
+		return ErrNoMigrationDefined
+	}
+
+	if err := m.checkMigrationPool(); err != nil {
+		return err
+	}
+
+	if !m.inPool(name) {
+		return fmt.Errorf(`Migration "%s" is not defined in the pool`, name)
+	}
+
+	if err := m.createMigrationTable(db); err != nil {
+		return fmt.Errorf("Migration table failed to be created: %v", err)
+	}
+
+	if err := m.fetchExecuted(db); err != nil {
+		return err
+	}
+
+	if m.isExecuted(name) {
+		return fmt.Errorf(`Migration "%s" is already applied`, name)
+	}
+
+	if err := m.insertEntry(db, name, m.batch()+1); err != nil {
+		return err
+	}
+
+	return m.clearProgress(db, name)
+}
+
+// MarkReverted removes migration from migration table without running its commands.
+//
+// Migration is not required to be in the pool, so entries of removed migrations can be cleaned too.
+func (m Migrator) MarkReverted(db *sql.DB, name string) error {
+	if !m.hasTable(db) {
+		return ErrTableNotExists
+	}
+
+	if err := m.fetchExecuted(db); err != nil {
+		return err
+	}
+
+	if !m.isExecuted(name) {
+		return fmt.Errorf(`Migration "%s" is not applied`, name)
+	}
+
+	for _, item := range m.executed {
+		if item.name != name {
+			continue
+		}
+
+		if err := m.deleteEntry(db, item.id); err != nil {
+			return err
+		}
+	}
+
+	return m.clearProgress(db, name)
+}
+
+// Repair brings migration table in line with the migrations pool.
+// It removes orphan entries, that have no migration in the pool,
+// and duplicated entries of the same migration, keeping the earliest one.
+//
+// Returns the list of migration names, which entries were removed.
+func (m Migrator) Repair(db *sql.DB) (removed []string, err error) {
+	if len(m.Pool) == 0 {
+		return removed, ErrNoMigrationDefined
+	}
+
+	if err := m.checkMigrationPool(); err != nil {
+		return removed, err
+	}
+
+	if !m.hasTable(db) {
+		return removed, ErrTableNotExists
+	}
+
+	if err := m.fetchExecuted(db); err != nil {
+		return removed, err
+	}
+
+	seen := map[string]bool{}
+
+	for _, item := range m.executed {
+		if m.inPool(item.name) && !seen[item.name] {
+			seen[item.name] = true
+			continue
+		}
+
+		if err := m.deleteEntry(db, item.id); err != nil {
+			return removed, err
+		}
+
+		removed = append(removed, item.name)
+	}
+
+	return removed, nil
+}
+
+// inPool reports whether migration is defined in the pool or replaced by one of its migrations
+func (m Migrator) inPool(name string) bool {
+	for _, item := range m.Pool {
+		if item.Name == name {
+			return true
+		}
+
+		for _, replaced := range item.Replaces {
+			if replaced == name {
+				return true
+			}
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,184 @@
+package migrator
+
+import (
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMarkApplied(t *testing.T) {
+	t.Run("it fails when migration pool is empty", func(t *testing.T) {
+		m := Migrator{}
+		db, _, resetDB := testDBConnection(t)
+		defer resetDB()
+
+		err := m.MarkApplied(db, "test")
+
+		assert.Error(t, err)
+		assert.Equal(t, ErrNoMigrationDefined, err)
+	})
+
+	t.Run("it fails when migration is not in the pool", func(t *testing.T) {
+		m := Migrator{Pool: []Migration{{Name: "test"}}}
+		db, _, resetDB := testDBConnection(t)
+		defer resetDB()
+
+		err := m.MarkApplied(db, "unknown")
+
+		assert.Error(t, err)
+		assert.Equal(t, `Migration "unknown" is not defined in the pool`, err.Error())
+	})
+
+	t.Run("it fails when migration is already applied", func(t *testing.T) {
+		m := Migrator{Pool: []Migration{{Name: "test"}}}
+		db, mock, resetDB := testDBConnection(t)
+		defer resetDB()
+
+		rows := sqlmock.NewRows([]string{"id", "name", "batch", "applied_at"}).AddRow(1, "test", 1, time.Now())
+
+		mock.ExpectQuery("SELECT").WillReturnRows(sqlmock.NewRows(nil))
+		mock.ExpectQuery("SELECT id, name, batch, applied_at FROM `migrations`").WillReturnRows(rows)
+
+		err := m.MarkApplied(db, "test")
+
+		assert.Error(t, err)
+		assert.Equal(t, `Migration "test" is already applied`, err.Error())
+	})
+
+	t.Run("it stores migration within a new batch", func(t *testing.T) {
+		m := Migrator{Pool: []Migration{{Name: "test"}, {Name: "new"}}}
+		db, mock, resetDB := testDBConnection(t)
+		defer resetDB()
+
+		rows := sqlmock.NewRows([]string{"id", "name", "batch", "applied_at"}).AddRow(1, "test", 2, time.Now())
+
+		mock.ExpectQuery("SELECT").WillReturnRows(sqlmock.NewRows(nil))
+		mock.ExpectQuery("SELECT id, name, batch, applied_at FROM `migrations`").WillReturnRows(rows)
+		mock.ExpectExec(`INSERT .* VALUES \("new", 3\)`).WillReturnResult(sqlmock.NewResult(2, 1))
+
+		err := m.MarkApplied(db, "new")
+
+		assert.Nil(t, err)
+	})
+}
+
+func TestMarkReverted(t *testing.T) {
+	t.Run("it fails when migration table missing", func(t *testing.T) {
+		m := Migrator{}
+		db, mock, resetDB := testDBConnection(t)
+		defer resetDB()
+
+		mock.ExpectQuery("SELECT").WillReturnError(errTestDBQueryFailed)
+
+		err := m.MarkReverted(db, "test")
+
+		assert.Error(t, err)
+		assert.Equal(t, ErrTableNotExists, err)
+	})
+
+	t.Run("it fails when migration is not applied", func(t *testing.T) {
+		m := Migrator{}
+		db, mock, resetDB := testDBConnection(t)
+		defer resetDB()
+
+		rows := sqlmock.NewRows([]string{"id", "name", "batch", "applied_at"}).AddRow(1, "test", 1, time.Now())
+
+		mock.ExpectQuery("SELECT").WillReturnRows(sqlmock.NewRows(nil))
+		mock.ExpectQuery("SELECT id, name, batch, applied_at FROM `migrations`").WillReturnRows(rows)
+
+		err := m.MarkReverted(db, "new")
+
+		assert.Error(t, err)
+		assert.Equal(t, `Migration "new" is not applied`, err.Error())
+	})
+
+	t.Run("it removes all entries of the migration", func(t *testing.T) {
+		m := Migrator{}
+		db, mock, resetDB := testDBConnection(t)
+		defer resetDB()
+
+		rows := sqlmock.NewRows([]string{"id", "name", "batch", "applied_at"}).
+			AddRow(1, "test", 1, time.Now()).
+			AddRow(2, "new", 1, time.Now()).
+			AddRow(3, "test", 2, time.Now())
+
+		mock.ExpectQuery("SELECT").WillReturnRows(sqlmock.NewRows(nil))
+		mock.ExpectQuery("SELECT id, name, batch, applied_at FROM `migrations`").WillReturnRows(rows)
+		mock.ExpectExec("DELETE FROM `migrations` WHERE id = ?").WithArgs(1).WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec("DELETE FROM `migrations` WHERE id = ?").WithArgs(3).WillReturnResult(sqlmock.NewResult(0, 1))
+
+		err := m.MarkReverted(db, "test")
+
+		assert.Nil(t, err)
+	})
+}
+
+func TestRepair(t *testing.T) {
+	t.Run("it fails when migration pool is empty", func(t *testing.T) {
+		m := Migrator{}
+		db, _, resetDB := testDBConnection(t)
+		defer resetDB()
+
+		removed, err := m.Repair(db)
+
+		assert.Len(t, removed, 0)
+		assert.Error(t, err)
+		assert.Equal(t, ErrNoMigrationDefined, err)
+	})
+
+	t.Run("it fails when migration table missing", func(t *testing.T) {
+		m := Migrator{Pool: []Migration{{Name: "test"}}}
+		db, mock, resetDB := testDBConnection(t)
+		defer resetDB()
+
+		mock.ExpectQuery("SELECT").WillReturnError(errTestDBQueryFailed)
+
+		removed, err := m.Repair(db)
+
+		assert.Len(t, removed, 0)
+		assert.Error(t, err)
+		assert.Equal(t, ErrTableNotExists, err)
+	})
+
+	t.Run("it fails while removing an entry", func(t *testing.T) {
+		m := Migrator{Pool: []Migration{{Name: "test"}}}
+		db, mock, resetDB := testDBConnection(t)
+		defer resetDB()
+
+		rows := sqlmock.NewRows([]string{"id", "name", "batch", "applied_at"}).AddRow(1, "orphan", 1, time.Now())
+
+		mock.ExpectQuery("SELECT").WillReturnRows(sqlmock.NewRows(nil))
+		mock.ExpectQuery("SELECT id, name, batch, applied_at FROM `migrations`").WillReturnRows(rows)
+		mock.ExpectExec("DELETE FROM `migrations` WHERE id = ?").WithArgs(1).WillReturnError(errTestDBExecFailed)
+
+		removed, err := m.Repair(db)
+
+		assert.Len(t, removed, 0)
+		assert.Error(t, err)
+		assert.Equal(t, errTestDBExecFailed, err)
+	})
+
+	t.Run("it removes orphan and duplicated entries", func(t *testing.T) {
+		m := Migrator{Pool: []Migration{{Name: "test"}, {Name: "new"}}}
+		db, mock, resetDB := testDBConnection(t)
+		defer resetDB()
+
+		rows := sqlmock.NewRows([]string{"id", "name", "batch", "applied_at"}).
+			AddRow(1, "test", 1, time.Now()).
+			AddRow(2, "orphan", 1, time.Now()).
+			AddRow(3, "new", 2, time.Now()).
+			AddRow(4, "test", 2, time.Now())
+
+		mock.ExpectQuery("SELECT").WillReturnRows(sqlmock.NewRows(nil))
+		mock.ExpectQuery("SELECT id, name, batch, applied_at FROM `migrations`").WillReturnRows(rows)
+		mock.ExpectExec("DELETE FROM `migrations` WHERE id = ?").WithArgs(2).WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec("DELETE FROM `migrations` WHERE id = ?").WithArgs(4).WillReturnResult(sqlmock.NewResult(0, 1))
+
+		removed, err := m.Repair(db)
+
+		assert.Nil(t, err)
+		assert.Equal(t, []string{"orphan", "test"}, removed)
+	})
+}
@@ -0,0 +1,166 @@
+package migrator
+
+import (
+	"database/sql/driver"
+	"errors"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// RetryPolicy describes how migrations are retried on transient errors.
+//
+// Transactional migrations are retried as a whole, as nothing is applied after rollback.
+// Commands of non-transactional migration are retried one by one,
+// when command is idempotent or database reports that it wasn't applied (lock wait timeout or deadlock).
+//
+// - MaxAttempts	total amount of attempts, retries are disabled when it is less than 2
+// - Backoff		delay before the second attempt, it is doubled for every next attempt
+// - Retryable		classifier of retryable errors, default: IsTransientError
+//
+// Example:
+//		m := migrator.Migrator{
+//			Pool:  migrations,
+//			Retry: migrator.RetryPolicy{MaxAttempts: 3, Backoff: time.Second},
+//		}
+type RetryPolicy struct {
+	MaxAttempts int
+	Backoff     time.Duration
+	Retryable   func(error) bool
+}
+
+var sleep = time.Sleep
+
+var mysqlErrorCode = regexp.MustCompile(`Error (\d+)`)
+
+// MySQL error codes, when statement was rolled back and can be safely executed again
+var lockErrorCodes = list{
+	"1205", // ER_LOCK_WAIT_TIMEOUT
+	"1213", // ER_LOCK_DEADLOCK
+}
+
+// MySQL client error codes, when connection to the server was lost
+var connectionErrorCodes = list{
+	"2006", // CR_SERVER_GONE_ERROR
+	"2013", // CR_SERVER_LOST
+}
+
+// IsTransientError reports whether error is caused by lock wait timeout, deadlock or lost connection,
+// so running the same command again might be successful.
+func IsTransientError(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	return isLockError(err) || isConnectionError(err)
+}
+
+func isConnectionError(err error) bool {
+	if errors.Is(err, driver.ErrBadConn) {
+		return true
+	}
+
+	if match := mysqlErrorCode.FindStringSubmatch(err.Error()); match != nil && connectionErrorCodes.has(match[1]) {
+		return true
+	}
+
+	return strings.Contains(err.Error(), "invalid connection")
+}
+
+func isLockError(err error) bool {
+	match := mysqlErrorCode.FindStringSubmatch(err.Error())
+
+	return match != nil && lockErrorCodes.has(match[1])
+}
+
+func (p RetryPolicy) retryable(err error) bool {
+	if p.Retryable != nil {
+		return p.Retryable(err)
+	}
+
+	return IsTransientError(err)
+}
+
+// retryableOn classifies errors of commands running on db. Dedicated connection of migration with session
+// variables can't be used again after it was lost, so errors of lost connection are not retried there.
+func (p RetryPolicy) retryableOn(db executableSQL) func(error) bool {
+	if _, ok := db.(connection); !ok {
+		return p.retryable
+	}
+
+	return func(err error) bool {
+		return p.retryable(err) && !isConnectionError(err)
+	}
+}
+
+// do calls fn until it succeeds, error is not retryable or attempts are exhausted.
+func (p RetryPolicy) do(fn func() error, retryable func(error) bool) error {
+	delay := p.Backoff
+
+	for attempt := 1; ; attempt++ {
+		err := fn()
+		if err == nil || attempt >= p.MaxAttempts || !retryable(err) {
+			return err
+		}
+
+		sleep(delay)
+		delay *= 2
+	}
+}
+
+// commitError is returned, when transaction fails to be committed
+type commitError struct {
+	err error
+}
+
+func (e commitError) Error() string {
+	return e.err.Error()
+}
+
+// transaction calls fn, that runs a transaction, until it succeeds like do. Transaction might have been committed
+// by the server before the connection was lost, so failed COMMIT is retried on lock errors only.
+func (p RetryPolicy) transaction(fn func() error, retryable func(error) bool) error {
+	err := p.do(fn, func(err error) bool {
+		if c, ok := err.(commitError); ok {
+			return isLockError(c.err) && retryable(c.err)
+		}
+
+		return retryable(err)
+	})
+
+	if c, ok := err.(commitError); ok {
+		return c.err
+	}
+
+	return err
+}
+
+// run executes commands one by one and retries every single command if it is safe.
+func (p RetryPolicy) run(db executableSQL, commands ...command) error {
+	retryableOn := p.retryableOn(db)
+
+	for _, c := range commands {
+		c := c
+		retryable := func(err error) bool {
+			return retryableOn(err) && (isIdempotent(c) || isLockError(err))
+		}
+
+		if err := p.do(func() error { return run(db, c) }, retryable); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// isIdempotent reports whether command can be executed again after it was applied.
+func isIdempotent(c command) bool {
+	switch v := c.(type) {
+	case dropTableCommand:
+		return v.soft
+	case rendered:
+		return isIdempotent(v.command)
+	}
+
+	return false
+}
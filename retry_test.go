@@ -0,0 +1,288 @@
+package migrator
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	errTestLockWaitTimeout = errors.New("Error 1205: Lock wait timeout exceeded; try restarting transaction")
+	errTestDeadlock        = errors.New("Error 1213 (40001): Deadlock found when trying to get lock; try restarting transaction")
+	errTestInvalidConn     = errors.New("invalid connection")
+)
+
+func TestIsTransientError(t *testing.T) {
+	t.Run("it returns false for nil error", func(t *testing.T) {
+		assert.False(t, IsTransientError(nil))
+	})
+
+	t.Run("it returns true for lock errors", func(t *testing.T) {
+		assert.True(t, IsTransientError(errTestLockWaitTimeout))
+		assert.True(t, IsTransientError(errTestDeadlock))
+		assert.True(t, IsTransientError(fmt.Errorf("migration failed: %w", errTestDeadlock)))
+	})
+
+	t.Run("it returns true for lost connection", func(t *testing.T) {
+		assert.True(t, IsTransientError(driver.ErrBadConn))
+		assert.True(t, IsTransientError(errors.New("invalid connection")))
+		assert.True(t, IsTransientError(errors.New("Error 2006: MySQL server has gone away")))
+		assert.True(t, IsTransientError(errors.New("Error 2013: Lost connection to MySQL server during query")))
+	})
+
+	t.Run("it returns false for other errors", func(t *testing.T) {
+		assert.False(t, IsTransientError(errTestDBExecFailed))
+		assert.False(t, IsTransientError(errors.New("Error 1050: Table 'posts' already exists")))
+	})
+}
+
+func TestRetryPolicyDo(t *testing.T) {
+	defer func(s func(time.Duration)) { sleep = s }(sleep)
+	var delays []time.Duration
+	sleep = func(d time.Duration) { delays = append(delays, d) }
+
+	t.Run("it doesn't retry by default", func(t *testing.T) {
+		calls := 0
+		err := RetryPolicy{}.do(func() error {
+			calls++
+			return errTestDeadlock
+		}, IsTransientError)
+
+		assert.Equal(t, errTestDeadlock, err)
+		assert.Equal(t, 1, calls)
+	})
+
+	t.Run("it doesn't retry non-retryable errors", func(t *testing.T) {
+		calls := 0
+		err := RetryPolicy{MaxAttempts: 3}.do(func() error {
+			calls++
+			return errTestDBExecFailed
+		}, IsTransientError)
+
+		assert.Equal(t, errTestDBExecFailed, err)
+		assert.Equal(t, 1, calls)
+	})
+
+	t.Run("it retries with exponential backoff until attempts are exhausted", func(t *testing.T) {
+		delays = nil
+		calls := 0
+		err := RetryPolicy{MaxAttempts: 3, Backoff: time.Second}.do(func() error {
+			calls++
+			return errTestLockWaitTimeout
+		}, IsTransientError)
+
+		assert.Equal(t, errTestLockWaitTimeout, err)
+		assert.Equal(t, 3, calls)
+		assert.Equal(t, []time.Duration{time.Second, 2 * time.Second}, delays)
+	})
+
+	t.Run("it stops retrying on success", func(t *testing.T) {
+		calls := 0
+		err := RetryPolicy{MaxAttempts: 5}.do(func() error {
+			calls++
+			if calls < 2 {
+				return errTestDeadlock
+			}
+			return nil
+		}, IsTransientError)
+
+		assert.Nil(t, err)
+		assert.Equal(t, 2, calls)
+	})
+}
+
+func TestRetryPolicyRetryable(t *testing.T) {
+	t.Run("it uses transient errors classifier by default", func(t *testing.T) {
+		assert.True(t, RetryPolicy{}.retryable(errTestDeadlock))
+	})
+
+	t.Run("it uses custom classifier", func(t *testing.T) {
+		p := RetryPolicy{Retryable: func(err error) bool { return err == errTestDBExecFailed }}
+
+		assert.True(t, p.retryable(errTestDBExecFailed))
+		assert.False(t, p.retryable(errTestDeadlock))
+	})
+}
+
+func TestRetryPolicyRun(t *testing.T) {
+	p := RetryPolicy{MaxAttempts: 2}
+
+	t.Run("it retries command rolled back by the database", func(t *testing.T) {
+		db, mock, resetDB := testDBConnection(t)
+		defer resetDB()
+
+		mock.ExpectExec("ALTER").WillReturnError(errTestLockWaitTimeout)
+		mock.ExpectExec("ALTER").WillReturnResult(sqlmock.NewResult(0, 0))
+
+		assert.Nil(t, p.run(db, testDummyCommand("ALTER TABLE posts")))
+	})
+
+	t.Run("it retries idempotent command on lost connection", func(t *testing.T) {
+		db, mock, resetDB := testDBConnection(t)
+		defer resetDB()
+
+		mock.ExpectExec("DROP TABLE IF EXISTS").WillReturnError(errors.New("invalid connection"))
+		mock.ExpectExec("DROP TABLE IF EXISTS").WillReturnResult(sqlmock.NewResult(0, 0))
+
+		assert.Nil(t, p.run(db, dropTableCommand{"posts", true, ""}))
+	})
+
+	t.Run("it doesn't retry non-idempotent command on lost connection", func(t *testing.T) {
+		db, mock, resetDB := testDBConnection(t)
+		defer resetDB()
+
+		err := errors.New("invalid connection")
+		mock.ExpectExec("DROP TABLE").WillReturnError(err)
+
+		assert.Equal(t, err, p.run(db, dropTableCommand{"posts", false, ""}))
+	})
+
+	t.Run("it doesn't retry on lost dedicated connection", func(t *testing.T) {
+		db, mock, resetDB := testDBConnection(t)
+		defer resetDB()
+
+		conn, _ := db.Conn(context.Background())
+		defer conn.Close()
+
+		err := errors.New("invalid connection")
+		mock.ExpectExec("DROP TABLE IF EXISTS").WillReturnError(err)
+
+		assert.Equal(t, err, p.run(connection{conn}, dropTableCommand{"posts", true, ""}))
+	})
+
+	t.Run("it retries lock errors on dedicated connection", func(t *testing.T) {
+		db, mock, resetDB := testDBConnection(t)
+		defer resetDB()
+
+		conn, _ := db.Conn(context.Background())
+		defer conn.Close()
+
+		mock.ExpectExec("ALTER").WillReturnError(errTestLockWaitTimeout)
+		mock.ExpectExec("ALTER").WillReturnResult(sqlmock.NewResult(0, 0))
+
+		assert.Nil(t, p.run(connection{conn}, testDummyCommand("ALTER TABLE posts")))
+	})
+}
+
+func TestMigrateWithRetry(t *testing.T) {
+	t.Run("it retries transactional migration as a whole", func(t *testing.T) {
+		migration := Migration{Name: "test", Transaction: true, Up: func() Schema {
+			var s Schema
+			s.CustomCommand(testDummyCommand("UPDATE test SET active = 1"))
+			return s
+		}}
+		m := Migrator{Pool: []Migration{migration}, Retry: RetryPolicy{MaxAttempts: 2}}
+		db, mock, resetDB := testDBConnection(t)
+		defer resetDB()
+
+		rows := sqlmock.NewRows([]string{"id", "name", "batch", "applied_at"})
+
+		mock.ExpectQuery("SELECT").WillReturnRows(sqlmock.NewRows(nil))
+		mock.ExpectQuery("SELECT id, name, batch, applied_at FROM `migrations`").WillReturnRows(rows)
+		mock.ExpectBegin()
+		mock.ExpectExec("UPDATE test").WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec("INSERT").WillReturnError(errTestDeadlock)
+		mock.ExpectRollback()
+		mock.ExpectBegin()
+		mock.ExpectExec("UPDATE test").WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(`INSERT .* VALUES \("test", 1\)`).WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectCommit()
+
+		migrated, err := m.Migrate(db)
+
+		assert.Nil(t, err)
+		assert.Equal(t, []string{"test"}, migrated)
+	})
+
+	t.Run("it doesn't retry transactional migration, when connection is lost on commit", func(t *testing.T) {
+		migration := Migration{Name: "test", Transaction: true, Up: func() Schema {
+			var s Schema
+			s.CustomCommand(testDummyCommand("UPDATE test SET active = 1"))
+			return s
+		}}
+		m := Migrator{Pool: []Migration{migration}, Retry: RetryPolicy{MaxAttempts: 3}}
+		db, mock, resetDB := testDBConnection(t)
+		defer resetDB()
+
+		rows := sqlmock.NewRows([]string{"id", "name", "batch", "applied_at"})
+
+		mock.ExpectQuery("SELECT").WillReturnRows(sqlmock.NewRows(nil))
+		mock.ExpectQuery("SELECT id, name, batch, applied_at FROM `migrations`").WillReturnRows(rows)
+		mock.ExpectBegin()
+		mock.ExpectExec("UPDATE test").WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec("INSERT").WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectCommit().WillReturnError(errTestInvalidConn)
+
+		migrated, err := m.Migrate(db)
+
+		assert.Len(t, migrated, 0)
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), errTestInvalidConn.Error())
+	})
+
+	t.Run("it retries transactional migration, when commit fails on lock", func(t *testing.T) {
+		migration := Migration{Name: "test", Transaction: true, Up: func() Schema {
+			var s Schema
+			s.CustomCommand(testDummyCommand("UPDATE test SET active = 1"))
+			return s
+		}}
+		m := Migrator{Pool: []Migration{migration}, Retry: RetryPolicy{MaxAttempts: 2}}
+		db, mock, resetDB := testDBConnection(t)
+		defer resetDB()
+
+		rows := sqlmock.NewRows([]string{"id", "name", "batch", "applied_at"})
+
+		mock.ExpectQuery("SELECT").WillReturnRows(sqlmock.NewRows(nil))
+		mock.ExpectQuery("SELECT id, name, batch, applied_at FROM `migrations`").WillReturnRows(rows)
+		mock.ExpectBegin()
+		mock.ExpectExec("UPDATE test").WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec("INSERT").WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectCommit().WillReturnError(errTestDeadlock)
+		mock.ExpectBegin()
+		mock.ExpectExec("UPDATE test").WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec("INSERT").WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectCommit()
+
+		migrated, err := m.Migrate(db)
+
+		assert.Nil(t, err)
+		assert.Equal(t, []string{"test"}, migrated)
+	})
+
+	t.Run("it doesn't retry atomic batch, when connection is lost on commit", func(t *testing.T) {
+		migration := Migration{Name: "test", Up: func() Schema {
+			var s Schema
+			s.CustomCommand(testDummyCommand("UPDATE test SET active = 1"))
+			return s
+		}}
+		m := Migrator{Atomic: true, Pool: []Migration{migration}, Retry: RetryPolicy{MaxAttempts: 3}}
+		db, mock, resetDB := testDBConnection(t)
+		defer resetDB()
+
+		rows := sqlmock.NewRows([]string{"id", "name", "batch", "applied_at"})
+
+		mock.ExpectQuery("SELECT").WillReturnRows(sqlmock.NewRows(nil))
+		mock.ExpectQuery("SELECT id, name, batch, applied_at FROM `migrations`").WillReturnRows(rows)
+		mock.ExpectBegin()
+		mock.ExpectExec("UPDATE test").WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec("INSERT").WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectCommit().WillReturnError(errTestInvalidConn)
+
+		migrated, err := m.Migrate(db)
+
+		assert.Len(t, migrated, 0)
+		assert.Equal(t, errTestInvalidConn, err)
+	})
+}
+
+func TestIsIdempotent(t *testing.T) {
+	assert.True(t, isIdempotent(dropTableCommand{"test", true, ""}))
+	assert.False(t, isIdempotent(dropTableCommand{"test", false, ""}))
+	assert.False(t, isIdempotent(createTableCommand{}))
+}
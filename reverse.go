@@ -0,0 +1,79 @@
+package migrator
+
+import "fmt"
+
+// DownSchema returns Down() schema of the migration, or the one derived from Up() schema, if Down() is not set.
+// Error is returned, when Down() is not set and Up() can't be reversed.
+func (m Migration) DownSchema() (Schema, error) {
+	return m.down()
+}
+
+// down returns Down() schema of the migration, or derives it from Up() schema, if Down() is not set.
+func (m Migration) down() (Schema, error) {
+	if m.Down != nil {
+		return m.Down(), nil
+	}
+
+	if m.Up == nil {
+		return Schema{}, fmt.Errorf(`Migration "%s" has neither Down() nor Up() to be reversed`, m.Name)
+	}
+
+	s, err := reverseSchema(m.Up())
+	if err != nil {
+		return Schema{}, fmt.Errorf(`Migration "%s" can't be reversed automatically: %v`, m.Name, err)
+	}
+
+	return s, nil
+}
+
+// reverseSchema reverts every command of the schema in reverse order
+func reverseSchema(up Schema) (s Schema, err error) {
+	for i := len(up.pool) - 1; i >= 0; i-- {
+		c, err := reverse(up.pool[i])
+		if err != nil {
+			return Schema{}, err
+		}
+
+		s.pool = append(s.pool, c)
+	}
+
+	return s, nil
+}
+
+// reverse returns a command to revert the given one.
+// Table commands of AlterTable are reverted in reverse order.
+func reverse(c command) (command, error) {
+	switch v := c.(type) {
+	case createTableCommand:
+		return dropTableCommand{table: v.t.Name, soft: true}, nil
+	case renameTableCommand:
+		return renameTableCommand{old: v.new, new: v.old}, nil
+	case alterTableCommand:
+		var pool TableCommands
+
+		for i := len(v.pool) - 1; i >= 0; i-- {
+			tc, err := reverse(v.pool[i])
+			if err != nil {
+				return nil, err
+			}
+
+			pool = append(pool, tc)
+		}
+
+		return alterTableCommand{name: v.name, pool: pool}, nil
+	case AddColumnCommand:
+		return DropColumnCommand(v.Name), nil
+	case AddIndexCommand:
+		return DropIndexCommand(v.Name), nil
+	case AddUniqueIndexCommand:
+		return DropIndexCommand(v.Key), nil
+	case AddForeignCommand:
+		return DropForeignCommand(v.Foreign.Key), nil
+	case AddPrimaryIndexCommand, AddCompositePrimaryIndexCommand:
+		return DropPrimaryIndexCommand{}, nil
+	case RenameColumnCommand:
+		return RenameColumnCommand{Old: v.New, New: v.Old}, nil
+	}
+
+	return nil, fmt.Errorf(`"%s" is not reversible`, c.toSQL())
+}
@@ -0,0 +1,120 @@
+package migrator
+
+import (
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReverse(t *testing.T) {
+	tests := []struct {
+		name     string
+		command  command
+		expected command
+	}{
+		{"create table", createTableCommand{Table{Name: "posts"}}, dropTableCommand{table: "posts", soft: true}},
+		{"rename table", renameTableCommand{old: "posts", new: "articles"}, renameTableCommand{old: "articles", new: "posts"}},
+		{"add column", AddColumnCommand{Name: "rating", Column: Integer{}}, DropColumnCommand("rating")},
+		{"add index", AddIndexCommand{Name: "idx", Columns: []string{"a"}}, DropIndexCommand("idx")},
+		{"add unique index", AddUniqueIndexCommand{Key: "idx", Columns: []string{"a"}}, DropIndexCommand("idx")},
+		{"add foreign key", AddForeignCommand{Foreign{Key: "fk"}}, DropForeignCommand("fk")},
+		{"add primary key", AddPrimaryIndexCommand("id"), DropPrimaryIndexCommand{}},
+		{"add composite primary key", AddCompositePrimaryIndexCommand{Columns: []string{"post_id", "tag_id"}}, DropPrimaryIndexCommand{}},
+		{"rename column", RenameColumnCommand{Old: "a", New: "b"}, RenameColumnCommand{Old: "b", New: "a"}},
+		{
+			"alter table",
+			alterTableCommand{name: "posts", pool: TableCommands{AddColumnCommand{Name: "a", Column: Integer{}}, AddIndexCommand{Name: "idx", Columns: []string{"a"}}}, options: AlterOptions{Algorithm: "INSTANT"}},
+			alterTableCommand{name: "posts", pool: TableCommands{DropIndexCommand("idx"), DropColumnCommand("a")}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := reverse(tt.command)
+
+			assert.Nil(t, err)
+			assert.Equal(t, tt.expected, c)
+		})
+	}
+
+	t.Run("it fails on irreversible command", func(t *testing.T) {
+		_, err := reverse(alterTableCommand{name: "posts", pool: TableCommands{AddIndexCommand{Name: "idx", Columns: []string{"a"}}, DropColumnCommand("title")}})
+
+		assert.EqualError(t, err, "\"DROP COLUMN `title`\" is not reversible")
+	})
+}
+
+func TestMigrationDown(t *testing.T) {
+	t.Run("it uses Down() if set", func(t *testing.T) {
+		m := Migration{Name: "test", Down: func() Schema {
+			var s Schema
+			s.CustomCommand(testDummyCommand("undo"))
+			return s
+		}}
+
+		s, err := m.down()
+
+		assert.Nil(t, err)
+		assert.Equal(t, Schema{pool: []command{testDummyCommand("undo")}}, s)
+	})
+
+	t.Run("it derives Down() from Up() in reverse order", func(t *testing.T) {
+		m := Migration{Name: "test", Up: func() Schema {
+			var s Schema
+			s.CreateTable(Table{Name: "posts"})
+			s.RenameTable("users", "authors")
+			return s
+		}}
+
+		s, err := m.down()
+
+		assert.Nil(t, err)
+		assert.Equal(t, Schema{pool: []command{
+			renameTableCommand{old: "authors", new: "users"},
+			dropTableCommand{table: "posts", soft: true},
+		}}, s)
+	})
+
+	t.Run("it fails when Up() is not reversible", func(t *testing.T) {
+		m := Migration{Name: "test", Up: func() Schema {
+			var s Schema
+			s.DropTableIfExists("posts")
+			return s
+		}}
+
+		_, err := m.down()
+
+		assert.EqualError(t, err, "Migration \"test\" can't be reversed automatically: \"DROP TABLE IF EXISTS `posts`\" is not reversible")
+	})
+
+	t.Run("it fails without Up() and Down()", func(t *testing.T) {
+		_, err := Migration{Name: "test"}.down()
+
+		assert.EqualError(t, err, `Migration "test" has neither Down() nor Up() to be reversed`)
+	})
+}
+
+func TestRollbackDerivedDown(t *testing.T) {
+	migration := Migration{Name: "test", Up: func() Schema {
+		var s Schema
+		s.AlterTable("posts", TableCommands{AddColumnCommand{Name: "rating", Column: Integer{}}})
+		return s
+	}}
+	m := Migrator{Pool: []Migration{migration}}
+	db, mock, resetDB := testDBConnection(t)
+	defer resetDB()
+
+	rows := sqlmock.NewRows([]string{"id", "name", "batch", "applied_at"}).AddRow(1, "test", 1, time.Now())
+
+	mock.ExpectQuery("SELECT").WillReturnRows(sqlmock.NewRows(nil))
+	mock.ExpectQuery("SELECT id, name, batch, applied_at FROM `migrations`").WillReturnRows(rows)
+	mock.ExpectExec("ALTER TABLE `posts` DROP COLUMN `rating`").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("DELETE FROM `migrations` WHERE id = ?").WithArgs(1).WillReturnResult(sqlmock.NewResult(0, 1))
+
+	reverted, err := m.Rollback(db)
+
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"test"}, reverted)
+}
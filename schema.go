@@ -6,6 +6,34 @@ type Schema struct {
 	pool []command
 }
 
+type validator interface {
+	validate() error
+}
+
+// validate checks commands in the pool, that are able to detect invalid definition before execution.
+func (s Schema) validate() error {
+	for _, c := range s.pool {
+		if v, ok := c.(validator); ok {
+			if err := v.validate(); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// Statements renders SQL of every command in the schema, e.g. to review or snapshot the schema.
+func (s Schema) Statements() []string {
+	var statements []string
+
+	for _, c := range s.pool {
+		statements = append(statements, c.toSQL())
+	}
+
+	return statements
+}
+
 // CreateTable allows creating the table in the schema.
 //
 // Example:
@@ -51,13 +79,44 @@ func (s *Schema) RenameTable(old string, new string) {
 }
 
 // AlterTable makes changes on the table level.
+// Optional AlterOptions define the algorithm and the lock level for online DDL.
 //
 // Example:
 //		var s migrator.Schema
 //		var c TableCommands
 //		s.AlterTable("test", c)
-func (s *Schema) AlterTable(name string, c TableCommands) {
-	s.pool = append(s.pool, alterTableCommand{name, c})
+//
+// Non-blocking change
+//		s.AlterTable("test", c, migrator.AlterOptions{Algorithm: "INPLACE", Lock: "NONE"})
+func (s *Schema) AlterTable(name string, c TableCommands, options ...AlterOptions) {
+	command := alterTableCommand{name: name, pool: c}
+
+	for _, o := range options {
+		if o.Algorithm != "" {
+			command.options.Algorithm = o.Algorithm
+		}
+		if o.Lock != "" {
+			command.options.Lock = o.Lock
+		}
+	}
+
+	s.pool = append(s.pool, command)
+}
+
+// Backfill updates existing rows of the table in chunks by primary key.
+// Every chunk is updated within its own transaction, unless migration is transactional itself.
+//
+// Example:
+//		var s migrator.Schema
+//		s.Backfill(migrator.BackfillCommand{
+//			Table:     "comments",
+//			Set:       "rating = 0",
+//			Where:     "rating IS NULL",
+//			ChunkSize: 5000,
+//			Sleep:     100 * time.Millisecond,
+//		})
+func (s *Schema) Backfill(c BackfillCommand) {
+	s.pool = append(s.pool, c)
 }
 
 // CustomCommand allows adding the custom command to the Schema.
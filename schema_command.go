@@ -93,8 +93,9 @@ func (c renameTableCommand) toSQL() string {
 }
 
 type alterTableCommand struct {
-	name string
-	pool TableCommands
+	name    string
+	pool    TableCommands
+	options AlterOptions
 }
 
 func (c alterTableCommand) toSQL() string {
@@ -102,7 +103,91 @@ func (c alterTableCommand) toSQL() string {
 		return ""
 	}
 
-	return "ALTER TABLE `" + c.name + "` " + c.poolToSQL()
+	sql := "ALTER TABLE `" + c.name + "` " + c.poolToSQL()
+
+	if algorithm := strings.ToUpper(c.options.Algorithm); algorithm != "" {
+		sql += ", ALGORITHM=" + algorithm
+	}
+
+	if lock := strings.ToUpper(c.options.Lock); lock != "" {
+		sql += ", LOCK=" + lock
+	}
+
+	return sql
+}
+
+// AlterOptions represents options of online DDL for AlterTable command
+// https://dev.mysql.com/doc/refman/8.0/en/innodb-online-ddl-operations.html
+//
+// - Algorithm	INSTANT, INPLACE, COPY or DEFAULT
+// - Lock		NONE, SHARED, EXCLUSIVE or DEFAULT
+//
+// MySQL fails the statement, if requested algorithm or lock level is not supported by the operation.
+type AlterOptions struct {
+	Algorithm string
+	Lock      string
+}
+
+var (
+	alterAlgorithms = list{"DEFAULT", "INSTANT", "INPLACE", "COPY"}
+	alterLocks      = list{"DEFAULT", "NONE", "SHARED", "EXCLUSIVE"}
+)
+
+// validate refuses algorithm and lock, that are obviously not supported for the queued commands
+func (c alterTableCommand) validate() error {
+	algorithm := strings.ToUpper(c.options.Algorithm)
+	lock := strings.ToUpper(c.options.Lock)
+
+	if algorithm != "" && !alterAlgorithms.has(algorithm) {
+		return fmt.Errorf(`Unknown algorithm "%s" to alter table "%s"`, c.options.Algorithm, c.name)
+	}
+
+	if lock != "" && !alterLocks.has(lock) {
+		return fmt.Errorf(`Unknown lock "%s" to alter table "%s"`, c.options.Lock, c.name)
+	}
+
+	switch algorithm {
+	case "INSTANT":
+		if lock != "" && lock != "DEFAULT" {
+			return fmt.Errorf(`LOCK=%s can't be used with ALGORITHM=INSTANT to alter table "%s"`, lock, c.name)
+		}
+
+		for _, tc := range c.pool {
+			if !supportsInstant(tc) {
+				return fmt.Errorf(`ALGORITHM=INSTANT is not supported for "%s" on table "%s"`, tc.toSQL(), c.name)
+			}
+		}
+	case "INPLACE":
+		var dropsPrimary, addsPrimary bool
+		for _, tc := range c.pool {
+			switch tc.(type) {
+			case DropPrimaryIndexCommand:
+				dropsPrimary = true
+			case AddPrimaryIndexCommand, AddCompositePrimaryIndexCommand:
+				addsPrimary = true
+			}
+		}
+
+		if dropsPrimary && !addsPrimary {
+			return fmt.Errorf(`ALGORITHM=INPLACE is not supported for "DROP PRIMARY KEY" without adding a new one on table "%s"`, c.name)
+		}
+	case "COPY":
+		if lock == "NONE" {
+			return fmt.Errorf(`LOCK=NONE can't be used with ALGORITHM=COPY to alter table "%s"`, c.name)
+		}
+	}
+
+	return nil
+}
+
+// supportsInstant reports whether table command might be executed with ALGORITHM=INSTANT
+func supportsInstant(c command) bool {
+	switch c.(type) {
+	case AddColumnCommand, DropColumnCommand, RenameColumnCommand:
+		return true
+	}
+
+	return false
 }
 
 func (c alterTableCommand) poolToSQL() string {
@@ -229,4 +229,90 @@ func TestAlterTableCommand(t *testing.T) {
 
 		assert.Equal(t, "ALTER TABLE `test` Do action on test, Do action on bang", c.toSQL())
 	})
+
+	t.Run("it renders algorithm and lock options", func(t *testing.T) {
+		c := alterTableCommand{
+			name:    "test",
+			pool:    TableCommands{testCommand("test")},
+			options: AlterOptions{Algorithm: "inplace", Lock: "none"},
+		}
+
+		assert.Equal(t, "ALTER TABLE `test` Do action on test, ALGORITHM=INPLACE, LOCK=NONE", c.toSQL())
+	})
+}
+
+func TestAlterTableCommandValidate(t *testing.T) {
+	t.Run("it is valid without options", func(t *testing.T) {
+		c := alterTableCommand{name: "test", pool: TableCommands{DropPrimaryIndexCommand{}}}
+
+		assert.Nil(t, c.validate())
+	})
+
+	t.Run("it refuses unknown algorithm", func(t *testing.T) {
+		c := alterTableCommand{name: "test", options: AlterOptions{Algorithm: "fast"}}
+
+		assert.EqualError(t, c.validate(), `Unknown algorithm "fast" to alter table "test"`)
+	})
+
+	t.Run("it refuses unknown lock", func(t *testing.T) {
+		c := alterTableCommand{name: "test", options: AlterOptions{Lock: "partial"}}
+
+		assert.EqualError(t, c.validate(), `Unknown lock "partial" to alter table "test"`)
+	})
+
+	t.Run("it refuses lock with instant algorithm", func(t *testing.T) {
+		c := alterTableCommand{name: "test", options: AlterOptions{Algorithm: "INSTANT", Lock: "NONE"}}
+
+		assert.EqualError(t, c.validate(), `LOCK=NONE can't be used with ALGORITHM=INSTANT to alter table "test"`)
+	})
+
+	t.Run("it allows instant algorithm for columns changes", func(t *testing.T) {
+		c := alterTableCommand{
+			name: "test",
+			pool: TableCommands{
+				AddColumnCommand{Name: "title", Column: String{Precision: 64}},
+				RenameColumnCommand{Old: "body", New: "content"},
+				DropColumnCommand("summary"),
+			},
+			options: AlterOptions{Algorithm: "instant"},
+		}
+
+		assert.Nil(t, c.validate())
+	})
+
+	t.Run("it refuses instant algorithm for index changes", func(t *testing.T) {
+		c := alterTableCommand{
+			name:    "test",
+			pool:    TableCommands{AddIndexCommand{Name: "idx_title", Columns: []string{"title"}}},
+			options: AlterOptions{Algorithm: "INSTANT"},
+		}
+
+		assert.EqualError(t, c.validate(), "ALGORITHM=INSTANT is not supported for \"ADD KEY `idx_title` (`title`)\" on table \"test\"")
+	})
+
+	t.Run("it refuses inplace algorithm to drop primary key", func(t *testing.T) {
+		c := alterTableCommand{
+			name:    "test",
+			pool:    TableCommands{DropPrimaryIndexCommand{}},
+			options: AlterOptions{Algorithm: "INPLACE"},
+		}
+
+		assert.EqualError(t, c.validate(), `ALGORITHM=INPLACE is not supported for "DROP PRIMARY KEY" without adding a new one on table "test"`)
+	})
+
+	t.Run("it allows inplace algorithm to replace primary key", func(t *testing.T) {
+		c := alterTableCommand{
+			name:    "test",
+			pool:    TableCommands{DropPrimaryIndexCommand{}, AddPrimaryIndexCommand("uuid")},
+			options: AlterOptions{Algorithm: "INPLACE", Lock: "NONE"},
+		}
+
+		assert.Nil(t, c.validate())
+	})
+
+	t.Run("it refuses concurrent changes with copy algorithm", func(t *testing.T) {
+		c := alterTableCommand{name: "test", options: AlterOptions{Algorithm: "COPY", Lock: "NONE"}}
+
+		assert.EqualError(t, c.validate(), `LOCK=NONE can't be used with ALGORITHM=COPY to alter table "test"`)
+	})
 }
@@ -64,7 +64,56 @@ func TestSchemaAlterTable(t *testing.T) {
 	s.AlterTable("table", TableCommands{})
 
 	assert.Len(s.pool, 1)
-	assert.Equal(alterTableCommand{"table", TableCommands{}}, s.pool[0])
+	assert.Equal(alterTableCommand{name: "table", pool: TableCommands{}}, s.pool[0])
+}
+
+func TestSchemaAlterTableWithOptions(t *testing.T) {
+	assert := assert.New(t)
+
+	s := Schema{}
+	s.AlterTable("table", TableCommands{}, AlterOptions{Algorithm: "INPLACE"}, AlterOptions{Lock: "NONE"})
+
+	assert.Len(s.pool, 1)
+	assert.Equal(
+		alterTableCommand{name: "table", pool: TableCommands{}, options: AlterOptions{Algorithm: "INPLACE", Lock: "NONE"}},
+		s.pool[0],
+	)
+}
+
+func TestSchemaValidate(t *testing.T) {
+	t.Run("it is valid without validatable commands", func(t *testing.T) {
+		s := Schema{pool: []command{testDummyCommand("test")}}
+		assert.Nil(t, s.validate())
+	})
+
+	t.Run("it returns the first validation error", func(t *testing.T) {
+		var s Schema
+		s.AlterTable("test", TableCommands{DropPrimaryIndexCommand{}}, AlterOptions{Algorithm: "INSTANT"})
+
+		err := s.validate()
+
+		assert.Error(t, err)
+		assert.Equal(t, `ALGORITHM=INSTANT is not supported for "DROP PRIMARY KEY" on table "test"`, err.Error())
+	})
+}
+
+func TestSchemaStatements(t *testing.T) {
+	var s Schema
+	s.DropTableIfExists("test")
+	s.CustomCommand(testDummyCommand("DROP PROCEDURE abc"))
+
+	assert.Equal(t, []string{"DROP TABLE IF EXISTS `test`", "DROP PROCEDURE abc"}, s.Statements())
+}
+
+func TestSchemaBackfill(t *testing.T) {
+	assert := assert.New(t)
+
+	s := Schema{}
+	c := BackfillCommand{Table: "test", Set: "active = 1"}
+	s.Backfill(c)
+
+	assert.Len(s.pool, 1)
+	assert.Equal(c.toSQL(), s.pool[0].toSQL())
 }
 
 func TestSchemaCustomCommand(t *testing.T) {
@@ -0,0 +1,136 @@
+package migrator
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+var sessionVariableName = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// connection adapts a dedicated connection to be used the same way as connection pool.
+type connection struct {
+	*sql.Conn
+}
+
+func (c connection) Exec(query string, args ...interface{}) (sql.Result, error) {
+	return c.ExecContext(context.Background(), query, args...)
+}
+
+func (c connection) Query(query string, args ...interface{}) (*sql.Rows, error) {
+	return c.QueryContext(context.Background(), query, args...)
+}
+
+func (c connection) QueryRow(query string, args ...interface{}) *sql.Row {
+	return c.QueryRowContext(context.Background(), query, args...)
+}
+
+func (c connection) Begin() (*sql.Tx, error) {
+	return c.BeginTx(context.Background(), nil)
+}
+
+// withSession calls fn on a dedicated connection with session variables of the migration,
+// previous values of the variables are restored afterwards.
+// Connection pool is used as is, if migration has no session variables.
+func (m Migration) withSession(db *sql.DB, d Dialect, fn func(transactableSQL) error) error {
+	if len(m.Session) == 0 {
+		return fn(db)
+	}
+
+	var names []string
+	for name := range m.Session {
+		if !sessionVariableName.MatchString(name) {
+			return fmt.Errorf(`Invalid session variable "%s" in migration "%s"`, name, m.Name)
+		}
+
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	ctx := context.Background()
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+
+	previous := map[string]sql.NullString{}
+	var set []string
+
+	for _, name := range names {
+		var value sql.NullString
+		if err = conn.QueryRowContext(ctx, d.sessionVariable(name)).Scan(&value); err != nil {
+			break
+		}
+		previous[name] = value
+
+		if _, err = conn.ExecContext(ctx, d.setSessionVariable(name, m.Session[name])); err != nil {
+			break
+		}
+		set = append(set, name)
+	}
+
+	if err == nil {
+		err = fn(connection{conn})
+	}
+
+	if restoreErr := restoreSession(ctx, conn, d, set, previous); restoreErr != nil {
+		discard(conn)
+
+		if err == nil {
+			err = restoreErr
+		}
+
+		return err
+	}
+
+	conn.Close()
+
+	return err
+}
+
+// restoreSession sets previous values of the variables, all of them are restored even if some fail
+func restoreSession(ctx context.Context, conn *sql.Conn, d Dialect, names []string, previous map[string]sql.NullString) error {
+	var err error
+
+	for _, name := range names {
+		_, restoreErr := conn.ExecContext(ctx, d.setSessionVariable(name, sessionValue(previous[name])))
+		if restoreErr != nil && err == nil {
+			err = restoreErr
+		}
+	}
+
+	return err
+}
+
+// rawConn is implemented by sql.Conn since Go 1.14
+type rawConn interface {
+	Raw(func(driverConn interface{}) error) error
+}
+
+// discard closes the connection with modified session instead of returning it to the pool.
+// Connection can't be closed before Go 1.14, so it's kept out of the pool instead.
+func discard(conn *sql.Conn) {
+	if raw, ok := interface{}(conn).(rawConn); ok {
+		raw.Raw(func(interface{}) error {
+			return driver.ErrBadConn
+		})
+	}
+}
+
+// sessionValue renders value of session variable to be used in SET statement.
+func sessionValue(v sql.NullString) string {
+	if !v.Valid {
+		return "DEFAULT"
+	}
+
+	if _, err := strconv.ParseFloat(v.String, 64); err == nil {
+		return v.String
+	}
+
+	return "'" + strings.Replace(v.String, "'", "''", -1) + "'"
+}
@@ -0,0 +1,175 @@
+package migrator
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithSession(t *testing.T) {
+	t.Run("it uses connection pool when there are no session variables", func(t *testing.T) {
+		db, _, resetDB := testDBConnection(t)
+		defer resetDB()
+
+		var used transactableSQL
+		err := Migration{}.withSession(db, MySQL, func(db transactableSQL) error {
+			used = db
+			return nil
+		})
+
+		assert.Nil(t, err)
+		assert.Equal(t, db, used)
+	})
+
+	t.Run("it refuses invalid variable names", func(t *testing.T) {
+		db, _, resetDB := testDBConnection(t)
+		defer resetDB()
+
+		m := Migration{Name: "test", Session: map[string]string{"sql_mode = ''; DROP TABLE users; --": "1"}}
+		err := m.withSession(db, MySQL, func(transactableSQL) error { return nil })
+
+		assert.Error(t, err)
+		assert.Equal(t, `Invalid session variable "sql_mode = ''; DROP TABLE users; --" in migration "test"`, err.Error())
+	})
+
+	t.Run("it fails reading previous value", func(t *testing.T) {
+		db, mock, resetDB := testDBConnection(t)
+		defer resetDB()
+
+		mock.ExpectQuery(`SELECT @@SESSION.lock_wait_timeout`).WillReturnError(errTestDBQueryFailed)
+
+		m := Migration{Session: map[string]string{"lock_wait_timeout": "5"}}
+		err := m.withSession(db, MySQL, func(transactableSQL) error { return nil })
+
+		assert.Equal(t, errTestDBQueryFailed, err)
+	})
+
+	t.Run("it sets variables and restores them afterwards", func(t *testing.T) {
+		db, mock, resetDB := testDBConnection(t)
+		defer resetDB()
+
+		mock.ExpectQuery(`SELECT @@SESSION.foreign_key_checks`).
+			WillReturnRows(sqlmock.NewRows([]string{"value"}).AddRow("1"))
+		mock.ExpectExec(`SET SESSION foreign_key_checks = 0`).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectQuery(`SELECT @@SESSION.sql_mode`).
+			WillReturnRows(sqlmock.NewRows([]string{"value"}).AddRow("STRICT_TRANS_TABLES,NO_ZERO_DATE"))
+		mock.ExpectExec(`SET SESSION sql_mode = 'TRADITIONAL'`).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec("ALTER TABLE posts").WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec(`SET SESSION foreign_key_checks = 1`).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec(`SET SESSION sql_mode = 'STRICT_TRANS_TABLES,NO_ZERO_DATE'`).WillReturnResult(sqlmock.NewResult(0, 0))
+
+		m := Migration{Session: map[string]string{"sql_mode": "'TRADITIONAL'", "foreign_key_checks": "0"}}
+		err := m.withSession(db, MySQL, func(db transactableSQL) error {
+			_, err := db.Exec("ALTER TABLE posts")
+			return err
+		})
+
+		assert.Nil(t, err)
+	})
+
+	t.Run("it restores variables when migration fails", func(t *testing.T) {
+		db, mock, resetDB := testDBConnection(t)
+		defer resetDB()
+
+		mock.ExpectQuery(`SELECT @@SESSION.lock_wait_timeout`).
+			WillReturnRows(sqlmock.NewRows([]string{"value"}).AddRow("31536000"))
+		mock.ExpectExec(`SET SESSION lock_wait_timeout = 5`).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec("ALTER TABLE posts").WillReturnError(errTestDBExecFailed)
+		mock.ExpectExec(`SET SESSION lock_wait_timeout = 31536000`).WillReturnResult(sqlmock.NewResult(0, 0))
+
+		m := Migration{Session: map[string]string{"lock_wait_timeout": "5"}}
+		err := m.withSession(db, MySQL, func(db transactableSQL) error {
+			_, err := db.Exec("ALTER TABLE posts")
+			return err
+		})
+
+		assert.Equal(t, errTestDBExecFailed, err)
+	})
+
+	t.Run("it restores variables set before failed one", func(t *testing.T) {
+		db, mock, resetDB := testDBConnection(t)
+		defer resetDB()
+
+		mock.ExpectQuery(`SELECT @@SESSION.foreign_key_checks`).
+			WillReturnRows(sqlmock.NewRows([]string{"value"}).AddRow("1"))
+		mock.ExpectExec(`SET SESSION foreign_key_checks = 0`).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectQuery(`SELECT @@SESSION.lock_wait_timeout`).
+			WillReturnRows(sqlmock.NewRows([]string{"value"}).AddRow("50"))
+		mock.ExpectExec(`SET SESSION lock_wait_timeout = bad`).WillReturnError(errTestDBExecFailed)
+		mock.ExpectExec(`SET SESSION foreign_key_checks = 1`).WillReturnResult(sqlmock.NewResult(0, 0))
+
+		m := Migration{Session: map[string]string{"foreign_key_checks": "0", "lock_wait_timeout": "bad"}}
+		err := m.withSession(db, MySQL, func(transactableSQL) error {
+			t.Fatal("migration should not run")
+			return nil
+		})
+
+		assert.Equal(t, errTestDBExecFailed, err)
+	})
+
+	t.Run("it discards connection when variables fail to be restored", func(t *testing.T) {
+		db, mock, resetDB := testDBConnection(t)
+		defer resetDB()
+
+		mock.ExpectQuery(`SELECT @@SESSION.foreign_key_checks`).
+			WillReturnRows(sqlmock.NewRows([]string{"value"}).AddRow("1"))
+		mock.ExpectExec(`SET SESSION foreign_key_checks = 0`).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectQuery(`SELECT @@SESSION.sql_mode`).
+			WillReturnRows(sqlmock.NewRows([]string{"value"}).AddRow("ANSI"))
+		mock.ExpectExec(`SET SESSION sql_mode = 'TRADITIONAL'`).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec(`SET SESSION foreign_key_checks = 1`).WillReturnError(errTestDBExecFailed)
+		mock.ExpectExec(`SET SESSION sql_mode = 'ANSI'`).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectClose()
+
+		m := Migration{Session: map[string]string{"foreign_key_checks": "0", "sql_mode": "'TRADITIONAL'"}}
+		err := m.withSession(db, MySQL, func(transactableSQL) error { return nil })
+
+		assert.Equal(t, errTestDBExecFailed, err)
+	})
+}
+
+func TestSessionValue(t *testing.T) {
+	assert.Equal(t, "DEFAULT", sessionValue(sql.NullString{}))
+	assert.Equal(t, "50", sessionValue(sql.NullString{String: "50", Valid: true}))
+	assert.Equal(t, "'ANSI'", sessionValue(sql.NullString{String: "ANSI", Valid: true}))
+	assert.Equal(t, "'it''s'", sessionValue(sql.NullString{String: "it's", Valid: true}))
+}
+
+func TestMigrateWithSession(t *testing.T) {
+	t.Run("it runs transactional migration on a dedicated connection", func(t *testing.T) {
+		migration := Migration{
+			Name:        "test",
+			Transaction: true,
+			Session:     map[string]string{"lock_wait_timeout": "5"},
+			Up: func() Schema {
+				var s Schema
+				s.CustomCommand(testDummyCommand("UPDATE test SET active = 1"))
+				return s
+			},
+		}
+		m := Migrator{Pool: []Migration{migration}}
+		db, mock, resetDB := testDBConnection(t)
+		defer resetDB()
+
+		rows := sqlmock.NewRows([]string{"id", "name", "batch", "applied_at"}).AddRow(1, "new", 1, time.Now())
+
+		mock.ExpectQuery("SELECT").WillReturnRows(sqlmock.NewRows(nil))
+		mock.ExpectQuery("SELECT id, name, batch, applied_at FROM `migrations`").WillReturnRows(rows)
+		mock.ExpectQuery(`SELECT @@SESSION.lock_wait_timeout`).
+			WillReturnRows(sqlmock.NewRows([]string{"value"}).AddRow("50"))
+		mock.ExpectExec(`SET SESSION lock_wait_timeout = 5`).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectBegin()
+		mock.ExpectExec("UPDATE test").WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(`INSERT .* VALUES \("test", 2\)`).WillReturnResult(sqlmock.NewResult(2, 1))
+		mock.ExpectCommit()
+		mock.ExpectExec(`SET SESSION lock_wait_timeout = 50`).WillReturnResult(sqlmock.NewResult(0, 0))
+
+		migrated, err := m.Migrate(db)
+
+		assert.Nil(t, err)
+		assert.Equal(t, []string{"test"}, migrated)
+	})
+}
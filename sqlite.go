@@ -0,0 +1,707 @@
+package migrator
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// sqliteDialect renders commands for SQLite.
+//
+// Column types are mapped to SQLite type affinities. Table engine, charset and collation,
+// comments and `ON UPDATE` of columns are skipped. Enum is rendered as varchar with CHECK constraint.
+// Changes, that SQLite can't apply with ALTER TABLE (modify column, add or drop primary and foreign keys),
+// are applied by rebuilding the table: https://www.sqlite.org/lang_altertable.html#otheralter
+type sqliteDialect struct {
+	standardDialect
+}
+
+func (d sqliteDialect) rebind(query string) string {
+	return query
+}
+
+// commitsImplicitly always returns false, DDL is transactional in SQLite
+func (d sqliteDialect) commitsImplicitly(c command) bool {
+	return false
+}
+
+func (d sqliteDialect) createMigrationTable(table string) string {
+	return fmt.Sprintf(
+		"CREATE TABLE %s (%s)",
+		table,
+		strings.Join([]string{
+			"id integer PRIMARY KEY AUTOINCREMENT",
+			"name varchar(255) NOT NULL",
+			"batch integer NOT NULL",
+			"applied_at timestamp NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f', 'now'))",
+		}, ", "),
+	)
+}
+
+func (d sqliteDialect) createProgressTable(table string) string {
+	return fmt.Sprintf(
+		"CREATE TABLE IF NOT EXISTS %s (%s)",
+		table,
+		strings.Join([]string{
+			"name varchar(255) NOT NULL PRIMARY KEY",
+			"step integer NOT NULL",
+		}, ", "),
+	)
+}
+
+// sessionVariable reads value of PRAGMA, SQLite has no session variables
+func (d sqliteDialect) sessionVariable(name string) string {
+	return "PRAGMA " + name
+}
+
+func (d sqliteDialect) setSessionVariable(name string, value string) string {
+	return fmt.Sprintf("PRAGMA %s = %s", name, value)
+}
+
+func (d sqliteDialect) render(c command) ([]string, error) {
+	switch v := c.(type) {
+	case createTableCommand:
+		return d.createTable(v.t)
+	case dropTableCommand:
+		sql := "DROP TABLE"
+		if v.soft {
+			sql += " IF EXISTS"
+		}
+
+		return []string{sql + " " + d.quote(v.table)}, nil
+	case renameTableCommand:
+		return []string{fmt.Sprintf("ALTER TABLE %s RENAME TO %s", d.quote(v.old), d.quote(v.new))}, nil
+	case alterTableCommand:
+		steps, err := d.alterSteps(v)
+		if err != nil {
+			return nil, err
+		}
+
+		var statements []string
+		for _, step := range steps {
+			r, ok := step.(rendered)
+			if !ok {
+				return nil, fmt.Errorf(`Table "%s" has to be rebuilt to be altered`, v.name)
+			}
+
+			statements = append(statements, r.statements...)
+		}
+
+		return statements, nil
+	}
+
+	return []string{c.toSQL()}, nil
+}
+
+// procedure returns command to alter table, that has to be rebuilt
+func (d sqliteDialect) procedure(c command) (procedure, error) {
+	alter, ok := c.(alterTableCommand)
+	if !ok {
+		return nil, nil
+	}
+
+	steps, err := d.alterSteps(alter)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, step := range steps {
+		if _, ok := step.(sqliteRebuild); ok {
+			return sqliteAlterTableCommand{alter: alter, steps: steps}, nil
+		}
+	}
+
+	return nil, nil
+}
+
+func (d sqliteDialect) createTable(t Table) ([]string, error) {
+	if t.Name == "" {
+		return nil, ErrNoSQLCommandsToRun
+	}
+
+	// SQLite allows autoincrement only for the primary key declared inline
+	var inline string
+	for _, key := range t.indexes {
+		if strings.ToUpper(key.Type) == "PRIMARY" && len(key.Columns) == 1 {
+			inline = key.Columns[0]
+		}
+	}
+
+	var definitions, indexes []string
+	var hasInline bool
+
+	for _, col := range t.columns {
+		cd, err := d.column(col.field, col.definition)
+		if err != nil {
+			return nil, err
+		}
+
+		definition := d.quote(col.field) + " " + cd.definition()
+		if i, ok := col.definition.(Integer); ok && i.Autoincrement && col.field == inline {
+			definition += " PRIMARY KEY AUTOINCREMENT"
+			hasInline = true
+		}
+
+		definitions = append(definitions, definition)
+	}
+
+	if len(definitions) == 0 {
+		definitions = append(definitions, d.quote("id")+" integer NOT NULL PRIMARY KEY AUTOINCREMENT")
+	}
+
+	for _, key := range t.indexes {
+		if len(key.Columns) == 0 {
+			continue
+		}
+
+		switch strings.ToUpper(key.Type) {
+		case "PRIMARY":
+			if !hasInline {
+				definitions = append(definitions, "PRIMARY KEY ("+d.quoteList(key.Columns)+")")
+			}
+		case "UNIQUE":
+			indexes = append(indexes, d.createIndex(t.Name, key.Name, key.Columns, true))
+		default:
+			indexes = append(indexes, d.createIndex(t.Name, key.Name, key.Columns, false))
+		}
+	}
+
+	for _, f := range t.foreigns {
+		if sql := d.foreign(f); sql != "" {
+			definitions = append(definitions, sql)
+		}
+	}
+
+	statements := []string{fmt.Sprintf("CREATE TABLE %s (%s)", d.quote(t.Name), strings.Join(definitions, ", "))}
+
+	return append(statements, indexes...), nil
+}
+
+// alterSteps splits table commands into statements supported by ALTER TABLE and table rebuilds,
+// consecutive commands of the same kind are grouped together.
+func (d sqliteDialect) alterSteps(c alterTableCommand) ([]command, error) {
+	if c.name == "" || len(c.pool) == 0 {
+		return nil, ErrNoSQLCommandsToRun
+	}
+
+	if c.options.Algorithm != "" || c.options.Lock != "" {
+		return nil, fmt.Errorf(`ALGORITHM and LOCK options are not supported by SQLite to alter table "%s"`, c.name)
+	}
+
+	var steps []command
+	var statements []string
+	var changes TableCommands
+	alter := "ALTER TABLE " + d.quote(c.name) + " "
+
+	addStatements := func(sql ...string) {
+		if len(changes) > 0 {
+			steps = append(steps, sqliteRebuild{table: c.name, changes: changes})
+			changes = nil
+		}
+
+		statements = append(statements, sql...)
+	}
+	addChange := func(tc command) {
+		if len(statements) > 0 {
+			steps = append(steps, rendered{c, statements})
+			statements = nil
+		}
+
+		changes = append(changes, tc)
+	}
+
+	for _, tc := range c.pool {
+		switch v := tc.(type) {
+		case AddColumnCommand:
+			if v.After != "" || v.First {
+				return nil, fmt.Errorf(`Column position is not supported by SQLite to add column "%s"`, v.Name)
+			}
+
+			cd, err := d.column(v.Name, v.Column)
+			if err != nil {
+				return nil, err
+			}
+			addStatements(alter + "ADD COLUMN " + d.quote(v.Name) + " " + cd.definition())
+		case RenameColumnCommand:
+			addStatements(alter + fmt.Sprintf("RENAME COLUMN %s TO %s", d.quote(v.Old), d.quote(v.New)))
+		case ModifyColumnCommand:
+			if _, err := d.column(v.Name, v.Column); err != nil {
+				return nil, err
+			}
+			addChange(v)
+		case ChangeColumnCommand:
+			if _, err := d.column(v.To, v.Column); err != nil {
+				return nil, err
+			}
+			// renamed column is updated in indexes by SQLite, so it is renamed before the rebuild
+			if v.From != v.To {
+				addStatements(alter + fmt.Sprintf("RENAME COLUMN %s TO %s", d.quote(v.From), d.quote(v.To)))
+			}
+			addChange(ModifyColumnCommand{Name: v.To, Column: v.Column})
+		case DropColumnCommand:
+			addStatements(alter + "DROP COLUMN " + d.quote(string(v)))
+		case AddIndexCommand:
+			addStatements(d.createIndex(c.name, v.Name, v.Columns, false))
+		case AddUniqueIndexCommand:
+			addStatements(d.createIndex(c.name, v.Key, v.Columns, true))
+		case DropIndexCommand:
+			addStatements("DROP INDEX " + d.quote(string(v)))
+		case AddForeignCommand, DropForeignCommand, AddPrimaryIndexCommand, AddCompositePrimaryIndexCommand, DropPrimaryIndexCommand:
+			addChange(tc)
+		default:
+			addStatements(alter + tc.toSQL())
+		}
+	}
+
+	addStatements()
+	if len(statements) > 0 {
+		steps = append(steps, rendered{c, statements})
+	}
+
+	return steps, nil
+}
+
+// createIndex names unnamed index after the table and columns, SQLite requires index name
+func (d sqliteDialect) createIndex(table string, name string, columns []string, unique bool) string {
+	if name == "" {
+		name = table + "_" + strings.Join(columns, "_") + "_index"
+	}
+
+	return d.standardDialect.createIndex(table, name, columns, unique)
+}
+
+// column converts column type to SQLite, custom column types are rendered as they are
+func (d sqliteDialect) column(name string, c columnType) (columnDefinition, error) {
+	var cd columnDefinition
+
+	switch v := c.(type) {
+	case Integer:
+		cd.typ = "integer"
+		cd.nullable, cd.def = v.Nullable, v.Default
+	case Floatable:
+		switch strings.ToLower(v.Type) {
+		case "decimal", "numeric":
+			cd.typ = "numeric"
+			if v.Precision > 0 {
+				cd.typ += fmt.Sprintf("(%d,%d)", v.Precision, v.Scale)
+			}
+		default:
+			cd.typ = "real"
+		}
+		cd.nullable, cd.def = v.Nullable, v.Default
+	case Timable:
+		switch strings.ToLower(v.Type) {
+		case "date", "time", "datetime":
+			cd.typ = strings.ToLower(v.Type)
+		case "year":
+			cd.typ = "integer"
+		default:
+			cd.typ = "timestamp"
+		}
+		cd.nullable, cd.def = v.Nullable, v.Default
+	case String:
+		cd.typ = "varchar"
+		if v.Fixed {
+			cd.typ = "char"
+		}
+		if v.Precision > 0 {
+			cd.typ += "(" + strconv.Itoa(int(v.Precision)) + ")"
+		}
+		cd.nullable, cd.def = v.Nullable, d.stringDefault(v.Default)
+	case Text:
+		cd.typ = "text"
+		if v.Blob {
+			cd.typ = "blob"
+		}
+		cd.nullable, cd.def = v.Nullable, d.stringDefault(v.Default)
+	case JSON:
+		// json declared type would get numeric affinity
+		cd.typ = "text"
+		cd.nullable, cd.def = v.Nullable, d.stringDefault(v.Default)
+	case Enum:
+		if v.Multiple {
+			return cd, fmt.Errorf(`Set column "%s" is not supported by SQLite`, name)
+		}
+
+		cd.typ = "varchar(255)"
+		var values []string
+		for _, value := range v.Values {
+			values = append(values, d.literal(value))
+		}
+		cd.check = fmt.Sprintf("CHECK (%s IN (%s))", d.quote(name), strings.Join(values, ", "))
+		cd.nullable, cd.def = v.Nullable, d.stringDefault(v.Default)
+	case Bit:
+		cd.typ = "integer"
+		cd.nullable, cd.def = v.Nullable, v.Default
+	case Binary:
+		cd.typ = "blob"
+		cd.nullable, cd.def = v.Nullable, v.Default
+	default:
+		cd.raw = c.buildRow()
+		cd.typ = cd.raw
+	}
+
+	cd.def = sqliteDefault(cd.def)
+
+	return cd, nil
+}
+
+var sqliteTimestampDefault = regexp.MustCompile(`(?i)^CURRENT_TIMESTAMP\(\d\)$`)
+
+// sqliteDefault replaces MySQL functions in default value with SQLite expressions
+func sqliteDefault(def string) string {
+	switch {
+	case def == "(UUID())":
+		return "(lower(hex(randomblob(4))) || '-' || lower(hex(randomblob(2))) || '-4' || " +
+			"substr(lower(hex(randomblob(2))), 2) || '-' || substr('89ab', 1 + (abs(random()) % 4), 1) || " +
+			"substr(lower(hex(randomblob(2))), 2) || '-' || lower(hex(randomblob(6))))"
+	case def == "(UUID_TO_BIN(UUID()))":
+		return "(randomblob(16))"
+	case sqliteTimestampDefault.MatchString(def):
+		return "CURRENT_TIMESTAMP"
+	}
+
+	return def
+}
+
+// sqliteAlterTableCommand runs statements supported by ALTER TABLE and rebuilds the table for other changes
+type sqliteAlterTableCommand struct {
+	alter alterTableCommand
+	steps []command
+}
+
+func (c sqliteAlterTableCommand) toSQL() string {
+	return c.alter.toSQL()
+}
+
+func (c sqliteAlterTableCommand) exec(db executableSQL) error {
+	return run(db, c.steps...)
+}
+
+// sqliteRebuild applies changes to the table by creating a new table with changed definition,
+// copying rows into it, dropping the table and renaming the new one. Indexes are created again.
+// Steps run within transaction, so the table is kept, if any of them fails.
+//
+// Foreign key enforcement is disabled while the table is rebuilt, so dropped table doesn't
+// cascade to other tables. It can't be disabled within transaction, the rebuild fails there
+// if foreign keys are enforced. Pragma applies to a single connection, so the table is rebuilt
+// on a dedicated connection, when connection pool is given. Foreign keys of the rebuilt table
+// are checked before commit, when they were enforced.
+type sqliteRebuild struct {
+	table   string
+	changes TableCommands
+}
+
+// toSQL renders changes as ALTER TABLE statement, while the table is rebuilt instead
+func (c sqliteRebuild) toSQL() string {
+	return alterTableCommand{name: c.table, pool: c.changes}.toSQL()
+}
+
+func (c sqliteRebuild) exec(db executableSQL) error {
+	pool, ok := db.(*sql.DB)
+	if !ok {
+		return c.rebuild(db)
+	}
+
+	conn, err := pool.Conn(context.Background())
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	return c.rebuild(connection{conn})
+}
+
+func (c sqliteRebuild) rebuild(db executableSQL) error {
+	d := sqliteDialect{}
+
+	var create string
+	if err := db.QueryRow("SELECT sql FROM sqlite_master WHERE type = 'table' AND name = ?", c.table).Scan(&create); err != nil {
+		return err
+	}
+
+	t, err := parseSQLiteTable(create)
+	if err != nil {
+		return err
+	}
+
+	for _, tc := range c.changes {
+		if err := t.apply(tc); err != nil {
+			return fmt.Errorf(`Table "%s" can't be rebuilt: %v`, c.table, err)
+		}
+	}
+
+	indexes, err := sqliteIndexes(db, c.table)
+	if err != nil {
+		return err
+	}
+
+	var enforced bool
+	if err := db.QueryRow("PRAGMA foreign_keys").Scan(&enforced); err != nil {
+		return err
+	}
+
+	check := enforced
+	if enforced {
+		if _, err := db.Exec("PRAGMA foreign_keys = OFF"); err != nil {
+			return err
+		}
+		defer db.Exec("PRAGMA foreign_keys = ON")
+
+		if err := db.QueryRow("PRAGMA foreign_keys").Scan(&enforced); err != nil {
+			return err
+		}
+		if enforced {
+			return fmt.Errorf(`Table "%s" can't be rebuilt while foreign keys are enforced, e.g. within transaction`, c.table)
+		}
+	}
+
+	shadow := "_" + c.table + "_new"
+	columns := d.quoteList(t.columns())
+
+	statements := []string{
+		fmt.Sprintf("CREATE TABLE %s (%s)%s", d.quote(shadow), strings.Join(t.definitions, ", "), t.options),
+		fmt.Sprintf("INSERT INTO %s (%s) SELECT %s FROM %s", d.quote(shadow), columns, columns, d.quote(c.table)),
+		"DROP TABLE " + d.quote(c.table),
+		fmt.Sprintf("ALTER TABLE %s RENAME TO %s", d.quote(shadow), d.quote(c.table)),
+	}
+
+	statements = append(statements, indexes...)
+
+	conn, ok := db.(transactableSQL)
+	if !ok {
+		return c.replace(db, statements, check)
+	}
+
+	tx, err := conn.Begin()
+	if err != nil {
+		return err
+	}
+
+	if err := c.replace(tx, statements, check); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// replace runs statements of the rebuild and checks, that rows of the rebuilt table don't violate its foreign keys
+func (c sqliteRebuild) replace(db executableSQL, statements []string, check bool) error {
+	if err := (rendered{statements: statements}).exec(db); err != nil {
+		return err
+	}
+
+	if !check {
+		return nil
+	}
+
+	rows, err := db.Query("PRAGMA foreign_key_check(" + sqliteDialect{}.quote(c.table) + ")")
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	if rows.Next() {
+		return fmt.Errorf(`Table "%s" can't be rebuilt: rows violate its foreign keys`, c.table)
+	}
+
+	return rows.Err()
+}
+
+// sqliteIndexes returns statements to create indexes of the table, indexes of constraints are skipped
+func sqliteIndexes(db executableSQL, table string) ([]string, error) {
+	rows, err := db.Query("SELECT sql FROM sqlite_master WHERE type = 'index' AND tbl_name = ? AND sql IS NOT NULL", table)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var indexes []string
+	for rows.Next() {
+		var sql string
+		if err := rows.Scan(&sql); err != nil {
+			return nil, err
+		}
+
+		indexes = append(indexes, sql)
+	}
+
+	return indexes, rows.Err()
+}
+
+// sqliteTable is a table definition parsed from CREATE TABLE statement stored in sqlite_master
+type sqliteTable struct {
+	// column definitions and table constraints
+	definitions []string
+	// table options after definitions, e.g. WITHOUT ROWID
+	options string
+}
+
+var (
+	sqliteConstraints   = list{"CONSTRAINT", "PRIMARY", "FOREIGN", "UNIQUE", "CHECK"}
+	sqliteInlinePrimary = regexp.MustCompile(`(?i)\s+PRIMARY\s+KEY(\s+(ASC|DESC))?(\s+AUTOINCREMENT)?`)
+)
+
+func parseSQLiteTable(sql string) (sqliteTable, error) {
+	start := strings.Index(sql, "(")
+	end := strings.LastIndex(sql, ")")
+	if start < 0 || end < start {
+		return sqliteTable{}, fmt.Errorf("Unexpected table definition: %s", sql)
+	}
+
+	t := sqliteTable{options: sql[end+1:]}
+	body := sql[start+1 : end]
+	depth, from := 0, 0
+	var quote byte
+
+	for i := 0; i < len(body); i++ {
+		ch := body[i]
+
+		switch {
+		case quote != 0:
+			if ch == quote {
+				quote = 0
+			}
+		case ch == '"' || ch == '\'' || ch == '`':
+			quote = ch
+		case ch == '[':
+			quote = ']'
+		case ch == '(':
+			depth++
+		case ch == ')':
+			depth--
+		case ch == ',' && depth == 0:
+			t.definitions = append(t.definitions, strings.TrimSpace(body[from:i]))
+			from = i + 1
+		}
+	}
+	t.definitions = append(t.definitions, strings.TrimSpace(body[from:]))
+
+	return t, nil
+}
+
+// columns returns names of the columns in order of definition
+func (t sqliteTable) columns() []string {
+	var columns []string
+
+	for _, def := range t.definitions {
+		if !isSQLiteConstraint(def) {
+			name, _ := sqliteIdentifier(def)
+			columns = append(columns, name)
+		}
+	}
+
+	return columns
+}
+
+func (t *sqliteTable) apply(tc command) error {
+	d := sqliteDialect{}
+
+	switch v := tc.(type) {
+	case ModifyColumnCommand:
+		for i, def := range t.definitions {
+			if name, _ := sqliteIdentifier(def); isSQLiteConstraint(def) || !strings.EqualFold(name, v.Name) {
+				continue
+			}
+
+			cd, err := d.column(v.Name, v.Column)
+			if err != nil {
+				return err
+			}
+
+			t.definitions[i] = d.quote(v.Name) + " " + cd.definition() + sqliteInlinePrimary.FindString(def)
+			return nil
+		}
+
+		return fmt.Errorf(`column "%s" does not exist`, v.Name)
+	case AddForeignCommand:
+		t.definitions = append(t.definitions, d.foreign(v.Foreign))
+	case DropForeignCommand:
+		for i, def := range t.definitions {
+			if !isSQLiteConstraint(def) {
+				continue
+			}
+
+			keyword, rest := sqliteIdentifier(def)
+			name, rest := sqliteIdentifier(rest)
+			if strings.ToUpper(keyword) == "CONSTRAINT" && name == string(v) && strings.HasPrefix(strings.ToUpper(rest), "FOREIGN") {
+				t.definitions = append(t.definitions[:i], t.definitions[i+1:]...)
+				return nil
+			}
+		}
+
+		return fmt.Errorf(`foreign key "%s" does not exist`, string(v))
+	case AddPrimaryIndexCommand:
+		t.definitions = append(t.definitions, "PRIMARY KEY ("+d.quote(string(v))+")")
+	case AddCompositePrimaryIndexCommand:
+		t.definitions = append(t.definitions, "PRIMARY KEY ("+d.quoteList(v.Columns)+")")
+	case DropPrimaryIndexCommand:
+		var definitions []string
+		var dropped bool
+
+		for _, def := range t.definitions {
+			if isSQLiteConstraint(def) {
+				keyword, rest := sqliteIdentifier(def)
+				if strings.ToUpper(keyword) == "CONSTRAINT" {
+					_, rest = sqliteIdentifier(rest)
+					keyword, _ = sqliteIdentifier(rest)
+				}
+
+				if strings.ToUpper(keyword) == "PRIMARY" {
+					dropped = true
+					continue
+				}
+			} else if sqliteInlinePrimary.MatchString(def) {
+				def = sqliteInlinePrimary.ReplaceAllString(def, "")
+				dropped = true
+			}
+
+			definitions = append(definitions, def)
+		}
+
+		if !dropped {
+			return fmt.Errorf("primary key does not exist")
+		}
+
+		t.definitions = definitions
+	default:
+		return fmt.Errorf(`"%s" is not supported`, tc.toSQL())
+	}
+
+	return nil
+}
+
+func isSQLiteConstraint(def string) bool {
+	if def == "" || strings.IndexByte("\"`[", def[0]) >= 0 {
+		return false
+	}
+
+	keyword, _ := sqliteIdentifier(def)
+
+	return sqliteConstraints.has(strings.ToUpper(keyword))
+}
+
+// sqliteIdentifier returns the first identifier or keyword of the definition and the rest of it
+func sqliteIdentifier(def string) (name string, rest string) {
+	def = strings.TrimSpace(def)
+	if def == "" {
+		return "", ""
+	}
+
+	closing := map[byte]byte{'"': '"', '`': '`', '[': ']'}
+	if c, ok := closing[def[0]]; ok {
+		if end := strings.IndexByte(def[1:], c); end >= 0 {
+			return def[1 : end+1], strings.TrimSpace(def[end+2:])
+		}
+	}
+
+	if end := strings.IndexAny(def, " \t\n("); end >= 0 {
+		return def[:end], strings.TrimSpace(def[end:])
+	}
+
+	return def, ""
+}
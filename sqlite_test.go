@@ -0,0 +1,405 @@
+package migrator
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSQLiteDialect(t *testing.T) {
+	assert.Equal(t, `"posts"`, SQLite.quote("posts"))
+	assert.Equal(t, "DELETE FROM a WHERE id = ?", SQLite.rebind("DELETE FROM a WHERE id = ?"))
+	assert.False(t, SQLite.commitsImplicitly(alterTableCommand{}))
+	assert.Equal(t, "PRAGMA foreign_keys", SQLite.sessionVariable("foreign_keys"))
+	assert.Equal(t, "PRAGMA foreign_keys = 0", SQLite.setSessionVariable("foreign_keys", "0"))
+	assert.Equal(
+		t,
+		"CREATE TABLE migrations (id integer PRIMARY KEY AUTOINCREMENT, name varchar(255) NOT NULL, batch integer NOT NULL, "+
+			"applied_at timestamp NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f', 'now')))",
+		SQLite.createMigrationTable("migrations"),
+	)
+	assert.Equal(
+		t,
+		"CREATE TABLE IF NOT EXISTS migrations_progress (name varchar(255) NOT NULL PRIMARY KEY, step integer NOT NULL)",
+		SQLite.createProgressTable("migrations_progress"),
+	)
+}
+
+func TestSQLiteCreateTable(t *testing.T) {
+	t.Run("it renders autoincrement column for empty table", func(t *testing.T) {
+		statements, err := SQLite.render(createTableCommand{Table{Name: "posts", Engine: "InnoDB"}})
+
+		assert.Nil(t, err)
+		assert.Equal(t, []string{`CREATE TABLE "posts" ("id" integer NOT NULL PRIMARY KEY AUTOINCREMENT)`}, statements)
+	})
+
+	t.Run("it declares autoincrement primary key inline", func(t *testing.T) {
+		table := Table{Name: "comments", Comment: "Comments of posts"}
+		table.ID("id")
+		table.Column("post_id", Integer{Prefix: "big", Unsigned: true, Comment: "Post"})
+		table.Column("status", Enum{Values: []string{"draft", "published"}, Default: "draft"})
+		table.Timestamps()
+		table.Index("", "status", "created_at")
+		table.Foreign("post_id", "id", "posts", "", "cascade")
+
+		statements, err := SQLite.render(createTableCommand{table})
+
+		assert.Nil(t, err)
+		assert.Equal(t, []string{
+			`CREATE TABLE "comments" (` +
+				`"id" integer NOT NULL PRIMARY KEY AUTOINCREMENT, ` +
+				`"post_id" integer NOT NULL, ` +
+				`"status" varchar(255) NOT NULL DEFAULT 'draft' CHECK ("status" IN ('draft', 'published')), ` +
+				`"created_at" timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP, ` +
+				`"updated_at" timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP, ` +
+				`CONSTRAINT "comments_post_id_foreign" FOREIGN KEY ("post_id") REFERENCES "posts" ("id") ON DELETE CASCADE)`,
+			`CREATE INDEX "comments_status_created_at_index" ON "comments" ("status", "created_at")`,
+			`CREATE INDEX "comments_post_id_foreign" ON "comments" ("post_id")`,
+		}, statements)
+	})
+
+	t.Run("it renders composite primary key as constraint", func(t *testing.T) {
+		table := Table{Name: "post_tags"}
+		table.Column("post_id", Integer{})
+		table.Column("tag_id", Integer{})
+		table.Primary("post_id", "tag_id")
+
+		statements, err := SQLite.render(createTableCommand{table})
+
+		assert.Nil(t, err)
+		assert.Equal(t, []string{
+			`CREATE TABLE "post_tags" ("post_id" integer NOT NULL, "tag_id" integer NOT NULL, PRIMARY KEY ("post_id", "tag_id"))`,
+		}, statements)
+	})
+
+	t.Run("it fails on set column", func(t *testing.T) {
+		table := Table{Name: "posts"}
+		table.Column("tags", Enum{Values: []string{"a"}, Multiple: true})
+
+		_, err := SQLite.render(createTableCommand{table})
+
+		assert.EqualError(t, err, `Set column "tags" is not supported by SQLite`)
+	})
+}
+
+func TestSQLiteColumn(t *testing.T) {
+	cases := []struct {
+		column   columnType
+		expected string
+	}{
+		{Integer{Prefix: "tiny", Unsigned: true, Default: "1"}, "integer NOT NULL DEFAULT 1"},
+		{Floatable{Type: "double", Nullable: true}, "real NULL"},
+		{Floatable{Type: "decimal", Precision: 10, Scale: 2}, "numeric(10,2) NOT NULL"},
+		{Timable{Type: "datetime", Precision: 6, Nullable: true}, "datetime NULL"},
+		{Timable{Type: "year"}, "integer NOT NULL"},
+		{String{Fixed: true, Precision: 36, Default: "(UUID())"}, "char(36) NOT NULL DEFAULT " + sqliteDefault("(UUID())")},
+		{Text{Prefix: "medium", Default: "<empty>"}, "text NOT NULL DEFAULT ''"},
+		{Text{Blob: true, Nullable: true}, "blob NULL"},
+		{JSON{Nullable: true}, "text NULL"},
+		{Bit{Precision: 1, Default: "0"}, "integer NOT NULL DEFAULT 0"},
+		{Binary{Fixed: true, Precision: 16, Default: "(UUID_TO_BIN(UUID()))"}, "blob NOT NULL DEFAULT (randomblob(16))"},
+		{testColumnType("custom type"), "custom type"},
+	}
+
+	for _, c := range cases {
+		cd, err := SQLite.(sqliteDialect).column("col", c.column)
+
+		assert.Nil(t, err)
+		assert.Equal(t, c.expected, cd.definition())
+	}
+}
+
+func TestSQLiteAlterTable(t *testing.T) {
+	t.Run("it renders commands supported by ALTER TABLE", func(t *testing.T) {
+		c := alterTableCommand{name: "posts", pool: TableCommands{
+			AddColumnCommand{Name: "rating", Column: Integer{Nullable: true}},
+			RenameColumnCommand{Old: "title", New: "name"},
+			DropColumnCommand("legacy"),
+			AddIndexCommand{Columns: []string{"rating"}},
+			AddUniqueIndexCommand{Key: "posts_slug_unique", Columns: []string{"slug"}},
+			DropIndexCommand("idx_old"),
+		}}
+
+		p, err := SQLite.(sqliteDialect).procedure(c)
+		assert.Nil(t, err)
+		assert.Nil(t, p)
+
+		statements, err := SQLite.render(c)
+
+		assert.Nil(t, err)
+		assert.Equal(t, []string{
+			`ALTER TABLE "posts" ADD COLUMN "rating" integer NULL`,
+			`ALTER TABLE "posts" RENAME COLUMN "title" TO "name"`,
+			`ALTER TABLE "posts" DROP COLUMN "legacy"`,
+			`CREATE INDEX "posts_rating_index" ON "posts" ("rating")`,
+			`CREATE UNIQUE INDEX "posts_slug_unique" ON "posts" ("slug")`,
+			`DROP INDEX "idx_old"`,
+		}, statements)
+	})
+
+	t.Run("it refuses column position and online DDL options", func(t *testing.T) {
+		_, err := SQLite.render(alterTableCommand{name: "posts", pool: TableCommands{AddColumnCommand{Name: "rating", Column: Integer{}, First: true}}})
+		assert.EqualError(t, err, `Column position is not supported by SQLite to add column "rating"`)
+
+		_, err = SQLite.render(alterTableCommand{name: "posts", pool: TableCommands{DropColumnCommand("title")}, options: AlterOptions{Algorithm: "INPLACE"}})
+		assert.EqualError(t, err, `ALGORITHM and LOCK options are not supported by SQLite to alter table "posts"`)
+	})
+
+	t.Run("it groups changes, that require rebuild", func(t *testing.T) {
+		c := alterTableCommand{name: "posts", pool: TableCommands{
+			AddColumnCommand{Name: "rating", Column: Integer{Nullable: true}},
+			ChangeColumnCommand{From: "title", To: "name", Column: String{Precision: 100}},
+			DropForeignCommand("posts_user_id_foreign"),
+			DropColumnCommand("legacy"),
+		}}
+
+		_, err := SQLite.render(c)
+		assert.EqualError(t, err, `Table "posts" has to be rebuilt to be altered`)
+
+		p, err := SQLite.(sqliteDialect).procedure(c)
+
+		assert.Nil(t, err)
+		assert.Equal(t, sqliteAlterTableCommand{alter: c, steps: []command{
+			rendered{c, []string{
+				`ALTER TABLE "posts" ADD COLUMN "rating" integer NULL`,
+				`ALTER TABLE "posts" RENAME COLUMN "title" TO "name"`,
+			}},
+			sqliteRebuild{table: "posts", changes: TableCommands{
+				ModifyColumnCommand{Name: "name", Column: String{Precision: 100}},
+				DropForeignCommand("posts_user_id_foreign"),
+			}},
+			rendered{c, []string{`ALTER TABLE "posts" DROP COLUMN "legacy"`}},
+		}}, p)
+		assert.Equal(t, c.toSQL(), p.toSQL())
+	})
+
+	t.Run("it is rendered into procedure by migrator", func(t *testing.T) {
+		var s Schema
+		s.AlterTable("posts", TableCommands{DropPrimaryIndexCommand{}})
+
+		commands, err := Migrator{Dialect: SQLite}.commands(s)
+
+		assert.Nil(t, err)
+		assert.Len(t, commands, 1)
+		assert.IsType(t, sqliteAlterTableCommand{}, commands[0])
+	})
+}
+
+func TestSQLiteTable(t *testing.T) {
+	create := `CREATE TABLE "posts" (` +
+		`"id" integer NOT NULL PRIMARY KEY AUTOINCREMENT, ` +
+		`"title" varchar(255) NOT NULL DEFAULT 'a, b', ` +
+		`"status" varchar(255) NOT NULL CHECK ("status" IN ('draft', 'published')), ` +
+		`"user_id" integer NULL, ` +
+		`CONSTRAINT "posts_user_id_foreign" FOREIGN KEY ("user_id") REFERENCES "users" ("id"))`
+
+	t.Run("it splits definitions", func(t *testing.T) {
+		table, err := parseSQLiteTable(create + " WITHOUT ROWID")
+
+		assert.Nil(t, err)
+		assert.Equal(t, []string{
+			`"id" integer NOT NULL PRIMARY KEY AUTOINCREMENT`,
+			`"title" varchar(255) NOT NULL DEFAULT 'a, b'`,
+			`"status" varchar(255) NOT NULL CHECK ("status" IN ('draft', 'published'))`,
+			`"user_id" integer NULL`,
+			`CONSTRAINT "posts_user_id_foreign" FOREIGN KEY ("user_id") REFERENCES "users" ("id")`,
+		}, table.definitions)
+		assert.Equal(t, " WITHOUT ROWID", table.options)
+		assert.Equal(t, []string{"id", "title", "status", "user_id"}, table.columns())
+	})
+
+	t.Run("it fails on unexpected definition", func(t *testing.T) {
+		_, err := parseSQLiteTable("CREATE TABLE posts")
+
+		assert.EqualError(t, err, "Unexpected table definition: CREATE TABLE posts")
+	})
+
+	t.Run("it applies changes", func(t *testing.T) {
+		table, _ := parseSQLiteTable(create)
+
+		assert.Nil(t, table.apply(ModifyColumnCommand{Name: "id", Column: Integer{Prefix: "big", Autoincrement: true}}))
+		assert.Nil(t, table.apply(ModifyColumnCommand{Name: "title", Column: Text{Nullable: true}}))
+		assert.Nil(t, table.apply(DropForeignCommand("posts_user_id_foreign")))
+		assert.Nil(t, table.apply(AddForeignCommand{Foreign{Key: "posts_author_foreign", Column: "user_id", Reference: "id", On: "authors"}}))
+
+		assert.Equal(t, []string{
+			`"id" integer NOT NULL PRIMARY KEY AUTOINCREMENT`,
+			`"title" text NULL`,
+			`"status" varchar(255) NOT NULL CHECK ("status" IN ('draft', 'published'))`,
+			`"user_id" integer NULL`,
+			`CONSTRAINT "posts_author_foreign" FOREIGN KEY ("user_id") REFERENCES "authors" ("id")`,
+		}, table.definitions)
+	})
+
+	t.Run("it replaces primary key", func(t *testing.T) {
+		table, _ := parseSQLiteTable(create)
+
+		assert.Nil(t, table.apply(DropPrimaryIndexCommand{}))
+		assert.Nil(t, table.apply(AddPrimaryIndexCommand("title")))
+
+		assert.Equal(t, `"id" integer NOT NULL`, table.definitions[0])
+		assert.Equal(t, `PRIMARY KEY ("title")`, table.definitions[len(table.definitions)-1])
+
+		assert.Nil(t, table.apply(DropPrimaryIndexCommand{}))
+		assert.NotContains(t, table.definitions, `PRIMARY KEY ("title")`)
+
+		assert.Nil(t, table.apply(AddCompositePrimaryIndexCommand{Columns: []string{"id", "title"}}))
+		assert.Equal(t, `PRIMARY KEY ("id", "title")`, table.definitions[len(table.definitions)-1])
+	})
+
+	t.Run("it fails on missing column, foreign key or primary key", func(t *testing.T) {
+		table, _ := parseSQLiteTable(`CREATE TABLE "tags" ("name" varchar(255) NOT NULL)`)
+
+		assert.EqualError(t, table.apply(ModifyColumnCommand{Name: "title", Column: Text{}}), `column "title" does not exist`)
+		assert.EqualError(t, table.apply(DropForeignCommand("tags_foreign")), `foreign key "tags_foreign" does not exist`)
+		assert.EqualError(t, table.apply(DropPrimaryIndexCommand{}), "primary key does not exist")
+	})
+}
+
+func TestSQLiteRebuild(t *testing.T) {
+	c := sqliteRebuild{table: "posts", changes: TableCommands{ModifyColumnCommand{Name: "title", Column: Text{Nullable: true}}}}
+	create := `CREATE TABLE "posts" ("id" integer NOT NULL PRIMARY KEY AUTOINCREMENT, "title" varchar(255) NOT NULL)`
+	index := `CREATE INDEX "posts_title_index" ON "posts" ("title")`
+
+	expectTable := func(mock sqlmock.Sqlmock) {
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT sql FROM sqlite_master WHERE type = 'table' AND name = ?")).
+			WithArgs("posts").
+			WillReturnRows(sqlmock.NewRows([]string{"sql"}).AddRow(create))
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT sql FROM sqlite_master WHERE type = 'index' AND tbl_name = ? AND sql IS NOT NULL")).
+			WithArgs("posts").
+			WillReturnRows(sqlmock.NewRows([]string{"sql"}).AddRow(index))
+	}
+
+	t.Run("it renders changes as ALTER TABLE", func(t *testing.T) {
+		assert.Equal(t, "ALTER TABLE `posts` MODIFY `title` text COLLATE utf8mb4_unicode_ci NULL", c.toSQL())
+	})
+
+	t.Run("it fails on missing table", func(t *testing.T) {
+		db, mock, resetDB := testDBConnection(t)
+		defer resetDB()
+
+		mock.ExpectQuery("SELECT sql FROM sqlite_master").WillReturnError(errTestDBQueryFailed)
+
+		assert.Equal(t, errTestDBQueryFailed, c.exec(db))
+	})
+
+	t.Run("it fails on changes, that can't be applied", func(t *testing.T) {
+		db, mock, resetDB := testDBConnection(t)
+		defer resetDB()
+
+		mock.ExpectQuery("SELECT sql FROM sqlite_master").WillReturnRows(sqlmock.NewRows([]string{"sql"}).AddRow(create))
+
+		err := sqliteRebuild{table: "posts", changes: TableCommands{DropForeignCommand("posts_user_id_foreign")}}.exec(db)
+
+		assert.EqualError(t, err, `Table "posts" can't be rebuilt: foreign key "posts_user_id_foreign" does not exist`)
+	})
+
+	t.Run("it refuses to rebuild table while foreign keys are enforced", func(t *testing.T) {
+		db, mock, resetDB := testDBConnection(t)
+		defer resetDB()
+
+		expectTable(mock)
+		mock.ExpectQuery("PRAGMA foreign_keys").WillReturnRows(sqlmock.NewRows([]string{"foreign_keys"}).AddRow(1))
+		mock.ExpectExec("PRAGMA foreign_keys = OFF").WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectQuery("PRAGMA foreign_keys").WillReturnRows(sqlmock.NewRows([]string{"foreign_keys"}).AddRow(1))
+		mock.ExpectExec("PRAGMA foreign_keys = ON").WillReturnResult(sqlmock.NewResult(0, 0))
+
+		err := c.exec(db)
+
+		assert.EqualError(t, err, `Table "posts" can't be rebuilt while foreign keys are enforced, e.g. within transaction`)
+		assert.Nil(t, mock.ExpectationsWereMet())
+	})
+
+	expectRebuild := func(mock sqlmock.Sqlmock) {
+		mock.ExpectBegin()
+		mock.ExpectExec(regexp.QuoteMeta(`CREATE TABLE "_posts_new" ("id" integer NOT NULL PRIMARY KEY AUTOINCREMENT, "title" text NULL)`)).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO "_posts_new" ("id", "title") SELECT "id", "title" FROM "posts"`)).
+			WillReturnResult(sqlmock.NewResult(0, 10))
+		mock.ExpectExec(regexp.QuoteMeta(`DROP TABLE "posts"`)).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec(regexp.QuoteMeta(`ALTER TABLE "_posts_new" RENAME TO "posts"`)).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec(regexp.QuoteMeta(index)).WillReturnResult(sqlmock.NewResult(0, 0))
+	}
+
+	t.Run("it rebuilds the table", func(t *testing.T) {
+		db, mock, resetDB := testDBConnection(t)
+		defer resetDB()
+
+		expectTable(mock)
+		mock.ExpectQuery("PRAGMA foreign_keys").WillReturnRows(sqlmock.NewRows([]string{"foreign_keys"}).AddRow(1))
+		mock.ExpectExec("PRAGMA foreign_keys = OFF").WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectQuery("PRAGMA foreign_keys").WillReturnRows(sqlmock.NewRows([]string{"foreign_keys"}).AddRow(0))
+		expectRebuild(mock)
+		mock.ExpectQuery(regexp.QuoteMeta(`PRAGMA foreign_key_check("posts")`)).WillReturnRows(sqlmock.NewRows(nil))
+		mock.ExpectCommit()
+		mock.ExpectExec("PRAGMA foreign_keys = ON").WillReturnResult(sqlmock.NewResult(0, 0))
+
+		assert.Nil(t, c.exec(db))
+		assert.Nil(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("it rebuilds the table without foreign key check, when they are not enforced", func(t *testing.T) {
+		db, mock, resetDB := testDBConnection(t)
+		defer resetDB()
+
+		expectTable(mock)
+		mock.ExpectQuery("PRAGMA foreign_keys").WillReturnRows(sqlmock.NewRows([]string{"foreign_keys"}).AddRow(0))
+		expectRebuild(mock)
+		mock.ExpectCommit()
+
+		assert.Nil(t, c.exec(db))
+		assert.Nil(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("it keeps the table, when rows violate foreign keys", func(t *testing.T) {
+		db, mock, resetDB := testDBConnection(t)
+		defer resetDB()
+
+		expectTable(mock)
+		mock.ExpectQuery("PRAGMA foreign_keys").WillReturnRows(sqlmock.NewRows([]string{"foreign_keys"}).AddRow(1))
+		mock.ExpectExec("PRAGMA foreign_keys = OFF").WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectQuery("PRAGMA foreign_keys").WillReturnRows(sqlmock.NewRows([]string{"foreign_keys"}).AddRow(0))
+		expectRebuild(mock)
+		mock.ExpectQuery(regexp.QuoteMeta(`PRAGMA foreign_key_check("posts")`)).WillReturnRows(
+			sqlmock.NewRows([]string{"table", "rowid", "parent", "fkid"}).AddRow("posts", 1, "users", 0),
+		)
+		mock.ExpectRollback()
+		mock.ExpectExec("PRAGMA foreign_keys = ON").WillReturnResult(sqlmock.NewResult(0, 0))
+
+		err := c.exec(db)
+
+		assert.EqualError(t, err, `Table "posts" can't be rebuilt: rows violate its foreign keys`)
+		assert.Nil(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("it keeps the table, when rebuild fails", func(t *testing.T) {
+		db, mock, resetDB := testDBConnection(t)
+		defer resetDB()
+
+		expectTable(mock)
+		mock.ExpectQuery("PRAGMA foreign_keys").WillReturnRows(sqlmock.NewRows([]string{"foreign_keys"}).AddRow(0))
+		mock.ExpectBegin()
+		mock.ExpectExec("CREATE TABLE").WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec("INSERT INTO").WillReturnError(errTestDBExecFailed)
+		mock.ExpectRollback()
+
+		assert.Equal(t, errTestDBExecFailed, c.exec(db))
+		assert.Nil(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestSQLiteIdentifier(t *testing.T) {
+	name, rest := sqliteIdentifier(`"user id" integer`)
+	assert.Equal(t, "user id", name)
+	assert.Equal(t, "integer", rest)
+
+	name, rest = sqliteIdentifier("PRIMARY KEY(id)")
+	assert.Equal(t, "PRIMARY", name)
+	assert.Equal(t, "KEY(id)", rest)
+
+	assert.True(t, isSQLiteConstraint(`CONSTRAINT "fk" FOREIGN KEY ("a") REFERENCES "b" ("id")`))
+	assert.True(t, isSQLiteConstraint(`unique ("a")`))
+	assert.False(t, isSQLiteConstraint(`"check" integer`))
+}
@@ -0,0 +1,186 @@
+package migrator
+
+import (
+	"database/sql"
+	"fmt"
+)
+
+// Squash replays Up() of the migrations in memory and returns Go source of a single baseline migration,
+// that creates the resulting schema and replaces them, e.g. for the first migrations of a long pool:
+//
+//		source, err := migrator.Generator{Package: "migrations"}.Squash("20261018_0000_baseline", pool[:500])
+//
+// Baseline migration runs on a new database instead of the replaced ones, while on existing database,
+// where all of them are applied, it is stored in migration table instead of them on the next Migrate.
+// Replaced migrations might be removed from the pool, once the baseline is stored everywhere.
+//
+// Backfills are skipped, as there are no rows to update in a new database.
+// Custom commands can't be replayed, so such migrations can't be squashed.
+func (g Generator) Squash(name string, squashed []Migration) ([]byte, error) {
+	if len(squashed) == 0 {
+		return nil, ErrNoMigrationDefined
+	}
+
+	model := &schemaModel{}
+	replaces := make([]string, len(squashed))
+
+	for i, item := range squashed {
+		if item.Up == nil {
+			return nil, fmt.Errorf(`Migration "%s" can't be squashed: Up is missing`, item.Name)
+		}
+
+		for _, c := range item.Up().pool {
+			switch c.(type) {
+			case createTableCommand, dropTableCommand, renameTableCommand, alterTableCommand:
+				model.apply(c)
+			case BackfillCommand:
+			default:
+				return nil, fmt.Errorf(`Migration "%s" can't be squashed: "%s" can't be replayed`, item.Name, c.toSQL())
+			}
+		}
+
+		replaces[i] = item.Name
+	}
+
+	if len(model.tables) == 0 {
+		return nil, ErrNoSchemaChanges
+	}
+
+	up, down := baselineSchemas(model.tables)
+
+	return g.source(name, replaces, up, down)
+}
+
+// baselineSchemas creates tables in the order they were created by replayed migrations.
+// Foreign keys, that reference tables created later, are added after all tables are created.
+func baselineSchemas(tables []Table) (up Schema, down Schema) {
+	created := map[string]bool{}
+	deferred := map[string]TableCommands{}
+	var order []string
+
+	for _, t := range tables {
+		table := t
+		table.foreigns = nil
+
+		for _, f := range t.foreigns {
+			if f.On != t.Name && !created[f.On] {
+				if _, ok := deferred[t.Name]; !ok {
+					order = append(order, t.Name)
+				}
+				deferred[t.Name] = append(deferred[t.Name], AddForeignCommand{Foreign: f})
+				continue
+			}
+
+			table.foreigns = append(table.foreigns, f)
+		}
+
+		up.CreateTable(table)
+		created[t.Name] = true
+	}
+
+	for _, name := range order {
+		up.AlterTable(name, deferred[name])
+
+		var drop TableCommands
+		for _, c := range deferred[name] {
+			drop = append(drop, DropForeignCommand(c.(AddForeignCommand).Foreign.Key))
+		}
+		down.AlterTable(name, drop)
+	}
+
+	for i := len(tables) - 1; i >= 0; i-- {
+		down.DropTableIfExists(tables[i].Name)
+	}
+
+	return up, down
+}
+
+// replaced maps names of replaced migrations to their baseline migrations
+func (m Migrator) replaced() map[string]string {
+	replaced := map[string]string{}
+
+	for _, item := range m.Pool {
+		for _, name := range item.Replaces {
+			replaced[name] = item.Name
+		}
+	}
+
+	return replaced
+}
+
+// executedReplaced returns names of executed migrations, that are replaced by the baseline migration
+func (m Migrator) executedReplaced(baseline Migration) (names []string) {
+	for _, name := range baseline.Replaces {
+		if m.isExecuted(name) {
+			names = append(names, name)
+		}
+	}
+
+	return names
+}
+
+// coverReplaced stores baseline migrations, which replaced migrations are all executed, instead of them.
+// Baseline gets the earliest batch and time of replaced migrations, so it's reverted after migrations applied later.
+func (m *Migrator) coverReplaced(db *sql.DB) error {
+	covered := false
+
+	for _, item := range m.Pool {
+		if len(item.Replaces) == 0 || m.isExecuted(item.Name) || len(m.executedReplaced(item)) < len(item.Replaces) {
+			continue
+		}
+
+		if err := m.cover(db, item); err != nil {
+			return fmt.Errorf(`Migration "%s" failed to replace squashed migrations: %v`, item.Name, err)
+		}
+		covered = true
+	}
+
+	if !covered {
+		return nil
+	}
+
+	return m.fetchExecuted(db)
+}
+
+func (m Migrator) cover(db *sql.DB, baseline Migration) error {
+	replaced := list(baseline.Replaces)
+	var entries []migrationEntry
+
+	for _, entry := range m.executed {
+		if replaced.has(entry.name) {
+			entries = append(entries, entry)
+		}
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+
+	d := m.dialect()
+	_, err = tx.Exec(
+		d.rebind(fmt.Sprintf(
+			"INSERT INTO %s (%s, %s, %s) VALUES (?, ?, ?)",
+			d.quote(m.table()),
+			d.quote("name"),
+			d.quote("batch"),
+			d.quote("applied_at"),
+		)),
+		baseline.Name,
+		entries[0].batch,
+		entries[0].appliedAt,
+	)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	for _, entry := range entries {
+		if err := m.deleteEntry(tx, entry.id); err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
@@ -0,0 +1,267 @@
+package migrator
+
+import (
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func testSquashedPool() []Migration {
+	return []Migration{
+		{Name: "create_posts", Up: func() Schema {
+			var s Schema
+			posts := Table{Name: "posts"}
+			posts.ID("id")
+			posts.Column("author_id", Integer{Prefix: "big", Unsigned: true})
+			posts.Foreign("author_id", "id", "users", "", "cascade")
+			s.CreateTable(posts)
+			return s
+		}},
+		{Name: "create_users", Up: func() Schema {
+			var s Schema
+			users := Table{Name: "users"}
+			users.ID("id")
+			users.Varchar("name", 255)
+			s.CreateTable(users)
+			s.Backfill(BackfillCommand{Table: "users", Set: "name = ''"})
+			return s
+		}},
+		{Name: "add_email", Up: func() Schema {
+			var s Schema
+			s.AlterTable("users", TableCommands{AddColumnCommand{Name: "email", Column: String{Precision: 255}}})
+			return s
+		}},
+	}
+}
+
+func TestSquash(t *testing.T) {
+	t.Run("it requires migrations", func(t *testing.T) {
+		_, err := Generator{}.Squash("baseline", nil)
+
+		assert.Equal(t, ErrNoMigrationDefined, err)
+	})
+
+	t.Run("it requires Up of every migration", func(t *testing.T) {
+		_, err := Generator{}.Squash("baseline", []Migration{{Name: "first"}})
+
+		assert.EqualError(t, err, `Migration "first" can't be squashed: Up is missing`)
+	})
+
+	t.Run("it refuses custom commands", func(t *testing.T) {
+		_, err := Generator{}.Squash("baseline", []Migration{{Name: "first", Up: func() Schema {
+			var s Schema
+			s.CustomCommand(testCommand("posts"))
+			return s
+		}}})
+
+		assert.EqualError(t, err, `Migration "first" can't be squashed: "Do action on posts" can't be replayed`)
+	})
+
+	t.Run("it returns error when no tables are left", func(t *testing.T) {
+		_, err := Generator{}.Squash("baseline", []Migration{{Name: "first", Up: func() Schema {
+			var s Schema
+			s.CreateTable(Table{Name: "posts"})
+			s.DropTable("posts", false, "")
+			return s
+		}}})
+
+		assert.Equal(t, ErrNoSchemaChanges, err)
+	})
+
+	t.Run("it generates baseline migration", func(t *testing.T) {
+		source, err := Generator{}.Squash("baseline", testSquashedPool())
+
+		assert.Nil(t, err)
+		assert.Equal(t, `// Migration is generated by migrator.Generator, review it before running.
+
+package migrations
+
+import "github.com/larapulse/migrator"
+
+var migrationBaseline = migrator.Migration{
+	Name: "baseline",
+	Replaces: []string{
+		"create_posts",
+		"create_users",
+		"add_email",
+	},
+	Up: func() migrator.Schema {
+		var s migrator.Schema
+
+		postsTable := migrator.Table{Name: "posts"}
+		postsTable.Column("id", migrator.Integer{Prefix: "big", Unsigned: true, Autoincrement: true})
+		postsTable.Column("author_id", migrator.Integer{Prefix: "big", Unsigned: true})
+		postsTable.Primary("id")
+		postsTable.Index("posts_author_id_foreign", "author_id")
+		s.CreateTable(postsTable)
+
+		usersTable := migrator.Table{Name: "users"}
+		usersTable.Column("id", migrator.Integer{Prefix: "big", Unsigned: true, Autoincrement: true})
+		usersTable.Column("name", migrator.String{Precision: 255})
+		usersTable.Column("email", migrator.String{Precision: 255})
+		usersTable.Primary("id")
+		s.CreateTable(usersTable)
+
+		s.AlterTable("posts", migrator.TableCommands{
+			migrator.AddForeignCommand{Foreign: migrator.Foreign{Key: "posts_author_id_foreign", Column: "author_id", Reference: "id", On: "users", OnDelete: "cascade"}},
+		})
+
+		return s
+	},
+	Down: func() migrator.Schema {
+		var s migrator.Schema
+
+		s.AlterTable("posts", migrator.TableCommands{
+			migrator.DropForeignCommand("posts_author_id_foreign"),
+		})
+
+		s.DropTableIfExists("users")
+		s.DropTableIfExists("posts")
+
+		return s
+	},
+}
+`, string(source))
+	})
+
+	t.Run("it repoints foreign keys to renamed table", func(t *testing.T) {
+		source, err := Generator{}.Squash("baseline", []Migration{
+			{Name: "create_tables", Up: func() Schema {
+				var s Schema
+				users := Table{Name: "users"}
+				users.ID("id")
+				s.CreateTable(users)
+
+				posts := Table{Name: "posts"}
+				posts.ID("id")
+				posts.Column("author_id", Integer{Prefix: "big", Unsigned: true})
+				posts.Foreign("author_id", "id", "users", "", "")
+				s.CreateTable(posts)
+				return s
+			}},
+			{Name: "rename_users", Up: func() Schema {
+				var s Schema
+				s.RenameTable("users", "accounts")
+				return s
+			}},
+		})
+
+		assert.Nil(t, err)
+		assert.Contains(t, string(source), `accountsTable := migrator.Table{Name: "accounts"}`)
+		assert.Contains(t, string(source), `postsTable.Foreign("author_id", "id", "accounts", "", "")`)
+		assert.NotContains(t, string(source), `"users"`)
+	})
+}
+
+func TestReplaces(t *testing.T) {
+	baseline := Migration{Name: "baseline", Replaces: []string{"first", "second"}, Up: func() Schema {
+		var s Schema
+		s.CreateTable(Table{Name: "posts"})
+		return s
+	}}
+	first := Migration{Name: "first", Up: func() Schema {
+		var s Schema
+		s.CreateTable(Table{Name: "posts"})
+		return s
+	}}
+	second := Migration{Name: "second", Up: func() Schema {
+		var s Schema
+		s.AlterTable("posts", TableCommands{DropColumnCommand("title")})
+		return s
+	}}
+
+	t.Run("it validates replaced migrations in the pool", func(t *testing.T) {
+		m := Migrator{Pool: []Migration{{Name: "baseline", Replaces: []string{"baseline"}}}}
+		assert.EqualError(t, m.checkMigrationPool(), `Migration "baseline" can't replace itself`)
+
+		m = Migrator{Pool: []Migration{{Name: "a", Replaces: []string{"first"}}, {Name: "b", Replaces: []string{"first"}}}}
+		assert.EqualError(t, m.checkMigrationPool(), `Migration "first" is replaced by both "a" and "b"`)
+	})
+
+	t.Run("it runs baseline instead of replaced migrations on a new database", func(t *testing.T) {
+		m := Migrator{Pool: []Migration{first, second, baseline}}
+
+		pending, _, err := m.pending()
+
+		assert.Nil(t, err)
+		assert.Len(t, pending, 1)
+		assert.Equal(t, "baseline", pending[0].Name)
+	})
+
+	t.Run("it skips baseline when replaced migrations are applied", func(t *testing.T) {
+		m := Migrator{Pool: []Migration{baseline}, executed: []migrationEntry{{name: "first"}, {name: "second"}}}
+
+		pending, _, err := m.pending()
+
+		assert.Nil(t, err)
+		assert.Len(t, pending, 0)
+	})
+
+	t.Run("it refuses partially applied replaced migrations", func(t *testing.T) {
+		m := Migrator{Pool: []Migration{first, second, baseline}, executed: []migrationEntry{{name: "first"}}}
+
+		_, _, err := m.pending()
+
+		assert.EqualError(t, err, `Migration "baseline" can't be applied, migrations it replaces are applied partially: "first"`)
+	})
+
+	t.Run("it keeps replaced migrations on repair", func(t *testing.T) {
+		m := Migrator{Pool: []Migration{baseline}}
+
+		assert.True(t, m.inPool("first"))
+		assert.False(t, m.inPool("third"))
+	})
+
+	t.Run("it stores baseline instead of applied replaced migrations", func(t *testing.T) {
+		m := Migrator{Pool: []Migration{baseline}}
+		db, mock, resetDB := testDBConnection(t)
+		defer resetDB()
+
+		appliedAt := time.Date(2026, 10, 18, 9, 30, 0, 0, time.UTC)
+		mock.ExpectQuery("SELECT").WillReturnRows(sqlmock.NewRows(nil))
+		mock.ExpectQuery("SELECT id, name, batch, applied_at FROM `migrations`").WillReturnRows(
+			sqlmock.NewRows([]string{"id", "name", "batch", "applied_at"}).
+				AddRow(1, "first", 1, appliedAt).
+				AddRow(2, "second", 2, appliedAt.Add(time.Hour)),
+		)
+		mock.ExpectBegin()
+		mock.ExpectExec("INSERT INTO `migrations` \\(`name`, `batch`, `applied_at`\\) VALUES \\(\\?, \\?, \\?\\)").
+			WithArgs("baseline", 1, appliedAt).WillReturnResult(sqlmock.NewResult(3, 1))
+		mock.ExpectExec("DELETE FROM `migrations` WHERE id = \\?").WithArgs(1).WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec("DELETE FROM `migrations` WHERE id = \\?").WithArgs(2).WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+		mock.ExpectQuery("SELECT id, name, batch, applied_at FROM `migrations`").WillReturnRows(
+			sqlmock.NewRows([]string{"id", "name", "batch", "applied_at"}).AddRow(3, "baseline", 1, appliedAt),
+		)
+
+		migrated, err := m.Migrate(db)
+
+		assert.Nil(t, err)
+		assert.Len(t, migrated, 0)
+		assert.Nil(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("it rolls back failed replacement", func(t *testing.T) {
+		m := Migrator{Pool: []Migration{baseline}}
+		db, mock, resetDB := testDBConnection(t)
+		defer resetDB()
+
+		mock.ExpectQuery("SELECT").WillReturnRows(sqlmock.NewRows(nil))
+		mock.ExpectQuery("SELECT id, name, batch, applied_at FROM `migrations`").WillReturnRows(
+			sqlmock.NewRows([]string{"id", "name", "batch", "applied_at"}).
+				AddRow(1, "first", 1, time.Now()).
+				AddRow(2, "second", 2, time.Now()),
+		)
+		mock.ExpectBegin()
+		mock.ExpectExec("INSERT").WillReturnResult(sqlmock.NewResult(3, 1))
+		mock.ExpectExec("DELETE").WillReturnError(errTestDBExecFailed)
+		mock.ExpectRollback()
+
+		_, err := m.Migrate(db)
+
+		assert.EqualError(t, err, `Migration "baseline" failed to replace squashed migrations: `+errTestDBExecFailed.Error())
+		assert.Nil(t, mock.ExpectationsWereMet())
+	})
+}
@@ -195,6 +195,19 @@ func (c AddPrimaryIndexCommand) toSQL() string {
 	return fmt.Sprintf("ADD PRIMARY KEY (`%s`)", c)
 }
 
+// AddCompositePrimaryIndexCommand is a command to add a primary key on multiple columns.
+type AddCompositePrimaryIndexCommand struct {
+	Columns []string
+}
+
+func (c AddCompositePrimaryIndexCommand) toSQL() string {
+	if len(c.Columns) == 0 {
+		return ""
+	}
+
+	return fmt.Sprintf("ADD PRIMARY KEY (`%s`)", strings.Join(c.Columns, "`, `"))
+}
+
 // DropPrimaryIndexCommand is a command to remove the primary key from the table.
 type DropPrimaryIndexCommand struct{}
 
@@ -215,6 +215,18 @@ func TestAddPrimaryIndexCommand(t *testing.T) {
 	})
 }
 
+func TestAddCompositePrimaryIndexCommand(t *testing.T) {
+	t.Run("it returns an empty string if columns are missing", func(t *testing.T) {
+		c := AddCompositePrimaryIndexCommand{}
+		assert.Equal(t, "", c.toSQL())
+	})
+
+	t.Run("it returns a proper row", func(t *testing.T) {
+		c := AddCompositePrimaryIndexCommand{Columns: []string{"post_id", "tag_id"}}
+		assert.Equal(t, "ADD PRIMARY KEY (`post_id`, `tag_id`)", c.toSQL())
+	})
+}
+
 func TestDropPrimaryIndexCommand(t *testing.T) {
 	c := DropPrimaryIndexCommand{}
 	assert.Equal(t, "DROP PRIMARY KEY", c.toSQL())
@@ -0,0 +1,447 @@
+package migrator
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var errVerifyNotSupported = errors.New("Schema can be verified with MySQL dialect only")
+
+// Drift describes a difference between the schema expected by applied migrations and the database.
+type Drift struct {
+	Table   string
+	Message string
+}
+
+func (d Drift) String() string {
+	return fmt.Sprintf(`Table "%s": %s`, d.Table, d.Message)
+}
+
+// Verify builds the expected schema by replaying Up() of applied migrations in memory
+// and compares it with the current database, e.g. to catch manual changes on a nightly check.
+// It reports missing and extra tables, columns, indexes and foreign keys, and columns with different definitions.
+//
+// Custom commands and backfills can't be replayed, so their changes are not expected.
+// Integer display width and case of default value expressions are not compared,
+// as they depend on the version of MySQL server.
+func (m Migrator) Verify(db *sql.DB) (drifts []Drift, err error) {
+	if len(m.Pool) == 0 {
+		return drifts, ErrNoMigrationDefined
+	}
+
+	if !isMySQL(m.dialect()) {
+		return drifts, errVerifyNotSupported
+	}
+
+	if err := m.checkMigrationPool(); err != nil {
+		return drifts, err
+	}
+
+	if !m.hasTable(db) {
+		return drifts, ErrTableNotExists
+	}
+
+	if err := m.fetchExecuted(db); err != nil {
+		return drifts, err
+	}
+
+	expected := m.expectedSchema()
+
+	for _, t := range expected.tables {
+		current, server, exists, err := inspectTable(db, t.Name)
+		if err != nil {
+			return drifts, err
+		}
+
+		if !exists {
+			drifts = append(drifts, Drift{Table: t.Name, Message: "table is missing"})
+			continue
+		}
+
+		for _, message := range compareTables(adaptExpected(t, server), current) {
+			drifts = append(drifts, Drift{Table: t.Name, Message: message})
+		}
+	}
+
+	tables, err := databaseTables(db)
+	if err != nil {
+		return drifts, err
+	}
+
+	for _, name := range tables {
+		if !expected.has(name) && !m.isServiceTable(name, expected) {
+			drifts = append(drifts, Drift{Table: name, Message: "table is not expected"})
+		}
+	}
+
+	return drifts, nil
+}
+
+// expectedSchema replays Up() of executed migrations in the order they were applied
+func (m Migrator) expectedSchema() *schemaModel {
+	pool := map[string]Migration{}
+	for _, item := range m.Pool {
+		pool[item.Name] = item
+	}
+
+	model := &schemaModel{}
+	for _, entry := range m.executed {
+		item, ok := pool[entry.name]
+		if !ok || item.Up == nil {
+			continue
+		}
+
+		for _, c := range item.Up().pool {
+			model.apply(c)
+		}
+	}
+
+	return model
+}
+
+// adaptExpected adapts columns of the expected table the same way they are adapted on migration,
+// e.g. UUID_TO_BIN() default is created with UNHEX() on MariaDB. Columns unsupported by the server are kept as is.
+func adaptExpected(t Table, server serverVersion) Table {
+	adapted, err := server.adapt(createTableCommand{t})
+	if err != nil {
+		return t
+	}
+
+	return adapted.(createTableCommand).t
+}
+
+// isServiceTable reports migration and progress tables, as well as shadow and old tables of online migrations
+func (m Migrator) isServiceTable(name string, expected *schemaModel) bool {
+	if name == m.table() || name == m.progressTable() {
+		return true
+	}
+
+	for _, t := range expected.tables {
+		if name == "_"+t.Name+"_new" || name == "_"+t.Name+"_old" {
+			return true
+		}
+	}
+
+	return false
+}
+
+func databaseTables(db executableSQL) ([]string, error) {
+	rows, err := db.Query(
+		"SELECT TABLE_NAME FROM information_schema.TABLES WHERE TABLE_SCHEMA = DATABASE() AND TABLE_TYPE = 'BASE TABLE' ORDER BY TABLE_NAME",
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var tables []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, err
+		}
+
+		tables = append(tables, name)
+	}
+
+	return tables, rows.Err()
+}
+
+// compareTables reports differences of columns, indexes and foreign keys between expected and current tables
+func compareTables(expected Table, current Table) (messages []string) {
+	currentColumns := map[string]columnType{}
+	for _, c := range current.columns {
+		currentColumns[c.field] = c.definition
+	}
+	expectedColumns := map[string]bool{}
+
+	for _, c := range expected.columns {
+		expectedColumns[c.field] = true
+
+		definition, ok := currentColumns[c.field]
+		if !ok {
+			messages = append(messages, fmt.Sprintf(`column "%s" is missing`, c.field))
+			continue
+		}
+
+		if want, got := comparableRow(c.definition), comparableRow(definition); want != got {
+			messages = append(messages, fmt.Sprintf(`column "%s" is "%s", expected "%s"`, c.field, got, want))
+		}
+	}
+
+	for _, c := range current.columns {
+		if !expectedColumns[c.field] {
+			messages = append(messages, fmt.Sprintf(`column "%s" is not expected`, c.field))
+		}
+	}
+
+	currentKeys := map[string]Key{}
+	for _, k := range current.indexes {
+		currentKeys[keyIdentity(k)] = k
+	}
+	expectedKeys := map[string]bool{}
+
+	for _, k := range expected.indexes {
+		expectedKeys[keyIdentity(k)] = true
+
+		key, ok := currentKeys[keyIdentity(k)]
+		if !ok {
+			messages = append(messages, fmt.Sprintf("index %s is missing", k.render()))
+			continue
+		}
+
+		if !sameKey(k, key) {
+			messages = append(messages, fmt.Sprintf("index %s is %s", k.render(), key.render()))
+		}
+	}
+
+	for _, k := range current.indexes {
+		if !expectedKeys[keyIdentity(k)] {
+			messages = append(messages, fmt.Sprintf("index %s is not expected", k.render()))
+		}
+	}
+
+	currentForeigns := map[string]Foreign{}
+	for _, f := range current.foreigns {
+		currentForeigns[f.Key] = f
+	}
+	expectedForeigns := map[string]bool{}
+
+	for _, f := range expected.foreigns {
+		expectedForeigns[f.Key] = true
+
+		foreign, ok := currentForeigns[f.Key]
+		if !ok {
+			messages = append(messages, fmt.Sprintf(`foreign key "%s" is missing`, f.Key))
+			continue
+		}
+
+		if !sameForeign(f, foreign) {
+			messages = append(messages, fmt.Sprintf(`foreign key "%s" is "%s", expected "%s"`, f.Key, foreign.render(), f.render()))
+		}
+	}
+
+	for _, f := range current.foreigns {
+		if !expectedForeigns[f.Key] {
+			messages = append(messages, fmt.Sprintf(`foreign key "%s" is not expected`, f.Key))
+		}
+	}
+
+	return messages
+}
+
+// schemaModel is an in-memory model of the schema, that is built by applying commands
+type schemaModel struct {
+	tables []Table
+}
+
+func (s *schemaModel) find(name string) int {
+	for i, t := range s.tables {
+		if t.Name == name {
+			return i
+		}
+	}
+
+	return -1
+}
+
+func (s *schemaModel) has(name string) bool {
+	return s.find(name) >= 0
+}
+
+func (s *schemaModel) apply(c command) {
+	switch v := c.(type) {
+	case createTableCommand:
+		t := v.t
+		t.columns = append(columns{}, v.t.columns...)
+		t.indexes = append(keys{}, v.t.indexes...)
+		t.foreigns = append(foreigns{}, v.t.foreigns...)
+		if len(t.columns) == 0 {
+			t.columns = columns{{"id", Integer{Prefix: "big", Unsigned: true, Precision: 20, Autoincrement: true}}}
+		}
+		for _, f := range t.foreigns {
+			t.indexes = withForeignIndex(t.indexes, f)
+		}
+
+		if i := s.find(t.Name); i >= 0 {
+			s.tables[i] = t
+		} else {
+			s.tables = append(s.tables, t)
+		}
+	case dropTableCommand:
+		if i := s.find(v.table); i >= 0 {
+			s.tables = append(s.tables[:i], s.tables[i+1:]...)
+		}
+	case renameTableCommand:
+		if i := s.find(v.old); i >= 0 {
+			s.tables[i].Name = v.new
+		}
+
+		// InnoDB repoints foreign keys of other tables to the renamed one
+		for i, t := range s.tables {
+			if len(t.foreigns) == 0 {
+				continue
+			}
+
+			f := make(foreigns, len(t.foreigns))
+			for j, foreign := range t.foreigns {
+				if foreign.On == v.old {
+					foreign.On = v.new
+				}
+				f[j] = foreign
+			}
+			s.tables[i].foreigns = f
+		}
+	case alterTableCommand:
+		if i := s.find(v.name); i >= 0 {
+			for _, tc := range v.pool {
+				s.tables[i] = alterModel(s.tables[i], tc)
+			}
+		}
+	}
+}
+
+// alterModel applies the table command to the table, slices of the table are never modified in place
+func alterModel(t Table, c command) Table {
+	switch v := c.(type) {
+	case AddColumnCommand:
+		position := len(t.columns)
+		if v.First {
+			position = 0
+		}
+		for i, col := range t.columns {
+			if v.After != "" && col.field == strings.Trim(v.After, "`") {
+				position = i + 1
+			}
+		}
+
+		cols := append(columns{}, t.columns[:position]...)
+		cols = append(cols, column{field: v.Name, definition: v.Column})
+		t.columns = append(cols, t.columns[position:]...)
+	case ModifyColumnCommand:
+		t.columns = replaceColumn(t.columns, v.Name, v.Name, v.Column)
+	case ChangeColumnCommand:
+		t.columns = replaceColumn(t.columns, v.From, v.To, v.Column)
+		t = renameModelColumn(t, v.From, v.To)
+	case RenameColumnCommand:
+		var definition columnType
+		for _, col := range t.columns {
+			if col.field == v.Old {
+				definition = col.definition
+			}
+		}
+		t.columns = replaceColumn(t.columns, v.Old, v.New, definition)
+		t = renameModelColumn(t, v.Old, v.New)
+	case DropColumnCommand:
+		var cols columns
+		for _, col := range t.columns {
+			if col.field != string(v) {
+				cols = append(cols, col)
+			}
+		}
+		t.columns = cols
+
+		var k keys
+		for _, key := range t.indexes {
+			var keyColumns []string
+			for _, name := range key.Columns {
+				if name != string(v) {
+					keyColumns = append(keyColumns, name)
+				}
+			}
+			if len(keyColumns) > 0 {
+				key.Columns = keyColumns
+				k = append(k, key)
+			}
+		}
+		t.indexes = k
+	case AddIndexCommand:
+		t.indexes = append(append(keys{}, t.indexes...), Key{Name: v.Name, Columns: v.Columns})
+	case AddUniqueIndexCommand:
+		t.indexes = append(append(keys{}, t.indexes...), Key{Name: v.Key, Type: "unique", Columns: v.Columns})
+	case AddPrimaryIndexCommand:
+		t.indexes = append(keys{{Type: "primary", Columns: []string{string(v)}}}, t.indexes...)
+	case AddCompositePrimaryIndexCommand:
+		t.indexes = append(keys{{Type: "primary", Columns: append([]string{}, v.Columns...)}}, t.indexes...)
+	case DropIndexCommand:
+		t.indexes = removeKey(t.indexes, string(v))
+	case DropPrimaryIndexCommand:
+		t.indexes = removeKey(t.indexes, "PRIMARY")
+	case AddForeignCommand:
+		t.foreigns = append(append(foreigns{}, t.foreigns...), v.Foreign)
+		t.indexes = withForeignIndex(t.indexes, v.Foreign)
+	case DropForeignCommand:
+		var f foreigns
+		for _, foreign := range t.foreigns {
+			if foreign.Key != string(v) {
+				f = append(f, foreign)
+			}
+		}
+		t.foreigns = f
+	}
+
+	return t
+}
+
+func replaceColumn(c columns, from string, to string, definition columnType) columns {
+	result := make(columns, len(c))
+	for i, col := range c {
+		result[i] = col
+		if col.field == from {
+			result[i] = column{field: to, definition: definition}
+		}
+	}
+
+	return result
+}
+
+// renameModelColumn renames the column within indexes and foreign keys of the table
+func renameModelColumn(t Table, from string, to string) Table {
+	k := make(keys, len(t.indexes))
+	for i, key := range t.indexes {
+		key.Columns = append([]string{}, key.Columns...)
+		for j, name := range key.Columns {
+			if name == from {
+				key.Columns[j] = to
+			}
+		}
+		k[i] = key
+	}
+	t.indexes = k
+
+	f := make(foreigns, len(t.foreigns))
+	for i, foreign := range t.foreigns {
+		if foreign.Column == from {
+			foreign.Column = to
+		}
+		f[i] = foreign
+	}
+	t.foreigns = f
+
+	return t
+}
+
+func removeKey(k keys, identity string) keys {
+	var result keys
+	for _, key := range k {
+		if keyIdentity(key) != identity {
+			result = append(result, key)
+		}
+	}
+
+	return result
+}
+
+// withForeignIndex adds an index named after the foreign key, when there is no index starting with its column,
+// as InnoDB creates it implicitly
+func withForeignIndex(k keys, f Foreign) keys {
+	for _, key := range k {
+		if len(key.Columns) > 0 && key.Columns[0] == f.Column {
+			return k
+		}
+	}
+
+	return append(append(keys{}, k...), Key{Name: f.Key, Columns: []string{f.Column}})
+}
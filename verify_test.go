@@ -0,0 +1,202 @@
+package migrator
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSchemaModel(t *testing.T) {
+	posts := Table{Name: "posts"}
+	posts.ID("id")
+	posts.Varchar("title", 255)
+	posts.Int("author_id", 0, true)
+	posts.Index("posts_title_index", "title")
+
+	model := &schemaModel{}
+	model.apply(createTableCommand{posts})
+	model.apply(createTableCommand{Table{Name: "logs"}})
+	assert.Equal(t, "`id` bigint(20) unsigned NOT NULL AUTO_INCREMENT", model.tables[1].columns.render())
+	model.apply(alterTableCommand{name: "posts", pool: TableCommands{
+		AddColumnCommand{Name: "rating", Column: Integer{Nullable: true}, After: "title"},
+		AddColumnCommand{Name: "uuid", Column: String{Fixed: true, Precision: 36}, First: true},
+		RenameColumnCommand{Old: "title", New: "name"},
+		ModifyColumnCommand{Name: "rating", Column: Floatable{}},
+		AddForeignCommand{Foreign: Foreign{Key: "posts_author_foreign", Column: "author_id", Reference: "id", On: "users"}},
+		AddUniqueIndexCommand{Key: "posts_uuid_unique", Columns: []string{"uuid"}},
+		DropColumnCommand("uuid"),
+	}})
+	model.apply(renameTableCommand{old: "logs", new: "events"})
+	model.apply(dropTableCommand{table: "events"})
+
+	assert.Len(t, model.tables, 1)
+	assert.Equal(
+		t,
+		"CREATE TABLE `posts` (`id` bigint unsigned NOT NULL AUTO_INCREMENT, `name` varchar(255) COLLATE utf8mb4_unicode_ci NOT NULL, "+
+			"`rating` float NOT NULL, `author_id` int unsigned NOT NULL, "+
+			"PRIMARY KEY (`id`), KEY `posts_title_index` (`name`), KEY `posts_author_foreign` (`author_id`), "+
+			"CONSTRAINT `posts_author_foreign` FOREIGN KEY (`author_id`) REFERENCES `users` (`id`)) "+
+			"ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci",
+		createTableCommand{model.tables[0]}.toSQL(),
+	)
+	assert.Equal(t, "`title` varchar(255) COLLATE utf8mb4_unicode_ci NOT NULL", posts.columns[1:2].render(), "original table is not changed")
+
+	model.apply(createTableCommand{Table{Name: "users"}})
+	model.apply(renameTableCommand{old: "users", new: "accounts"})
+
+	assert.Equal(t, "accounts", model.tables[0].foreigns[0].On, "foreign key is repointed to the renamed table")
+	assert.Equal(t, "accounts", model.tables[1].Name)
+}
+
+func TestVerify(t *testing.T) {
+	createPosts := Migration{Name: "create_posts", Up: func() Schema {
+		posts := Table{Name: "posts"}
+		posts.ID("id")
+		posts.Varchar("title", 255)
+		posts.Unique("title")
+
+		var s Schema
+		s.CreateTable(posts)
+		return s
+	}}
+	addRating := Migration{Name: "add_rating", Up: func() Schema {
+		var s Schema
+		s.AlterTable("posts", TableCommands{AddColumnCommand{Name: "rating", Column: Integer{Nullable: true}, After: "title"}})
+		s.CreateTable(Table{Name: "users"})
+		s.Backfill(BackfillCommand{Table: "posts", Set: "rating = 0"})
+		return s
+	}}
+	pending := Migration{Name: "pending", Up: func() Schema {
+		var s Schema
+		s.DropTable("posts", false, "")
+		return s
+	}}
+
+	t.Run("it fails when migration pool is empty", func(t *testing.T) {
+		db, _, resetDB := testDBConnection(t)
+		defer resetDB()
+
+		_, err := Migrator{}.Verify(db)
+
+		assert.Equal(t, ErrNoMigrationDefined, err)
+	})
+
+	t.Run("it refuses other dialects", func(t *testing.T) {
+		db, _, resetDB := testDBConnection(t)
+		defer resetDB()
+
+		_, err := Migrator{Pool: []Migration{createPosts}, Dialect: PostgreSQL}.Verify(db)
+
+		assert.EqualError(t, err, "Schema can be verified with MySQL dialect only")
+	})
+
+	t.Run("it fails without migration table", func(t *testing.T) {
+		db, mock, resetDB := testDBConnection(t)
+		defer resetDB()
+
+		mock.ExpectQuery("SELECT").WillReturnError(errTestDBQueryFailed)
+
+		_, err := Migrator{Pool: []Migration{createPosts}}.Verify(db)
+
+		assert.Equal(t, ErrTableNotExists, err)
+	})
+
+	t.Run("it reports drift", func(t *testing.T) {
+		db, mock, resetDB := testDBConnection(t)
+		defer resetDB()
+
+		mock.ExpectQuery("SELECT").WillReturnRows(sqlmock.NewRows(nil))
+		mock.ExpectQuery("SELECT id, name, batch, applied_at FROM `migrations`").WillReturnRows(
+			sqlmock.NewRows([]string{"id", "name", "batch", "applied_at"}).
+				AddRow(1, "create_posts", 1, time.Now()).
+				AddRow(2, "add_rating", 2, time.Now()),
+		)
+		mock.ExpectQuery(testInspectTableQuery).WithArgs("posts").
+			WillReturnRows(testInspectTable(""))
+		mock.ExpectQuery(testInspectColumnsQuery).WithArgs("posts").WillReturnRows(
+			testInspectColumns().
+				AddRow("id", "bigint(20) unsigned", "NO", nil, "auto_increment", nil, "").
+				AddRow("title", "varchar(100)", "NO", nil, "", "utf8mb4_unicode_ci", "").
+				AddRow("hotfix", "int(11)", "YES", nil, "", nil, ""),
+		)
+		mock.ExpectQuery(testInspectIndexesQuery).WithArgs("posts").WillReturnRows(
+			sqlmock.NewRows([]string{"INDEX_NAME", "NON_UNIQUE", "COLUMN_NAME"}).
+				AddRow("PRIMARY", 0, "id").
+				AddRow("posts_title_unique", 1, "title").
+				AddRow("posts_hotfix_index", 1, "hotfix"),
+		)
+		mock.ExpectQuery(testInspectForeignQuery).WithArgs("posts").WillReturnRows(
+			sqlmock.NewRows([]string{"CONSTRAINT_NAME", "COLUMN_NAME", "REFERENCED_TABLE_NAME", "REFERENCED_COLUMN_NAME", "UPDATE_RULE", "DELETE_RULE"}).
+				AddRow("posts_hotfix_foreign", "hotfix", "users", "id", "RESTRICT", "RESTRICT"),
+		)
+		mock.ExpectQuery(testInspectTableQuery).WithArgs("users").WillReturnError(sql.ErrNoRows)
+		mock.ExpectQuery("SELECT TABLE_NAME FROM information_schema.TABLES").WillReturnRows(
+			sqlmock.NewRows([]string{"TABLE_NAME"}).
+				AddRow("_posts_old").
+				AddRow("migrations").
+				AddRow("migrations_progress").
+				AddRow("posts").
+				AddRow("tmp"),
+		)
+
+		m := Migrator{Pool: []Migration{createPosts, addRating, pending}}
+		drifts, err := m.Verify(db)
+
+		assert.Nil(t, err)
+		assert.Nil(t, mock.ExpectationsWereMet())
+		assert.Equal(t, []Drift{
+			{"posts", `column "title" is "varchar(100) COLLATE utf8mb4_unicode_ci NOT NULL", expected "varchar(255) COLLATE utf8mb4_unicode_ci NOT NULL"`},
+			{"posts", `column "rating" is missing`},
+			{"posts", `column "hotfix" is not expected`},
+			{"posts", "index UNIQUE KEY `posts_title_unique` (`title`) is KEY `posts_title_unique` (`title`)"},
+			{"posts", "index KEY `posts_hotfix_index` (`hotfix`) is not expected"},
+			{"posts", `foreign key "posts_hotfix_foreign" is not expected`},
+			{"users", "table is missing"},
+			{"tmp", "table is not expected"},
+		}, drifts)
+		assert.Equal(t, `Table "users": table is missing`, drifts[6].String())
+	})
+
+	t.Run("it adapts expected columns to MariaDB", func(t *testing.T) {
+		db, mock, resetDB := testDBConnection(t)
+		defer resetDB()
+
+		createTokens := Migration{Name: "create_tokens", Up: func() Schema {
+			tokens := Table{Name: "tokens"}
+			tokens.BinaryID("id")
+
+			var s Schema
+			s.CreateTable(tokens)
+			return s
+		}}
+
+		mock.ExpectQuery("SELECT").WillReturnRows(sqlmock.NewRows(nil))
+		mock.ExpectQuery("SELECT id, name, batch, applied_at FROM `migrations`").WillReturnRows(
+			sqlmock.NewRows([]string{"id", "name", "batch", "applied_at"}).AddRow(1, "create_tokens", 1, time.Now()),
+		)
+		mock.ExpectQuery(testInspectTableQuery).WithArgs("tokens").WillReturnRows(
+			sqlmock.NewRows([]string{"ENGINE", "TABLE_COLLATION", "TABLE_COMMENT", "VERSION()"}).
+				AddRow("InnoDB", "utf8mb4_unicode_ci", "", "10.6.12-MariaDB-1:10.6.12"),
+		)
+		mock.ExpectQuery(testInspectColumnsQuery).WithArgs("tokens").WillReturnRows(
+			testInspectColumns().AddRow("id", "binary(16)", "NO", "unhex(replace(uuid(),'-',''))", "", nil, ""),
+		)
+		mock.ExpectQuery(testInspectIndexesQuery).WithArgs("tokens").WillReturnRows(
+			sqlmock.NewRows([]string{"INDEX_NAME", "NON_UNIQUE", "COLUMN_NAME"}).AddRow("PRIMARY", 0, "id"),
+		)
+		mock.ExpectQuery(testInspectForeignQuery).WithArgs("tokens").WillReturnRows(
+			sqlmock.NewRows([]string{"CONSTRAINT_NAME", "COLUMN_NAME", "REFERENCED_TABLE_NAME", "REFERENCED_COLUMN_NAME", "UPDATE_RULE", "DELETE_RULE"}),
+		)
+		mock.ExpectQuery("SELECT TABLE_NAME FROM information_schema.TABLES").WillReturnRows(
+			sqlmock.NewRows([]string{"TABLE_NAME"}).AddRow("migrations").AddRow("tokens"),
+		)
+
+		drifts, err := Migrator{Pool: []Migration{createTokens}}.Verify(db)
+
+		assert.Nil(t, err)
+		assert.Len(t, drifts, 0)
+	})
+}
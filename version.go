@@ -0,0 +1,378 @@
+package migrator
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// serverVersion is a flavor and version of MySQL server
+type serverVersion struct {
+	raw     string
+	mariaDB bool
+	version [3]int
+}
+
+var serverVersionNumber = regexp.MustCompile(`^(\d+)\.(\d+)(?:\.(\d+))?`)
+
+// parseServerVersion parses result of `SELECT VERSION()`, e.g. "8.0.36", "5.7.44-log" or "10.6.12-MariaDB-1:10.6.12".
+// MariaDB might be reported with "5.5.5-" prefix for compatibility with replication protocol.
+func parseServerVersion(v string) (serverVersion, error) {
+	s := serverVersion{raw: v, mariaDB: strings.Contains(strings.ToLower(v), "mariadb")}
+
+	number := v
+	if s.mariaDB {
+		number = strings.TrimPrefix(number, "5.5.5-")
+	}
+
+	match := serverVersionNumber.FindStringSubmatch(number)
+	if match == nil {
+		return s, fmt.Errorf(`Unknown server version "%s"`, v)
+	}
+
+	for i := range s.version {
+		s.version[i], _ = strconv.Atoi(match[i+1])
+	}
+
+	return s, nil
+}
+
+func (s serverVersion) String() string {
+	return s.raw
+}
+
+func (s serverVersion) atLeast(version [3]int) bool {
+	for i := range version {
+		if s.version[i] != version[i] {
+			return s.version[i] > version[i]
+		}
+	}
+
+	return true
+}
+
+// serverFeature is a feature, that is available since the version of MySQL and MariaDB.
+// Zero version means the feature is not available.
+type serverFeature struct {
+	name    string
+	mysql   [3]int
+	mariaDB [3]int
+}
+
+var (
+	renameColumnFeature      = serverFeature{"RENAME COLUMN", [3]int{8, 0, 0}, [3]int{10, 5, 2}}
+	defaultExpressionFeature = serverFeature{"Default value expression", [3]int{8, 0, 13}, [3]int{10, 2, 1}}
+	instantFeature           = serverFeature{"ALGORITHM=INSTANT", [3]int{8, 0, 12}, [3]int{10, 3, 2}}
+	uuidToBinFeature         = serverFeature{"UUID_TO_BIN()", [3]int{8, 0, 0}, [3]int{}}
+)
+
+func (s serverVersion) supports(f serverFeature) bool {
+	if s.mariaDB {
+		return f.mariaDB != [3]int{} && s.atLeast(f.mariaDB)
+	}
+
+	return s.atLeast(f.mysql)
+}
+
+// require returns error with required version, if the feature is not supported by the server
+func (s serverVersion) require(f serverFeature, subject string) error {
+	if s.supports(f) {
+		return nil
+	}
+
+	flavor, version := "MySQL", f.mysql
+	if s.mariaDB {
+		flavor, version = "MariaDB", f.mariaDB
+	}
+
+	if version == [3]int{} {
+		return fmt.Errorf("%s %sis not supported by %s, server version is %s", f.name, subject, flavor, s)
+	}
+
+	return fmt.Errorf(
+		"%s %srequires %s %d.%d.%d or later, server version is %s",
+		f.name,
+		subject,
+		flavor,
+		version[0],
+		version[1],
+		version[2],
+		s,
+	)
+}
+
+// detectServer reads version of MySQL server, when some of the schemas depend on it,
+// and checks commands of the schemas against it before anything runs.
+func (m *Migrator) detectServer(db executableSQL, pending []Migration, schemas []Schema) error {
+	if !isMySQL(m.dialect()) || m.server != nil {
+		return m.checkServer(pending, schemas)
+	}
+
+	var dependent bool
+	for _, s := range schemas {
+		for _, c := range s.pool {
+			dependent = dependent || dependsOnVersion(c)
+		}
+	}
+
+	if !dependent && m.ServerVersion == "" {
+		return nil
+	}
+
+	version := m.ServerVersion
+	if version == "" {
+		if err := db.QueryRow("SELECT VERSION()").Scan(&version); err != nil {
+			return fmt.Errorf("Server version failed to be detected: %v", err)
+		}
+	}
+
+	server, err := parseServerVersion(version)
+	if err != nil {
+		return err
+	}
+	m.server = &server
+
+	return m.checkServer(pending, schemas)
+}
+
+func (m Migrator) checkServer(pending []Migration, schemas []Schema) error {
+	if m.server == nil {
+		return nil
+	}
+
+	for i, s := range schemas {
+		if _, err := m.migrationCommands(pending[i], s); err != nil {
+			return fmt.Errorf(`Migration "%s" can't be run: %v`, pending[i].Name, err)
+		}
+	}
+
+	return nil
+}
+
+// dependsOnVersion reports whether command can be run only on some versions of MySQL or MariaDB
+func dependsOnVersion(c command) bool {
+	switch v := c.(type) {
+	case createTableCommand:
+		for _, col := range v.t.columns {
+			if isDefaultExpression(columnDefault(col.definition)) {
+				return true
+			}
+		}
+	case alterTableCommand:
+		if strings.ToUpper(v.options.Algorithm) == "INSTANT" {
+			return true
+		}
+
+		for _, tc := range v.pool {
+			if _, ok := tc.(RenameColumnCommand); ok {
+				return true
+			}
+
+			if _, col, ok := alteredColumn(tc); ok && isDefaultExpression(columnDefault(col)) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// adapt replaces commands unsupported by the server with equivalent ones,
+// or returns error with required version of the server.
+func (s serverVersion) adapt(c command) (command, error) {
+	switch v := c.(type) {
+	case createTableCommand:
+		t := v.t
+		t.columns = make(columns, len(v.t.columns))
+
+		for i, col := range v.t.columns {
+			definition, err := s.adaptColumn(col.field, col.definition)
+			if err != nil {
+				return nil, err
+			}
+
+			t.columns[i] = column{field: col.field, definition: definition}
+		}
+
+		return createTableCommand{t}, nil
+	case alterTableCommand:
+		if strings.ToUpper(v.options.Algorithm) == "INSTANT" {
+			if err := s.require(instantFeature, fmt.Sprintf(`to alter table "%s" `, v.name)); err != nil {
+				return nil, err
+			}
+		}
+
+		alter := v
+		alter.pool = make(TableCommands, len(v.pool))
+		var renames bool
+
+		for i, tc := range v.pool {
+			alter.pool[i] = tc
+
+			switch cmd := tc.(type) {
+			case RenameColumnCommand:
+				renames = renames || !s.supports(renameColumnFeature)
+			case AddColumnCommand:
+				definition, err := s.adaptColumn(cmd.Name, cmd.Column)
+				if err != nil {
+					return nil, err
+				}
+				cmd.Column = definition
+				alter.pool[i] = cmd
+			case ModifyColumnCommand:
+				definition, err := s.adaptColumn(cmd.Name, cmd.Column)
+				if err != nil {
+					return nil, err
+				}
+				cmd.Column = definition
+				alter.pool[i] = cmd
+			case ChangeColumnCommand:
+				definition, err := s.adaptColumn(cmd.To, cmd.Column)
+				if err != nil {
+					return nil, err
+				}
+				cmd.Column = definition
+				alter.pool[i] = cmd
+			}
+		}
+
+		if renames {
+			return renameColumnFallback{alter}, nil
+		}
+
+		return alter, nil
+	}
+
+	return c, nil
+}
+
+// adaptColumn checks default value expression of the column,
+// UUID_TO_BIN(UUID()) is replaced with UNHEX() of UUID without dashes for MariaDB
+func (s serverVersion) adaptColumn(name string, c columnType) (columnType, error) {
+	def := columnDefault(c)
+	if !isDefaultExpression(def) {
+		return c, nil
+	}
+
+	subject := fmt.Sprintf(`%s of column "%s" `, def, name)
+	if err := s.require(defaultExpressionFeature, subject); err != nil {
+		return nil, err
+	}
+
+	if !strings.Contains(strings.ToUpper(def), "UUID_TO_BIN(") || s.supports(uuidToBinFeature) {
+		return c, nil
+	}
+
+	if b, ok := c.(Binary); ok && strings.ToUpper(b.Default) == "(UUID_TO_BIN(UUID()))" {
+		b.Default = "(UNHEX(REPLACE(UUID(), '-', '')))"
+		return b, nil
+	}
+
+	return nil, s.require(uuidToBinFeature, subject)
+}
+
+func isDefaultExpression(def string) bool {
+	return strings.HasPrefix(def, "(") && strings.HasSuffix(def, ")")
+}
+
+// alteredColumn returns column, that is added or changed by the table command
+func alteredColumn(c command) (name string, definition columnType, ok bool) {
+	switch v := c.(type) {
+	case AddColumnCommand:
+		return v.Name, v.Column, true
+	case ModifyColumnCommand:
+		return v.Name, v.Column, true
+	case ChangeColumnCommand:
+		return v.To, v.Column, true
+	}
+
+	return "", nil, false
+}
+
+func columnDefault(c columnType) string {
+	switch v := c.(type) {
+	case Integer:
+		return v.Default
+	case Floatable:
+		return v.Default
+	case Timable:
+		return v.Default
+	case String:
+		return v.Default
+	case Text:
+		return v.Default
+	case JSON:
+		return v.Default
+	case Enum:
+		return v.Default
+	case Bit:
+		return v.Default
+	case Binary:
+		return v.Default
+	}
+
+	return ""
+}
+
+// renameColumnFallback alters table with `CHANGE` instead of `RENAME COLUMN`,
+// that isn't supported by the server. Current definition of the column is read with SHOW CREATE TABLE.
+type renameColumnFallback struct {
+	alter alterTableCommand
+}
+
+func (c renameColumnFallback) toSQL() string {
+	return c.alter.toSQL()
+}
+
+func (c renameColumnFallback) exec(db executableSQL) error {
+	if c.alter.toSQL() == "" {
+		return ErrNoSQLCommandsToRun
+	}
+
+	var table, create string
+	if err := db.QueryRow(fmt.Sprintf("SHOW CREATE TABLE `%s`", c.alter.name)).Scan(&table, &create); err != nil {
+		return err
+	}
+
+	alter := c.alter
+	alter.pool = make(TableCommands, len(c.alter.pool))
+
+	for i, tc := range c.alter.pool {
+		alter.pool[i] = tc
+
+		if r, ok := tc.(RenameColumnCommand); ok {
+			definition, err := showColumnDefinition(create, r.Old)
+			if err != nil {
+				return fmt.Errorf(`Column "%s" can't be renamed: %v`, r.Old, err)
+			}
+
+			alter.pool[i] = ChangeColumnCommand{From: r.Old, To: r.New, Column: rawColumn(definition)}
+		}
+	}
+
+	_, err := db.Exec(alter.toSQL())
+
+	return err
+}
+
+// showColumnDefinition returns definition of the column from the output of SHOW CREATE TABLE
+func showColumnDefinition(create string, name string) (string, error) {
+	prefix := "`" + name + "` "
+
+	for _, line := range strings.Split(create, "\n") {
+		line = strings.TrimSpace(line)
+		if strings.HasPrefix(line, prefix) {
+			return strings.TrimSuffix(strings.TrimPrefix(line, prefix), ","), nil
+		}
+	}
+
+	return "", fmt.Errorf(`column "%s" does not exist`, name)
+}
+
+// rawColumn is a column definition, that is used as it is
+type rawColumn string
+
+func (c rawColumn) buildRow() string {
+	return string(c)
+}
@@ -0,0 +1,272 @@
+package migrator
+
+import (
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseServerVersion(t *testing.T) {
+	cases := []struct {
+		raw     string
+		mariaDB bool
+		version [3]int
+	}{
+		{"8.0.36", false, [3]int{8, 0, 36}},
+		{"5.7.44-log", false, [3]int{5, 7, 44}},
+		{"8.4", false, [3]int{8, 4, 0}},
+		{"10.6.12-MariaDB-1:10.6.12+maria~ubu2004", true, [3]int{10, 6, 12}},
+		{"5.5.5-10.3.38-MariaDB", true, [3]int{10, 3, 38}},
+	}
+
+	for _, c := range cases {
+		v, err := parseServerVersion(c.raw)
+
+		assert.Nil(t, err)
+		assert.Equal(t, serverVersion{raw: c.raw, mariaDB: c.mariaDB, version: c.version}, v)
+	}
+
+	_, err := parseServerVersion("unknown")
+	assert.EqualError(t, err, `Unknown server version "unknown"`)
+}
+
+func TestServerVersionRequire(t *testing.T) {
+	mysql57, _ := parseServerVersion("5.7.44")
+	mysql8, _ := parseServerVersion("8.0.12")
+	mariaDB, _ := parseServerVersion("10.4.32-MariaDB")
+
+	assert.True(t, mysql8.supports(renameColumnFeature))
+	assert.True(t, mysql8.supports(instantFeature))
+	assert.False(t, mysql8.supports(defaultExpressionFeature))
+	assert.True(t, mariaDB.supports(defaultExpressionFeature))
+	assert.False(t, mariaDB.supports(renameColumnFeature))
+
+	assert.Nil(t, mysql8.require(renameColumnFeature, ""))
+	assert.EqualError(
+		t,
+		mysql57.require(renameColumnFeature, ""),
+		"RENAME COLUMN requires MySQL 8.0.0 or later, server version is 5.7.44",
+	)
+	assert.EqualError(
+		t,
+		mariaDB.require(uuidToBinFeature, `of column "id" `),
+		`UUID_TO_BIN() of column "id" is not supported by MariaDB, server version is 10.4.32-MariaDB`,
+	)
+}
+
+func TestDependsOnVersion(t *testing.T) {
+	table := Table{Name: "posts"}
+	table.Int("rating", 11, false)
+	assert.False(t, dependsOnVersion(createTableCommand{table}))
+
+	table.UniqueID("id")
+	assert.True(t, dependsOnVersion(createTableCommand{table}))
+
+	assert.False(t, dependsOnVersion(alterTableCommand{name: "posts", pool: TableCommands{DropColumnCommand("title")}}))
+	assert.True(t, dependsOnVersion(alterTableCommand{name: "posts", pool: TableCommands{DropColumnCommand("title")}, options: AlterOptions{Algorithm: "instant"}}))
+	assert.True(t, dependsOnVersion(alterTableCommand{name: "posts", pool: TableCommands{RenameColumnCommand{Old: "a", New: "b"}}}))
+	assert.True(t, dependsOnVersion(alterTableCommand{name: "posts", pool: TableCommands{
+		AddColumnCommand{Name: "uuid", Column: String{Fixed: true, Precision: 36, Default: "(UUID())"}},
+	}}))
+	assert.False(t, dependsOnVersion(dropTableCommand{table: "posts"}))
+}
+
+func TestServerVersionAdapt(t *testing.T) {
+	mysql57, _ := parseServerVersion("5.7.44")
+	mysql8, _ := parseServerVersion("8.0.36")
+	mariaDB, _ := parseServerVersion("10.6.12-MariaDB")
+
+	t.Run("it keeps supported commands", func(t *testing.T) {
+		c := alterTableCommand{name: "posts", pool: TableCommands{RenameColumnCommand{Old: "title", New: "name"}}}
+
+		adapted, err := mysql8.adapt(c)
+
+		assert.Nil(t, err)
+		assert.Equal(t, c, adapted)
+	})
+
+	t.Run("it falls back to CHANGE to rename column", func(t *testing.T) {
+		c := alterTableCommand{name: "posts", pool: TableCommands{
+			RenameColumnCommand{Old: "title", New: "name"},
+			DropColumnCommand("legacy"),
+		}}
+
+		adapted, err := mysql57.adapt(c)
+
+		assert.Nil(t, err)
+		assert.Equal(t, renameColumnFallback{c}, adapted)
+	})
+
+	t.Run("it refuses default expressions on older MySQL", func(t *testing.T) {
+		table := Table{Name: "posts"}
+		table.UniqueID("id")
+
+		_, err := mysql57.adapt(createTableCommand{table})
+
+		assert.EqualError(t, err, `Default value expression (UUID()) of column "id" requires MySQL 8.0.13 or later, server version is 5.7.44`)
+	})
+
+	t.Run("it refuses instant algorithm on older MySQL", func(t *testing.T) {
+		c := alterTableCommand{name: "posts", pool: TableCommands{DropColumnCommand("title")}, options: AlterOptions{Algorithm: "INSTANT"}}
+
+		_, err := mysql57.adapt(c)
+
+		assert.EqualError(t, err, `ALGORITHM=INSTANT to alter table "posts" requires MySQL 8.0.12 or later, server version is 5.7.44`)
+	})
+
+	t.Run("it replaces UUID_TO_BIN for MariaDB", func(t *testing.T) {
+		table := Table{Name: "posts"}
+		table.BinaryID("id")
+
+		adapted, err := mariaDB.adapt(createTableCommand{table})
+
+		assert.Nil(t, err)
+		assert.Contains(t, adapted.toSQL(), "`id` binary(16) NOT NULL DEFAULT (UNHEX(REPLACE(UUID(), '-', '')))")
+		assert.Contains(t, createTableCommand{table}.toSQL(), "UUID_TO_BIN", "original table is not changed")
+
+		adapted, err = mariaDB.adapt(alterTableCommand{name: "posts", pool: TableCommands{
+			ModifyColumnCommand{Name: "id", Column: Binary{Fixed: true, Precision: 16, Default: "(UUID_TO_BIN(UUID()))"}},
+		}})
+
+		assert.Nil(t, err)
+		assert.Equal(t, "ALTER TABLE `posts` MODIFY `id` binary(16) NOT NULL DEFAULT (UNHEX(REPLACE(UUID(), '-', '')))", adapted.toSQL())
+	})
+
+	t.Run("it refuses other UUID_TO_BIN expressions for MariaDB", func(t *testing.T) {
+		_, err := mariaDB.adapt(alterTableCommand{name: "posts", pool: TableCommands{
+			AddColumnCommand{Name: "uid", Column: Binary{Fixed: true, Precision: 16, Default: "(UUID_TO_BIN(UUID(), 1))"}},
+		}})
+
+		assert.EqualError(t, err, `UUID_TO_BIN() (UUID_TO_BIN(UUID(), 1)) of column "uid" is not supported by MariaDB, server version is 10.6.12-MariaDB`)
+	})
+}
+
+func TestRenameColumnFallback(t *testing.T) {
+	c := renameColumnFallback{alterTableCommand{name: "posts", pool: TableCommands{
+		RenameColumnCommand{Old: "title", New: "name"},
+		DropColumnCommand("legacy"),
+	}}}
+	create := "CREATE TABLE `posts` (\n" +
+		"  `id` bigint(20) unsigned NOT NULL AUTO_INCREMENT,\n" +
+		"  `title` varchar(255) COLLATE utf8mb4_unicode_ci NOT NULL DEFAULT '' COMMENT 'Title',\n" +
+		"  PRIMARY KEY (`id`)\n" +
+		") ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci"
+
+	t.Run("it renders original command", func(t *testing.T) {
+		assert.Equal(t, "ALTER TABLE `posts` RENAME COLUMN `title` TO `name`, DROP COLUMN `legacy`", c.toSQL())
+	})
+
+	t.Run("it fails on missing column", func(t *testing.T) {
+		db, mock, resetDB := testDBConnection(t)
+		defer resetDB()
+
+		mock.ExpectQuery("SHOW CREATE TABLE `posts`").
+			WillReturnRows(sqlmock.NewRows([]string{"Table", "Create Table"}).AddRow("posts", "CREATE TABLE `posts` (\n  `id` int\n)"))
+
+		assert.EqualError(t, c.exec(db), `Column "title" can't be renamed: column "title" does not exist`)
+	})
+
+	t.Run("it changes column with its current definition", func(t *testing.T) {
+		db, mock, resetDB := testDBConnection(t)
+		defer resetDB()
+
+		mock.ExpectQuery("SHOW CREATE TABLE `posts`").
+			WillReturnRows(sqlmock.NewRows([]string{"Table", "Create Table"}).AddRow("posts", create))
+		mock.ExpectExec(regexp.QuoteMeta(
+			"ALTER TABLE `posts` CHANGE `title` `name` varchar(255) COLLATE utf8mb4_unicode_ci NOT NULL DEFAULT '' COMMENT 'Title', DROP COLUMN `legacy`",
+		)).WillReturnResult(sqlmock.NewResult(0, 0))
+
+		assert.Nil(t, c.exec(db))
+		assert.Nil(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestMigrateWithServerVersion(t *testing.T) {
+	rename := Migration{Name: "rename_title", Up: func() Schema {
+		var s Schema
+		s.AlterTable("posts", TableCommands{RenameColumnCommand{Old: "title", New: "name"}})
+		return s
+	}}
+	uuid := Migration{Name: "create_tags", Up: func() Schema {
+		t := Table{Name: "tags"}
+		t.UniqueID("id")
+
+		var s Schema
+		s.CreateTable(t)
+		return s
+	}}
+	executed := func() *sqlmock.Rows {
+		return sqlmock.NewRows([]string{"id", "name", "batch", "applied_at"}).AddRow(1, "done", 1, time.Now())
+	}
+
+	t.Run("it fails when version can't be detected", func(t *testing.T) {
+		m := Migrator{Pool: []Migration{rename}}
+		db, mock, resetDB := testDBConnection(t)
+		defer resetDB()
+
+		mock.ExpectQuery("SELECT").WillReturnRows(sqlmock.NewRows(nil))
+		mock.ExpectQuery("SELECT id, name, batch, applied_at FROM `migrations`").WillReturnRows(executed())
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT VERSION()")).WillReturnError(errTestDBQueryFailed)
+
+		_, err := m.Migrate(db)
+
+		assert.EqualError(t, err, "Server version failed to be detected: "+errTestDBQueryFailed.Error())
+	})
+
+	t.Run("it refuses unsupported commands before anything runs", func(t *testing.T) {
+		m := Migrator{Pool: []Migration{rename, uuid}}
+		db, mock, resetDB := testDBConnection(t)
+		defer resetDB()
+
+		mock.ExpectQuery("SELECT").WillReturnRows(sqlmock.NewRows(nil))
+		mock.ExpectQuery("SELECT id, name, batch, applied_at FROM `migrations`").WillReturnRows(executed())
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT VERSION()")).WillReturnRows(sqlmock.NewRows([]string{"VERSION()"}).AddRow("5.7.44-log"))
+
+		migrated, err := m.Migrate(db)
+
+		assert.Len(t, migrated, 0)
+		assert.EqualError(t, err, `Migration "create_tags" can't be run: Default value expression (UUID()) of column "id" requires MySQL 8.0.13 or later, server version is 5.7.44-log`)
+		assert.Nil(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("it uses configured version and falls back to CHANGE", func(t *testing.T) {
+		m := Migrator{Pool: []Migration{rename}, ServerVersion: "5.7.44"}
+		db, mock, resetDB := testDBConnection(t)
+		defer resetDB()
+
+		mock.ExpectQuery("SELECT").WillReturnRows(sqlmock.NewRows(nil))
+		mock.ExpectQuery("SELECT id, name, batch, applied_at FROM `migrations`").WillReturnRows(executed())
+		mock.ExpectQuery("SHOW CREATE TABLE `posts`").
+			WillReturnRows(sqlmock.NewRows([]string{"Table", "Create Table"}).AddRow("posts", "CREATE TABLE `posts` (\n  `title` text NOT NULL\n)"))
+		mock.ExpectExec("ALTER TABLE `posts` CHANGE `title` `name` text NOT NULL").WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec(`INSERT INTO .* VALUES \("rename_title", 2\)`).WillReturnResult(sqlmock.NewResult(1, 1))
+
+		migrated, err := m.Migrate(db)
+
+		assert.Nil(t, err)
+		assert.Equal(t, []string{"rename_title"}, migrated)
+		assert.Nil(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("it detects version to rollback", func(t *testing.T) {
+		m := Migrator{Pool: []Migration{rename}}
+		db, mock, resetDB := testDBConnection(t)
+		defer resetDB()
+
+		mock.ExpectQuery("SELECT").WillReturnRows(sqlmock.NewRows(nil))
+		mock.ExpectQuery("SELECT id, name, batch, applied_at FROM `migrations`").
+			WillReturnRows(sqlmock.NewRows([]string{"id", "name", "batch", "applied_at"}).AddRow(2, "rename_title", 1, time.Now()))
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT VERSION()")).WillReturnRows(sqlmock.NewRows([]string{"VERSION()"}).AddRow("10.11.6-MariaDB"))
+		mock.ExpectExec("ALTER TABLE `posts` RENAME COLUMN `name` TO `title`").WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec("DELETE FROM `migrations`").WithArgs(2).WillReturnResult(sqlmock.NewResult(1, 1))
+
+		reverted, err := m.Rollback(db)
+
+		assert.Nil(t, err)
+		assert.Equal(t, []string{"rename_title"}, reverted)
+		assert.Nil(t, mock.ExpectationsWereMet())
+	})
+}